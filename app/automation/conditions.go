@@ -0,0 +1,140 @@
+package automation
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/models"
+)
+
+var placeholderPattern = regexp.MustCompile(`\{([A-Za-z0-9_.]+)\}`)
+
+// Context is the set of fields a rule's conditions and placeholders see:
+// the event's payload, flattened with dots for nested fields, plus the
+// fields added by buildContext
+type Context map[string]interface{}
+
+// flatten copies a payload into ctx, naming nested fields "parent.child"
+func flatten(ctx Context, prefix string, data map[string]interface{}) {
+	for key, value := range data {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			flatten(ctx, key, nested)
+			continue
+		}
+		ctx[key] = value
+	}
+}
+
+// addTime adds the clock fields used for time windows
+func (ctx Context) addTime(now time.Time) {
+	ctx["hour"] = now.Hour()
+	ctx["minute"] = now.Minute()
+	ctx["weekday"] = int(now.Weekday())
+	ctx["day"] = strings.ToLower(now.Weekday().String()[:3])
+	ctx["time"] = now.Format("15:04")
+}
+
+// String returns a field as text, or "" when it's missing
+func (ctx Context) String(field string) string {
+	value, ok := ctx[field]
+	if !ok || value == nil {
+		return ""
+	}
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Expand replaces {field} placeholders. Missing fields become empty.
+func (ctx Context) Expand(template string) string {
+	return placeholderPattern.ReplaceAllStringFunc(template, func(match string) string {
+		return ctx.String(match[1 : len(match)-1])
+	})
+}
+
+// Matches reports whether every condition holds
+func (ctx Context) Matches(conditions []models.AutomationCondition) bool {
+	for _, cond := range conditions {
+		if !ctx.holds(cond) {
+			return false
+		}
+	}
+	return true
+}
+
+func (ctx Context) holds(cond models.AutomationCondition) bool {
+	_, present := ctx[cond.Field]
+	if cond.Op == models.ConditionExists {
+		return present
+	}
+	if !present {
+		return false
+	}
+	actual := ctx.String(cond.Field)
+
+	switch cond.Op {
+	case models.ConditionEq:
+		return compare(actual, cond.Value) == 0
+	case models.ConditionNe:
+		return compare(actual, cond.Value) != 0
+	case models.ConditionGt:
+		return compare(actual, cond.Value) > 0
+	case models.ConditionGte:
+		return compare(actual, cond.Value) >= 0
+	case models.ConditionLt:
+		return compare(actual, cond.Value) < 0
+	case models.ConditionLte:
+		return compare(actual, cond.Value) <= 0
+	case models.ConditionContains:
+		return strings.Contains(strings.ToLower(actual), strings.ToLower(cond.Value))
+	case models.ConditionMatches:
+		expr, err := regexp.Compile("(?i)" + cond.Value)
+		return err == nil && expr.MatchString(actual)
+	case models.ConditionIn:
+		for _, item := range strings.Split(cond.Value, ",") {
+			if compare(actual, strings.TrimSpace(item)) == 0 {
+				return true
+			}
+		}
+		return false
+	case models.ConditionBetween:
+		low, high, ok := models.SplitBetween(cond.Value)
+		if !ok {
+			return false
+		}
+		if compare(low, high) <= 0 {
+			return compare(actual, low) >= 0 && compare(actual, high) <= 0
+		}
+		// Wraps around, e.g. 22:00-06:00, or weekday 5-1 for Friday to Monday
+		return compare(actual, low) >= 0 || compare(actual, high) <= 0
+	}
+	return false
+}
+
+// compare orders two values numerically when both are numbers, otherwise
+// as case-insensitive strings
+func compare(a, b string) int {
+	x, errA := strconv.ParseFloat(strings.TrimSpace(a), 64)
+	y, errB := strconv.ParseFloat(strings.TrimSpace(b), 64)
+	if errA == nil && errB == nil {
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
@@ -0,0 +1,504 @@
+// Package automation runs admin-defined rules: when an event is published
+// or a server's stats snapshot comes in and a rule's conditions hold, its
+// actions fire.
+package automation
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/database"
+	"github.com/ethanburkett/goadmin/app/events"
+	"github.com/ethanburkett/goadmin/app/logger"
+	"github.com/ethanburkett/goadmin/app/models"
+	"github.com/ethanburkett/goadmin/app/rcon"
+	"github.com/ethanburkett/goadmin/app/supervisor"
+	"github.com/ethanburkett/goadmin/app/watcher"
+	"github.com/ethanburkett/goadmin/app/webhook"
+	"go.uber.org/zap"
+)
+
+// queueSize is how many triggers can wait for the engine before new ones
+// are dropped
+const queueSize = 256
+
+// MaxGroupPower is the most powerful group a rule may assign. Admin groups
+// (power 50 and up) are only granted by people.
+const MaxGroupPower = 49
+
+// Triggers lists what rules can fire on. High-volume events (damage and
+// weapon switches) aren't offered.
+var Triggers = []string{
+	string(events.PlayerConnect),
+	string(events.PlayerDisconnect),
+	string(events.PlayerChat),
+	string(events.PlayerTeamChat),
+	string(events.PlayerKill),
+	string(events.PlayerAction),
+	string(events.PlayerNameChange),
+	string(events.MapStart),
+	string(events.MapEnd),
+	string(events.RoundEnd),
+	string(events.PlayerBanned),
+	string(events.PlayerUnbanned),
+	string(events.PlayerKicked),
+	string(events.PlayerFlagged),
+	string(events.ReportCreated),
+	string(events.ReportActioned),
+	models.AutomationTriggerSnapshot,
+}
+
+// IsTrigger reports whether rules can fire on name
+func IsTrigger(name string) bool {
+	for _, trigger := range Triggers {
+		if trigger == name {
+			return true
+		}
+	}
+	return false
+}
+
+// AssignableGroup looks up the group a group action names. The name must be
+// literal, not a placeholder, and the group no more powerful than
+// MaxGroupPower; it is checked when a rule is saved and again when it fires.
+func AssignableGroup(name string) (*models.Group, error) {
+	if strings.ContainsAny(name, "{}") {
+		return nil, fmt.Errorf("group must be named, not a placeholder")
+	}
+	group, err := models.GetGroupByName(strings.TrimSpace(name))
+	if err != nil {
+		return nil, fmt.Errorf("group '%s' not found", name)
+	}
+	if group.Power > MaxGroupPower {
+		return nil, fmt.Errorf("group '%s' has power %d, rules can assign up to %d", group.Name, group.Power, MaxGroupPower)
+	}
+	return group, nil
+}
+
+// AssignableTarget checks that a group action may move a player. Admins
+// (power above MaxGroupPower) and players already at or above the group's
+// power are left alone, so a rule can only promote and never demotes an
+// admin. It is checked when a rule naming a GUID is saved and again when
+// any group rule fires.
+func AssignableTarget(guid string, group *models.Group) error {
+	power := models.GetPlayerPower(guid)
+	if power > MaxGroupPower {
+		return fmt.Errorf("player %s has power %d, rules can't change admin groups", guid, power)
+	}
+	if power >= group.Power {
+		return fmt.Errorf("player %s already has power %d, at least that of group '%s'", guid, power, group.Name)
+	}
+	return nil
+}
+
+// trigger is an event or snapshot waiting to be evaluated
+type trigger struct {
+	name     string
+	serverID *uint
+	data     map[string]interface{}
+	at       time.Time
+}
+
+// Engine evaluates the enabled rules against events and snapshots on a
+// single goroutine
+type Engine struct {
+	servers  *supervisor.Supervisor
+	queue    chan trigger
+	stopChan chan bool
+
+	mu    sync.RWMutex
+	rules []models.AutomationRule
+
+	// Only touched by the engine goroutine
+	snapshots map[uint]watcher.Snapshot
+	holding   map[string]bool // Snapshot rules whose conditions held last time, by rule and server
+	lastFired map[string]time.Time
+}
+
+// Global is the running engine, set at startup
+var Global *Engine
+
+// NewEngine creates an engine acting on the given servers
+func NewEngine(servers *supervisor.Supervisor) *Engine {
+	return &Engine{
+		servers:   servers,
+		queue:     make(chan trigger, queueSize),
+		stopChan:  make(chan bool),
+		snapshots: make(map[uint]watcher.Snapshot),
+		holding:   make(map[string]bool),
+		lastFired: make(map[string]time.Time),
+	}
+}
+
+// Start loads the rules and begins evaluating triggers
+func (e *Engine) Start() {
+	logger.Info("Starting automation engine")
+	if err := e.Reload(); err != nil {
+		logger.Error("Failed to load automation rules", zap.Error(err))
+	}
+	go e.run()
+}
+
+// Stop halts the engine. Queued triggers are discarded.
+func (e *Engine) Stop() {
+	logger.Info("Stopping automation engine")
+	close(e.stopChan)
+}
+
+// Reload reads the enabled rules from the database. Call it whenever rules
+// change.
+func (e *Engine) Reload() error {
+	rules, err := models.GetEnabledAutomationRules()
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	e.rules = rules
+	e.mu.Unlock()
+	return nil
+}
+
+// HandleEvent queues an event for evaluation. It is subscribed to the
+// event bus.
+func (e *Engine) HandleEvent(event events.Event) {
+	if event.Type.IsHighVolume() {
+		return
+	}
+	e.enqueue(trigger{
+		name:     string(event.Type),
+		serverID: event.ServerID,
+		data:     event.DataMap(),
+		at:       event.Timestamp,
+	})
+}
+
+// HandleSnapshot queues a stats snapshot for evaluation
+func (e *Engine) HandleSnapshot(snapshot watcher.Snapshot) {
+	e.enqueue(trigger{
+		name:     models.AutomationTriggerSnapshot,
+		serverID: snapshot.ServerID,
+		data: map[string]interface{}{
+			"players":     snapshot.Players,
+			"max_players": snapshot.MaxPlayers,
+			"map":         snapshot.Map,
+			"gametype":    snapshot.GameType,
+		},
+		at: snapshot.At,
+	})
+}
+
+func (e *Engine) enqueue(t trigger) {
+	select {
+	case e.queue <- t:
+	default:
+		logger.Warn("Automation queue is full, dropping trigger", zap.String("trigger", t.name))
+	}
+}
+
+func (e *Engine) run() {
+	for {
+		select {
+		case t := <-e.queue:
+			e.process(t)
+		case <-e.stopChan:
+			return
+		}
+	}
+}
+
+// process evaluates the rules for one trigger. Snapshot rules fire when
+// their conditions start to hold, not on every snapshot while they do.
+func (e *Engine) process(t trigger) {
+	if t.name == models.AutomationTriggerSnapshot && t.serverID != nil {
+		e.snapshots[*t.serverID] = watcher.Snapshot{
+			ServerID:   t.serverID,
+			Players:    intField(t.data, "players"),
+			MaxPlayers: intField(t.data, "max_players"),
+			Map:        fmt.Sprint(t.data["map"]),
+			GameType:   fmt.Sprint(t.data["gametype"]),
+			At:         t.at,
+		}
+	}
+
+	rules := e.rulesFor(t)
+	if len(rules) == 0 {
+		return
+	}
+	ctx := e.buildContext(t)
+
+	var serverKey uint
+	if t.serverID != nil {
+		serverKey = *t.serverID
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		key := fmt.Sprintf("%d:%d", rule.ID, serverKey)
+		matched := ctx.Matches(rule.Conditions)
+
+		if t.name == models.AutomationTriggerSnapshot {
+			held := e.holding[key]
+			e.holding[key] = matched
+			if held {
+				continue
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		cooldown := time.Duration(rule.CooldownSeconds) * time.Second
+		if last, ok := e.lastFired[key]; ok && t.at.Sub(last) < cooldown {
+			continue
+		}
+		e.lastFired[key] = t.at
+
+		e.fire(rule, t, ctx)
+	}
+}
+
+// rulesFor returns the rules listening for a trigger on its server
+func (e *Engine) rulesFor(t trigger) []models.AutomationRule {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	var rules []models.AutomationRule
+	for _, rule := range e.rules {
+		if rule.Trigger != t.name {
+			continue
+		}
+		if rule.ServerID != nil && (t.serverID == nil || *rule.ServerID != *t.serverID) {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// buildContext gathers the fields rules see for a trigger: the payload,
+// the server's latest snapshot, the time, and the player's GUID, name,
+// slot and power when the payload names a player
+func (e *Engine) buildContext(t trigger) Context {
+	ctx := Context{}
+	flatten(ctx, "", t.data)
+	ctx["trigger"] = t.name
+	ctx.addTime(t.at)
+
+	if t.serverID != nil {
+		ctx["server_id"] = *t.serverID
+		if snapshot, ok := e.snapshots[*t.serverID]; ok {
+			for field, value := range map[string]interface{}{
+				"players":     snapshot.Players,
+				"max_players": snapshot.MaxPlayers,
+				"map":         snapshot.Map,
+				"gametype":    snapshot.GameType,
+			} {
+				if _, set := ctx[field]; !set {
+					ctx[field] = value
+				}
+			}
+		}
+	}
+
+	for field, aliases := range map[string][]string{
+		"guid": {"playerGUID", "player_guid"},
+		"name": {"playerName", "player_name"},
+		"slot": {"playerID", "player_id"},
+	} {
+		for _, alias := range aliases {
+			if value := ctx.String(alias); value != "" {
+				ctx[field] = value
+				break
+			}
+		}
+	}
+	if guid := ctx.String("guid"); guid != "" {
+		ctx["power"] = models.GetPlayerPower(guid)
+	}
+
+	return ctx
+}
+
+// fire runs a rule's actions, or only describes them in dry-run mode, and
+// records the run
+func (e *Engine) fire(rule *models.AutomationRule, t trigger, ctx Context) {
+	contextJSON, _ := json.Marshal(ctx)
+	run := &models.AutomationRun{
+		RuleID:    rule.ID,
+		ServerID:  t.serverID,
+		Trigger:   t.name,
+		DryRun:    rule.DryRun,
+		Success:   true,
+		Context:   string(contextJSON),
+		CreatedAt: time.Now(),
+	}
+
+	var failures []string
+	for _, action := range rule.Actions {
+		result, err := e.perform(rule, action, t, ctx)
+		if err != nil {
+			run.Success = false
+			failures = append(failures, err.Error())
+			result += " (failed: " + err.Error() + ")"
+		}
+		run.Results = append(run.Results, result)
+	}
+	run.Error = strings.Join(failures, "; ")
+
+	if err := models.RecordAutomationRun(run); err != nil {
+		logger.Error("Failed to record automation run", zap.Uint("rule_id", rule.ID), zap.Error(err))
+	}
+
+	if rule.DryRun {
+		logger.Info(fmt.Sprintf("Automation rule '%s' matched %s (dry run): %s", rule.Name, t.name, strings.Join(run.Results, "; ")))
+		return
+	}
+
+	metadataJSON, _ := json.Marshal(map[string]interface{}{
+		"trigger":   t.name,
+		"server_id": t.serverID,
+		"results":   run.Results,
+	})
+	models.CreateAuditLog(
+		database.DB,
+		nil,
+		"SYSTEM",
+		"",
+		models.ActionAutomationRun,
+		models.SourceSystem,
+		run.Success,
+		run.Error,
+		"automation_rule",
+		fmt.Sprintf("%d", rule.ID),
+		rule.Name,
+		string(metadataJSON),
+		fmt.Sprintf("Automation rule '%s' fired on %s: %s", rule.Name, t.name, strings.Join(run.Results, "; ")),
+	)
+}
+
+// perform carries out one action and describes it. In dry-run mode only
+// the description is produced.
+func (e *Engine) perform(rule *models.AutomationRule, action models.AutomationAction, t trigger, ctx Context) (string, error) {
+	switch action.Type {
+	case models.AutomationRcon:
+		command := ctx.expandCommand(action.Value)
+		if rule.DryRun {
+			return "rcon: " + command, nil
+		}
+		client, err := e.client(t.serverID)
+		if err == nil {
+			_, err = client.SendCommand(command)
+		}
+		return "rcon: " + command, err
+
+	case models.AutomationSay:
+		message := ctx.Expand(action.Value)
+		if rule.DryRun {
+			return "say: " + message, nil
+		}
+		client, err := e.client(t.serverID)
+		if err == nil {
+			_, err = client.Say(message)
+		}
+		return "say: " + message, err
+
+	case models.AutomationTell:
+		target := ctx.Expand(defaultString(action.Target, "{slot}"))
+		message := ctx.Expand(action.Value)
+		description := fmt.Sprintf("tell %s: %s", target, message)
+		if target == "" {
+			return description, fmt.Errorf("no player to tell")
+		}
+		if rule.DryRun {
+			return description, nil
+		}
+		client, err := e.client(t.serverID)
+		if err == nil {
+			_, err = client.Tell(target, message)
+		}
+		return description, err
+
+	case models.AutomationWebhook:
+		message := ctx.Expand(action.Value)
+		if rule.DryRun {
+			return "webhook: " + message, nil
+		}
+		data := map[string]interface{}{
+			"rule_id": rule.ID,
+			"rule":    rule.Name,
+			"trigger": t.name,
+			"message": message,
+			"context": ctx,
+		}
+		if t.serverID != nil {
+			data["server_id"] = *t.serverID
+		}
+		return "webhook: " + message, webhook.GlobalDispatcher.Dispatch(models.WebhookEventAutomation, data)
+
+	case models.AutomationGroup:
+		guid := ctx.Expand(defaultString(action.Target, "{guid}"))
+		description := fmt.Sprintf("group: %s -> %s", guid, action.Value)
+		if guid == "" {
+			return description, fmt.Errorf("no player to assign")
+		}
+		group, err := AssignableGroup(action.Value)
+		if err != nil {
+			return description, err
+		}
+		player, err := models.GetInGamePlayerByGUID(guid)
+		if err != nil {
+			return description, fmt.Errorf("player %s not found", guid)
+		}
+		if err := AssignableTarget(guid, group); err != nil {
+			return description, err
+		}
+		if rule.DryRun {
+			return description, nil
+		}
+		return description, models.AssignPlayerToGroup(player.ID, group.ID)
+	}
+
+	return action.Type, fmt.Errorf("unknown action type: %s", action.Type)
+}
+
+func (e *Engine) client(serverID *uint) (*rcon.Client, error) {
+	if serverID == nil {
+		return nil, fmt.Errorf("trigger has no server")
+	}
+	inst, ok := e.servers.Get(*serverID)
+	if !ok {
+		return nil, fmt.Errorf("server %d is not running", *serverID)
+	}
+	return inst.RCON, nil
+}
+
+// expandCommand fills placeholders in an rcon command. Values come from
+// players (names, chat), so characters that could end the command or start
+// another are removed.
+func (ctx Context) expandCommand(template string) string {
+	return placeholderPattern.ReplaceAllStringFunc(template, func(match string) string {
+		return strings.Map(func(r rune) rune {
+			if r == ';' || r == '"' || r == '\n' || r == '\r' {
+				return -1
+			}
+			return r
+		}, ctx.String(match[1:len(match)-1]))
+	})
+}
+
+func defaultString(value, fallback string) string {
+	if strings.TrimSpace(value) == "" {
+		return fallback
+	}
+	return value
+}
+
+func intField(data map[string]interface{}, field string) int {
+	if n, ok := data[field].(int); ok {
+		return n
+	}
+	return 0
+}
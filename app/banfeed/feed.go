@@ -0,0 +1,362 @@
+// Package banfeed shares bans between GoAdmin instances. Each instance
+// serves a signed feed of the GUID bans it issued; subscribers pull trusted
+// peers' feeds and apply them as bans or watch flags per peer policy.
+package banfeed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/events"
+	"github.com/ethanburkett/goadmin/app/logger"
+	"github.com/ethanburkett/goadmin/app/models"
+	"github.com/ethanburkett/goadmin/app/webhook"
+	"go.uber.org/zap"
+)
+
+// Headers used by the feed protocol
+const (
+	// InstanceHeader names the instance pulling a feed, so the publisher
+	// knows which peer secret to check and sign with
+	InstanceHeader = "X-Ban-Feed-Instance"
+	// TimestampHeader is when a pull was signed, in Unix seconds
+	TimestampHeader = "X-Ban-Feed-Timestamp"
+	// SignatureHeader carries webhook.SignPayload of the request (see
+	// requestPayload) on a pull, and of the body, which echoes the request,
+	// on the response
+	SignatureHeader = "X-Ban-Feed-Signature"
+)
+
+// MaxRequestAge is how far a pull's timestamp may be from the publisher's
+// clock, which bounds how long a captured request can be replayed
+const MaxRequestAge = 5 * time.Minute
+
+// PageSize is the most entries served per pull. Subscribers pull again from
+// the returned cursor until they catch up.
+const PageSize = 500
+
+// maxFeedSize caps a peer's response
+const maxFeedSize = 5 << 20
+
+// Entry is one ban in a feed. Revoked and expired bans are included with
+// Active false so subscribers can lift them.
+type Entry struct {
+	ID        string     `json:"id"`
+	GUID      string     `json:"guid"`
+	Category  string     `json:"category"`
+	BannedAt  time.Time  `json:"bannedAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	Active    bool       `json:"active"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// Feed is the document a publisher serves. It echoes the cursor and
+// timestamp of the pull it answers, so the signature over the body also
+// ties it to that request and a captured response can't be replayed to a
+// later pull.
+type Feed struct {
+	Instance    string    `json:"instance"` // Issuing instance of every entry
+	GeneratedAt time.Time `json:"generatedAt"`
+	Since       string    `json:"since,omitempty"`
+	After       string    `json:"after,omitempty"`
+	Timestamp   string    `json:"timestamp"` // TimestampHeader of the pull
+	Entries     []Entry   `json:"entries"`
+	More        bool      `json:"more"` // Another page follows
+}
+
+// SyncResult counts what a pull did
+type SyncResult struct {
+	Entries int `json:"entries"`
+	Banned  int `json:"banned"`
+	Flagged int `json:"flagged"`
+	Lifted  int `json:"lifted"`
+	Skipped int `json:"skipped"` // Already banned locally, or our own bans echoed back
+}
+
+// Build returns the feed of local bans changed after the (since, afterID)
+// cursor
+func Build(instance string, since *time.Time, afterID uint) (*Feed, error) {
+	bans, err := models.GetLocalBansUpdatedSince(since, afterID, PageSize+1)
+	if err != nil {
+		return nil, err
+	}
+
+	feed := &Feed{Instance: instance, GeneratedAt: time.Now(), Entries: []Entry{}}
+	if len(bans) > PageSize {
+		bans = bans[:PageSize]
+		feed.More = true
+	}
+	for _, ban := range bans {
+		feed.Entries = append(feed.Entries, Entry{
+			ID:        strconv.FormatUint(uint64(ban.ID), 10),
+			GUID:      ban.Value,
+			Category:  ban.Category,
+			BannedAt:  ban.CreatedAt,
+			ExpiresAt: ban.ExpiresAt,
+			Active:    ban.IsInEffect(),
+			UpdatedAt: ban.UpdatedAt,
+		})
+	}
+	return feed, nil
+}
+
+// Pull fetches a peer's feed from its cursor, applies it, and records the
+// outcome on the peer
+func Pull(client *http.Client, instance string, peer *models.BanFeedPeer) (*SyncResult, error) {
+	result := &SyncResult{}
+	var err error
+
+	for {
+		var feed *Feed
+		feed, err = fetch(client, instance, peer)
+		if err != nil {
+			break
+		}
+		for _, entry := range feed.Entries {
+			result.Entries++
+			if err = apply(peer, feed.Instance, entry, result); err != nil {
+				break
+			}
+			updated := entry.UpdatedAt
+			peer.Cursor = &updated
+			if id, parseErr := strconv.ParseUint(entry.ID, 10, 32); parseErr == nil {
+				peer.CursorID = uint(id)
+			}
+		}
+		if err != nil || !feed.More || len(feed.Entries) == 0 {
+			break
+		}
+	}
+
+	now := time.Now()
+	peer.LastPulledAt = &now
+	peer.LastError = ""
+	if err != nil {
+		peer.LastError = err.Error()
+	}
+	if saveErr := models.SaveBanFeedPeer(peer); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	return result, err
+}
+
+func fetch(client *http.Client, instance string, peer *models.BanFeedPeer) (*Feed, error) {
+	feedURL, err := url.Parse(peer.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid feed URL: %w", err)
+	}
+	var since, after string
+	if peer.Cursor != nil {
+		since = peer.Cursor.UTC().Format(time.RFC3339Nano)
+		after = strconv.FormatUint(uint64(peer.CursorID), 10)
+		q := feedURL.Query()
+		q.Set("since", since)
+		q.Set("after", after)
+		feedURL.RawQuery = q.Encode()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, feedURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(InstanceHeader, instance)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, webhook.SignPayload(requestPayload(instance, since, after, timestamp), peer.Secret))
+	req.Header.Set("User-Agent", "GoAdmin-BanFeed/1.0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("peer returned HTTP %d", resp.StatusCode)
+	}
+	if !webhook.VerifyPayload(body, peer.Secret, resp.Header.Get(SignatureHeader)) {
+		return nil, errors.New("feed signature does not match the peer's secret")
+	}
+
+	var feed Feed
+	if err := json.Unmarshal(body, &feed); err != nil {
+		return nil, fmt.Errorf("invalid feed: %w", err)
+	}
+	if feed.Instance != peer.Name {
+		return nil, fmt.Errorf("feed is from %q, expected %q", feed.Instance, peer.Name)
+	}
+	if feed.Since != since || feed.After != after || feed.Timestamp != timestamp {
+		return nil, errors.New("feed does not answer this request")
+	}
+	if age := time.Since(feed.GeneratedAt); age > MaxRequestAge || age < -MaxRequestAge {
+		return nil, errors.New("feed generation time is outside the allowed window")
+	}
+	return &feed, nil
+}
+
+// apply brings the local ban list and flags in line with one feed entry
+func apply(peer *models.BanFeedPeer, instance string, entry Entry, result *SyncResult) error {
+	guid := strings.ToLower(strings.TrimSpace(entry.GUID))
+	if guid == "" || entry.ID == "" {
+		result.Skipped++
+		return nil
+	}
+	if !models.ValidBanCategory(entry.Category) {
+		entry.Category = models.BanCategoryOther
+	}
+	active := entry.Active && (entry.ExpiresAt == nil || entry.ExpiresAt.After(time.Now()))
+
+	existing, err := models.GetFeedBan(peer.ID, entry.ID)
+	if err != nil {
+		return err
+	}
+
+	if !active {
+		if existing != nil && existing.Active {
+			if err := models.RevokeBan(existing.ID, instance, "Lifted on "+instance); err != nil && err != models.ErrBanNotFound {
+				return err
+			}
+			result.Lifted++
+		}
+		return models.DeactivateBanFlag(peer.ID, entry.ID)
+	}
+
+	if !peer.ShouldBan(entry.Category) {
+		result.Flagged++
+		return models.UpsertBanFlag(&models.BanFlag{
+			PeerID:          peer.ID,
+			RemoteBanID:     entry.ID,
+			GUID:            guid,
+			Category:        entry.Category,
+			IssuingInstance: instance,
+			BannedAt:        entry.BannedAt,
+			ExpiresAt:       entry.ExpiresAt,
+			Active:          true,
+		})
+	}
+
+	if existing != nil {
+		if existing.Active {
+			// Expiry may have been changed by the peer
+			existing.ExpiresAt = entry.ExpiresAt
+			existing.Category = entry.Category
+			return models.UpdateBan(existing)
+		}
+		// Lifted locally by an admin; don't reapply
+		result.Skipped++
+		return nil
+	}
+
+	if local, err := models.FindMatchingBan(guid, "", ""); err != nil {
+		return err
+	} else if local != nil {
+		result.Skipped++
+		return nil
+	}
+
+	ban := &models.Ban{
+		Type:         models.BanTypeGUID,
+		Value:        guid,
+		PlayerGUID:   guid,
+		Reason:       fmt.Sprintf("Banned on %s (%s)", instance, entry.Category),
+		Category:     entry.Category,
+		BannedByName: instance,
+		Source:       "feed:" + peer.Name,
+		ExpiresAt:    entry.ExpiresAt,
+		FeedPeerID:   &peer.ID,
+		FeedBanID:    entry.ID,
+		CreatedAt:    entry.BannedAt,
+	}
+	if err := models.CreateBan(ban); err != nil {
+		return err
+	}
+	result.Banned++
+
+	payload := events.BanPayload{
+		PlayerGUID: guid,
+		BannedBy:   instance,
+		Reason:     ban.Reason,
+		BanType:    "permanent",
+		Source:     "feed",
+		BanID:      &ban.ID,
+	}
+	if ban.ExpiresAt != nil {
+		payload.BanType = "temporary"
+		payload.ExpiresAt = ban.ExpiresAt.Format(time.RFC3339)
+	}
+	events.Publish(events.PlayerBanned, nil, payload)
+	return nil
+}
+
+// requestPayload is what a pull's signature covers
+func requestPayload(instance, since, after, timestamp string) []byte {
+	return []byte(instance + "\n" + since + "\n" + after + "\n" + timestamp)
+}
+
+// VerifyRequest checks a pull's signature against a peer's secret and that
+// it was signed within MaxRequestAge of now
+func VerifyRequest(instance, since, after, timestamp, signature, secret string, now time.Time) error {
+	if signature == "" || timestamp == "" {
+		return errors.New("request is not signed")
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("invalid request timestamp")
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > MaxRequestAge || age < -MaxRequestAge {
+		return errors.New("request timestamp is outside the allowed window")
+	}
+	if !webhook.VerifyPayload(requestPayload(instance, since, after, timestamp), secret, signature) {
+		return errors.New("request signature does not match the peer's secret")
+	}
+	return nil
+}
+
+// Sign serializes a feed and signs it with a peer's secret
+func Sign(feed *Feed, secret string) (body []byte, signature string, err error) {
+	body, err = json.Marshal(feed)
+	if err != nil {
+		return nil, "", err
+	}
+	return body, webhook.SignPayload(body, secret), nil
+}
+
+// SyncDue pulls every peer whose interval has passed
+func SyncDue(client *http.Client, instance string) {
+	peers, err := models.GetBanFeedPeers()
+	if err != nil {
+		logger.Error("Failed to load ban feed peers", zap.Error(err))
+		return
+	}
+
+	now := time.Now()
+	for i := range peers {
+		peer := &peers[i]
+		if !peer.PullDue(now) {
+			continue
+		}
+		result, err := Pull(client, instance, peer)
+		if err != nil {
+			logger.Warn("Ban feed pull failed", zap.String("peer", peer.Name), zap.Error(err))
+			continue
+		}
+		if result.Banned+result.Flagged+result.Lifted > 0 {
+			logger.Info("Pulled ban feed",
+				zap.String("peer", peer.Name),
+				zap.Int("banned", result.Banned),
+				zap.Int("flagged", result.Flagged),
+				zap.Int("lifted", result.Lifted))
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package banio
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ethanburkett/goadmin/app/models"
+)
+
+// B3 keeps bans in its MySQL penalties table, keyed by client. Export them
+// joined with the client's GUID, name and IP, e.g.
+//
+//	mysql -B -e "SELECT p.id, p.type, p.time_add, p.time_expire, p.reason,
+//	  p.inactive, c.guid, c.name, c.ip, a.name AS admin
+//	  FROM penalties p JOIN clients c ON c.id = p.client_id
+//	  LEFT JOIN clients a ON a.id = p.admin_id
+//	  WHERE p.type IN ('Ban', 'TempBan')" b3 > penalties.tsv
+//
+// Tab and comma separated files are both accepted, with any column order.
+// time_expire of -1 (B3's permanent) or 0 means no expiry.
+var b3Columns = []string{"id", "type", "time_add", "time_expire", "reason", "inactive", "guid", "name", "ip", "admin"}
+
+func parseB3(r io.Reader) ([]Record, []Skipped, error) {
+	br := bufio.NewReader(r)
+	peek, err := br.Peek(4096)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, nil, err
+	}
+
+	var read func() ([]string, error)
+	firstLine, _, _ := strings.Cut(string(peek), "\n")
+	if strings.Contains(firstLine, "\t") {
+		read = mysqlBatchReader(br)
+	} else {
+		reader := csv.NewReader(br)
+		reader.FieldsPerRecord = -1
+		read = reader.Read
+	}
+
+	columns, err := read()
+	if err != nil {
+		return nil, nil, errors.New("b3: missing header row")
+	}
+	index := make(map[string]int, len(columns))
+	for i, col := range columns {
+		index[strings.ToLower(strings.TrimSpace(col))] = i
+	}
+	if _, ok := index["guid"]; !ok {
+		return nil, nil, errors.New("b3: header has no guid column")
+	}
+
+	var records []Record
+	var skipped []Skipped
+	line := 1
+	for {
+		row, err := read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, nil, err
+		}
+
+		field := func(name string) string {
+			i, ok := index[name]
+			if !ok || i >= len(row) {
+				return ""
+			}
+			value := strings.TrimSpace(row[i])
+			if value == `\N` || strings.EqualFold(value, "NULL") {
+				return ""
+			}
+			return value
+		}
+
+		guid := field("guid")
+		penaltyType := field("type")
+		if penaltyType != "" && !strings.EqualFold(penaltyType, "Ban") && !strings.EqualFold(penaltyType, "TempBan") {
+			skipped = append(skipped, Skipped{Line: line, Value: guid, Reason: "not a ban: " + penaltyType})
+			continue
+		}
+		if field("inactive") == "1" {
+			skipped = append(skipped, Skipped{Line: line, Value: guid, Reason: "ban was lifted in B3"})
+			continue
+		}
+		if guid == "" {
+			skipped = append(skipped, Skipped{Line: line, Value: field("name"), Reason: "no GUID"})
+			continue
+		}
+
+		ban := models.Ban{
+			Type:         models.BanTypeGUID,
+			Value:        guid,
+			PlayerName:   field("name"),
+			PlayerGUID:   guid,
+			Reason:       field("reason"),
+			BannedByName: field("admin"),
+		}
+		if expire, err := strconv.ParseInt(field("time_expire"), 10, 64); err == nil {
+			ban.ExpiresAt = unixTime(expire)
+		}
+		if added, err := strconv.ParseInt(field("time_add"), 10, 64); err == nil {
+			if t := unixTime(added); t != nil {
+				ban.CreatedAt = *t
+			}
+		}
+		records = append(records, Record{Line: line, Ban: ban})
+	}
+
+	return records, skipped, nil
+}
+
+// mysqlBatchReader reads the tab separated output of mysql -B, which
+// escapes tabs, newlines and backslashes inside values instead of quoting
+func mysqlBatchReader(r io.Reader) func() ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	unescape := strings.NewReplacer(`\t`, "\t", `\n`, "\n", `\0`, "", `\\`, `\`)
+	return func() ([]string, error) {
+		for scanner.Scan() {
+			line := strings.TrimRight(scanner.Text(), "\r")
+			if line == "" {
+				continue
+			}
+			fields := strings.Split(line, "\t")
+			for i, f := range fields {
+				if f != `\N` {
+					fields[i] = unescape.Replace(f)
+				}
+			}
+			return fields, nil
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+}
+
+// mysqlEscape escapes a value the way mysql -B does, so exports read back
+// with mysqlBatchReader
+var mysqlEscape = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`)
+
+func exportB3(w io.Writer, bans []models.Ban) (int, error) {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(strings.Join(b3Columns, "\t") + "\n")
+
+	written := 0
+	for _, ban := range bans {
+		if ban.Type != models.BanTypeGUID {
+			continue
+		}
+		penaltyType, expire := "Ban", "-1"
+		if ban.ExpiresAt != nil {
+			penaltyType, expire = "TempBan", strconv.FormatInt(ban.ExpiresAt.Unix(), 10)
+		}
+		row := []string{
+			strconv.FormatUint(uint64(ban.ID), 10),
+			penaltyType,
+			strconv.FormatInt(ban.CreatedAt.Unix(), 10),
+			expire,
+			ban.Reason,
+			"0",
+			ban.Value,
+			ban.PlayerName,
+			"",
+			ban.BannedByName,
+		}
+		for i := range row {
+			row[i] = mysqlEscape.Replace(row[i])
+		}
+		if _, err := bw.WriteString(strings.Join(row, "\t") + "\n"); err != nil {
+			return written, err
+		}
+		written++
+	}
+
+	return written, bw.Flush()
+}
@@ -0,0 +1,181 @@
+// Package banio imports and exports ban lists in the formats other admin
+// tools use, so bans can be carried into and out of GoAdmin's ban list.
+package banio
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/models"
+)
+
+// Supported formats
+const (
+	FormatB3    = "b3"    // B3 penalties table exported as CSV or TSV, see b3.go
+	FormatCoD4x = "cod4x" // CoD4x banlist.dat
+	FormatGUIDs = "guids" // One GUID per line, optionally followed by a reason
+	FormatJSON  = "json"  // GoAdmin's own export
+)
+
+// Formats lists every supported format
+var Formats = []string{FormatB3, FormatCoD4x, FormatGUIDs, FormatJSON}
+
+// ValidFormat reports whether format is supported
+func ValidFormat(format string) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// Record is one ban read from a file, before validation
+type Record struct {
+	Line int
+	Ban  models.Ban
+}
+
+// Skipped is an entry that was not imported and why
+type Skipped struct {
+	Line   int    `json:"line"`
+	Value  string `json:"value"`
+	Reason string `json:"reason"`
+}
+
+// Options control an import
+type Options struct {
+	DryRun        bool   // Validate and report without saving
+	DefaultReason string // Used for entries without a reason
+	ImportedBy    string // Recorded as the issuer when the file has none
+}
+
+// Report is the outcome of an import. Bans holds what was, or in a dry run
+// would be, imported.
+type Report struct {
+	Format     string       `json:"format"`
+	DryRun     bool         `json:"dryRun"`
+	Total      int          `json:"total"`
+	Imported   int          `json:"imported"`
+	Duplicates int          `json:"duplicates"`
+	Skipped    []Skipped    `json:"skipped"`
+	Bans       []models.Ban `json:"bans"`
+}
+
+// Parse reads the entries of a ban file. Entries the format itself marks as
+// not worth importing (kicks, lifted bans, expired bans) are returned as
+// skipped.
+func Parse(format string, r io.Reader) ([]Record, []Skipped, error) {
+	switch format {
+	case FormatB3:
+		return parseB3(r)
+	case FormatCoD4x:
+		return parseCoD4x(r)
+	case FormatGUIDs:
+		return parseGUIDs(r)
+	case FormatJSON:
+		return parseJSON(r)
+	}
+	return nil, nil, fmt.Errorf("unknown format: %s", format)
+}
+
+// Import reads a ban file into the ban list. Entries that are invalid,
+// expired, or already banned (in the list or earlier in the file) are
+// skipped and reported rather than failing the import.
+func Import(format string, r io.Reader, opts Options) (*Report, error) {
+	records, skipped, err := Parse(format, r)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := models.ActiveBanKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Format:  format,
+		DryRun:  opts.DryRun,
+		Total:   len(records) + len(skipped),
+		Skipped: skipped,
+		Bans:    []models.Ban{},
+	}
+	now := time.Now()
+
+	for _, rec := range records {
+		ban := rec.Ban
+
+		value, err := models.NormalizeBanValue(ban.Type, ban.Value)
+		if err != nil {
+			report.Skipped = append(report.Skipped, Skipped{Line: rec.Line, Value: ban.Value, Reason: err.Error()})
+			continue
+		}
+		ban.Value = value
+
+		if ban.ExpiresAt != nil && !ban.ExpiresAt.After(now) {
+			report.Skipped = append(report.Skipped, Skipped{Line: rec.Line, Value: ban.Value, Reason: "expired"})
+			continue
+		}
+
+		key := models.BanKey(ban.Type, ban.Value)
+		if existing[key] {
+			report.Duplicates++
+			report.Skipped = append(report.Skipped, Skipped{Line: rec.Line, Value: ban.Value, Reason: "already banned"})
+			continue
+		}
+		existing[key] = true
+
+		if strings.TrimSpace(ban.Reason) == "" {
+			ban.Reason = opts.DefaultReason
+		}
+		if ban.Reason == "" {
+			ban.Reason = "Imported from " + format
+		}
+		if ban.BannedByName == "" {
+			ban.BannedByName = opts.ImportedBy
+		}
+		if ban.Type == models.BanTypeGUID && ban.PlayerGUID == "" {
+			ban.PlayerGUID = ban.Value
+		}
+		ban.Source = "import:" + format
+
+		report.Bans = append(report.Bans, ban)
+	}
+
+	if !opts.DryRun && len(report.Bans) > 0 {
+		if err := models.CreateBans(report.Bans); err != nil {
+			return nil, err
+		}
+	}
+	report.Imported = len(report.Bans)
+
+	return report, nil
+}
+
+// Export writes bans in the given format and returns how many were written.
+// Formats that can only hold GUIDs leave out other ban types.
+func Export(format string, w io.Writer, bans []models.Ban) (int, error) {
+	switch format {
+	case FormatB3:
+		return exportB3(w, bans)
+	case FormatCoD4x:
+		return exportCoD4x(w, bans)
+	case FormatGUIDs:
+		return exportGUIDs(w, bans)
+	case FormatJSON:
+		return exportJSON(w, bans)
+	}
+	return 0, fmt.Errorf("unknown format: %s", format)
+}
+
+// unixTime converts a Unix timestamp to a time, treating zero and negative
+// values as unset
+func unixTime(seconds int64) *time.Time {
+	if seconds <= 0 {
+		return nil
+	}
+	t := time.Unix(seconds, 0)
+	return &t
+}
@@ -0,0 +1,107 @@
+package banio
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ethanburkett/goadmin/app/models"
+)
+
+// CoD4x writes one ban per line of banlist.dat as a key\value info string:
+//
+//	\nick\Bob\rsn\aimbot\playerid\2310346616...\steamid\0\expire\-1\created\1700000000\adminname\Admin
+//
+// Older builds use guid, name and reason for the same fields. expire is a
+// Unix time, with -1 or 0 meaning permanent.
+func parseCoD4x(r io.Reader) ([]Record, []Skipped, error) {
+	var records []Record
+	var skipped []Skipped
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "//") || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		info := parseInfoString(text)
+		guid := firstNonEmpty(info["playerid"], info["guid"])
+		if guid == "" || guid == "0" {
+			guid = info["steamid"]
+		}
+		if guid == "" || guid == "0" {
+			skipped = append(skipped, Skipped{Line: line, Value: text, Reason: "no player ID"})
+			continue
+		}
+
+		ban := models.Ban{
+			Type:         models.BanTypeGUID,
+			Value:        guid,
+			PlayerName:   firstNonEmpty(info["nick"], info["name"]),
+			PlayerGUID:   guid,
+			Reason:       firstNonEmpty(info["rsn"], info["reason"]),
+			BannedByName: info["adminname"],
+		}
+		if expire, err := strconv.ParseInt(info["expire"], 10, 64); err == nil {
+			ban.ExpiresAt = unixTime(expire)
+		}
+		if created, err := strconv.ParseInt(info["created"], 10, 64); err == nil {
+			if t := unixTime(created); t != nil {
+				ban.CreatedAt = *t
+			}
+		}
+		records = append(records, Record{Line: line, Ban: ban})
+	}
+
+	return records, skipped, scanner.Err()
+}
+
+func exportCoD4x(w io.Writer, bans []models.Ban) (int, error) {
+	bw := bufio.NewWriter(w)
+	clean := strings.NewReplacer(`\`, "", "\n", " ", "\r", "")
+
+	written := 0
+	for _, ban := range bans {
+		if ban.Type != models.BanTypeGUID {
+			continue
+		}
+		expire := int64(-1)
+		if ban.ExpiresAt != nil {
+			expire = ban.ExpiresAt.Unix()
+		}
+		_, err := fmt.Fprintf(bw, "\\nick\\%s\\rsn\\%s\\playerid\\%s\\steamid\\0\\expire\\%d\\created\\%d\\adminname\\%s\n",
+			clean.Replace(ban.PlayerName), clean.Replace(ban.Reason), clean.Replace(ban.Value),
+			expire, ban.CreatedAt.Unix(), clean.Replace(ban.BannedByName))
+		if err != nil {
+			return written, err
+		}
+		written++
+	}
+
+	return written, bw.Flush()
+}
+
+// parseInfoString splits a Quake style \key\value\key\value string. Keys are
+// lowercased.
+func parseInfoString(s string) map[string]string {
+	parts := strings.Split(strings.TrimPrefix(s, `\`), `\`)
+	info := make(map[string]string, len(parts)/2)
+	for i := 0; i+1 < len(parts); i += 2 {
+		info[strings.ToLower(parts[i])] = strings.TrimSpace(parts[i+1])
+	}
+	return info
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
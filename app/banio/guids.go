@@ -0,0 +1,58 @@
+package banio
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/ethanburkett/goadmin/app/models"
+)
+
+// A GUID list has one GUID per line, optionally followed by whitespace and a
+// reason. Blank lines and lines starting with # or // are ignored.
+func parseGUIDs(r io.Reader) ([]Record, []Skipped, error) {
+	var records []Record
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, "//") {
+			continue
+		}
+
+		guid, reason := text, ""
+		if i := strings.IndexFunc(text, unicode.IsSpace); i >= 0 {
+			guid, reason = text[:i], text[i+1:]
+		}
+		records = append(records, Record{Line: line, Ban: models.Ban{
+			Type:       models.BanTypeGUID,
+			Value:      guid,
+			PlayerGUID: guid,
+			Reason:     strings.TrimSpace(reason),
+		}})
+	}
+
+	return records, nil, scanner.Err()
+}
+
+func exportGUIDs(w io.Writer, bans []models.Ban) (int, error) {
+	bw := bufio.NewWriter(w)
+	clean := strings.NewReplacer("\n", " ", "\r", "")
+
+	written := 0
+	for _, ban := range bans {
+		if ban.Type != models.BanTypeGUID {
+			continue
+		}
+		if _, err := fmt.Fprintf(bw, "%s %s\n", ban.Value, clean.Replace(ban.Reason)); err != nil {
+			return written, err
+		}
+		written++
+	}
+
+	return written, bw.Flush()
+}
@@ -0,0 +1,81 @@
+package banio
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/models"
+)
+
+// jsonBan is one ban in GoAdmin's export. It carries every ban type, so it
+// is the format to use when moving bans between GoAdmin installs.
+type jsonBan struct {
+	Type         string     `json:"type"`
+	Value        string     `json:"value"`
+	PlayerName   string     `json:"playerName,omitempty"`
+	PlayerGUID   string     `json:"playerGuid,omitempty"`
+	Reason       string     `json:"reason"`
+	Category     string     `json:"category,omitempty"`
+	Evidence     []string   `json:"evidence,omitempty"`
+	BannedByName string     `json:"bannedByName,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+}
+
+func parseJSON(r io.Reader) ([]Record, []Skipped, error) {
+	var entries []jsonBan
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, nil, err
+	}
+
+	var records []Record
+	var skipped []Skipped
+	for i, e := range entries {
+		// Entries are numbered from 1 in place of line numbers
+		if !models.ValidBanType(e.Type) {
+			skipped = append(skipped, Skipped{Line: i + 1, Value: e.Value, Reason: "unknown ban type: " + e.Type})
+			continue
+		}
+		if e.Category != "" && !models.ValidBanCategory(e.Category) {
+			skipped = append(skipped, Skipped{Line: i + 1, Value: e.Value, Reason: "unknown ban category: " + e.Category})
+			continue
+		}
+		records = append(records, Record{Line: i + 1, Ban: models.Ban{
+			Type:         e.Type,
+			Value:        e.Value,
+			PlayerName:   e.PlayerName,
+			PlayerGUID:   e.PlayerGUID,
+			Reason:       e.Reason,
+			Category:     e.Category,
+			Evidence:     e.Evidence,
+			BannedByName: e.BannedByName,
+			CreatedAt:    e.CreatedAt,
+			ExpiresAt:    e.ExpiresAt,
+		}})
+	}
+
+	return records, skipped, nil
+}
+
+func exportJSON(w io.Writer, bans []models.Ban) (int, error) {
+	entries := make([]jsonBan, 0, len(bans))
+	for _, ban := range bans {
+		entries = append(entries, jsonBan{
+			Type:         ban.Type,
+			Value:        ban.Value,
+			PlayerName:   ban.PlayerName,
+			PlayerGUID:   ban.PlayerGUID,
+			Reason:       ban.Reason,
+			Category:     ban.Category,
+			Evidence:     ban.Evidence,
+			BannedByName: ban.BannedByName,
+			CreatedAt:    ban.CreatedAt,
+			ExpiresAt:    ban.ExpiresAt,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return len(entries), encoder.Encode(entries)
+}
@@ -0,0 +1,499 @@
+// Package chatfilter moderates chat automatically. Every say and sayteam
+// line is checked against the chat filter rules in order; the first rule a
+// line breaks decides what happens to the player.
+package chatfilter
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/database"
+	"github.com/ethanburkett/goadmin/app/events"
+	"github.com/ethanburkett/goadmin/app/logger"
+	"github.com/ethanburkett/goadmin/app/models"
+	"github.com/ethanburkett/goadmin/app/parser"
+	"github.com/ethanburkett/goadmin/app/rcon"
+	"go.uber.org/zap"
+)
+
+const (
+	// minHistory is how long lines are kept for repeat and flood rules when
+	// no rule asks for longer
+	minHistory = time.Minute
+	// maxHistoryLines caps the lines kept per player
+	maxHistoryLines = 30
+	// defaultCapsLength is the shortest line caps rules check when the rule
+	// doesn't set one
+	defaultCapsLength = 10
+)
+
+// Line is one chat line to check
+type Line struct {
+	ServerID *uint
+	GUID     string
+	Name     string
+	Slot     string
+	Message  string
+}
+
+// Violation is a rule a line broke and what in the line broke it
+type Violation struct {
+	Rule  models.ChatFilterRule
+	Match string
+}
+
+// Mute is a player muted by the filter. Game servers can't silence a
+// player over rcon, so a muted player who keeps talking is kicked.
+type Mute struct {
+	PlayerGUID string    `json:"playerGuid"`
+	PlayerName string    `json:"playerName"`
+	Reason     string    `json:"reason"`
+	RuleID     uint      `json:"ruleId"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+// rule is a chat filter rule with its pattern parsed
+type rule struct {
+	models.ChatFilterRule
+	words   []wordTerm
+	exprs   []*regexp.Regexp
+	allowed []string
+}
+
+// sentLine is a line kept for repeat and flood rules
+type sentLine struct {
+	at   time.Time
+	text string
+}
+
+// Engine holds the rules and the per-player state the filter needs
+type Engine struct {
+	mu        sync.Mutex
+	loaded    bool
+	rules     []rule
+	history   map[string][]sentLine
+	keep      time.Duration
+	mutes     map[string]*Mute
+	lastSweep time.Time
+}
+
+// Default is the engine the log watchers feed
+var Default = NewEngine()
+
+// NewEngine creates an engine with no rules loaded
+func NewEngine() *Engine {
+	return &Engine{
+		history: make(map[string][]sentLine),
+		keep:    minHistory,
+		mutes:   make(map[string]*Mute),
+	}
+}
+
+// Reload reads the rules from the database. Call it whenever rules change.
+func (e *Engine) Reload() error {
+	rules, err := models.GetChatFilterRules()
+	if err != nil {
+		return err
+	}
+	e.SetRules(rules)
+	return nil
+}
+
+// SetRules replaces the rules. Disabled rules and expressions that don't
+// compile are skipped.
+func (e *Engine) SetRules(rules []models.ChatFilterRule) {
+	compiled := make([]rule, 0, len(rules))
+	keep := minHistory
+
+	for _, r := range rules {
+		if !r.Enabled {
+			continue
+		}
+		c := rule{ChatFilterRule: r}
+
+		switch r.Type {
+		case models.ChatFilterWords:
+			for _, entry := range r.Terms() {
+				if term, ok := newWordTerm(entry); ok {
+					c.words = append(c.words, term)
+				}
+			}
+		case models.ChatFilterRegex:
+			for _, entry := range r.Terms() {
+				expr, err := regexp.Compile("(?i)" + entry)
+				if err != nil {
+					logger.Warn("Skipping invalid chat filter expression", zap.Uint("rule_id", r.ID), zap.Error(err))
+					continue
+				}
+				c.exprs = append(c.exprs, expr)
+			}
+		case models.ChatFilterAdvertising:
+			for _, entry := range r.Terms() {
+				if host := advertHost(entry); host != "" {
+					c.allowed = append(c.allowed, host)
+				}
+			}
+		case models.ChatFilterRepeat, models.ChatFilterFlood:
+			if window := time.Duration(r.WindowSeconds) * time.Second; window > keep {
+				keep = window
+			}
+		}
+
+		compiled = append(compiled, c)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rules = compiled
+	e.keep = keep
+	e.loaded = true
+}
+
+// Check records a line and returns the first rule it breaks, or nil.
+// Rules exempting the player's power are skipped.
+func (e *Engine) Check(line Line, power int, now time.Time) *Violation {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.sweep(now)
+
+	text := parser.StripColorCodes(line.Message)
+	normalized := normalize(text)
+
+	guid := strings.ToLower(line.GUID)
+	history := append(e.recent(guid, now), sentLine{at: now, text: normalized})
+	if len(history) > maxHistoryLines {
+		history = history[len(history)-maxHistoryLines:]
+	}
+	e.history[guid] = history
+
+	for i := range e.rules {
+		r := &e.rules[i]
+		if r.Exempts(power) {
+			continue
+		}
+		match, ok := r.check(text, normalized, history, now)
+		if !ok {
+			continue
+		}
+		if r.Type == models.ChatFilterRepeat || r.Type == models.ChatFilterFlood {
+			// Start counting afresh so the next line isn't caught again
+			delete(e.history, guid)
+		}
+		return &Violation{Rule: r.ChatFilterRule, Match: match}
+	}
+	return nil
+}
+
+// check reports whether a line breaks the rule, with the offending part
+func (r *rule) check(text, normalized string, history []sentLine, now time.Time) (string, bool) {
+	switch r.Type {
+	case models.ChatFilterWords:
+		for _, token := range tokens(normalized) {
+			for _, term := range r.words {
+				if term.matches(token) {
+					return token, true
+				}
+			}
+		}
+
+	case models.ChatFilterRegex:
+		for _, expr := range r.exprs {
+			if match := expr.FindString(text); match != "" {
+				return match, true
+			}
+		}
+
+	case models.ChatFilterAdvertising:
+		if advert := findAdvert(text, r.allowed); advert != "" {
+			return advert, true
+		}
+
+	case models.ChatFilterRepeat, models.ChatFilterFlood:
+		if normalized == "" && r.Type == models.ChatFilterRepeat {
+			return "", false
+		}
+		since := now.Add(-time.Duration(r.WindowSeconds) * time.Second)
+		count := 0
+		for _, sent := range history {
+			if sent.at.Before(since) {
+				continue
+			}
+			if r.Type == models.ChatFilterFlood || sent.text == normalized {
+				count++
+			}
+		}
+		if count >= r.Threshold {
+			return fmt.Sprintf("%d lines in %ds", count, r.WindowSeconds), true
+		}
+
+	case models.ChatFilterCaps:
+		minLength := r.MinLength
+		if minLength < 1 {
+			minLength = defaultCapsLength
+		}
+		if percent, letters := capsPercent(text); letters >= minLength && percent >= r.Threshold {
+			return fmt.Sprintf("%d%% capitals", percent), true
+		}
+	}
+	return "", false
+}
+
+// recent returns a player's lines within the history window
+func (e *Engine) recent(guid string, now time.Time) []sentLine {
+	history := e.history[guid]
+	cutoff := now.Add(-e.keep)
+	for len(history) > 0 && history[0].at.Before(cutoff) {
+		history = history[1:]
+	}
+	return history
+}
+
+// sweep drops the history of players who went quiet and expired mutes
+func (e *Engine) sweep(now time.Time) {
+	if now.Sub(e.lastSweep) < e.keep {
+		return
+	}
+	e.lastSweep = now
+
+	for guid, history := range e.history {
+		if len(history) == 0 || now.Sub(history[len(history)-1].at) > e.keep {
+			delete(e.history, guid)
+		}
+	}
+	for guid, mute := range e.mutes {
+		if !now.Before(mute.ExpiresAt) {
+			delete(e.mutes, guid)
+		}
+	}
+}
+
+// Mute mutes a player until the given time
+func (e *Engine) Mute(mute Mute) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	mute.PlayerGUID = strings.ToLower(mute.PlayerGUID)
+	e.mutes[mute.PlayerGUID] = &mute
+}
+
+// Unmute lifts a player's mute and reports whether they were muted
+func (e *Engine) Unmute(guid string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	guid = strings.ToLower(guid)
+	mute, ok := e.mutes[guid]
+	delete(e.mutes, guid)
+	return ok && time.Now().Before(mute.ExpiresAt)
+}
+
+// Muted returns a player's mute, or nil when they aren't muted
+func (e *Engine) Muted(guid string) *Mute {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	mute, ok := e.mutes[strings.ToLower(guid)]
+	if !ok || !time.Now().Before(mute.ExpiresAt) {
+		return nil
+	}
+	copied := *mute
+	return &copied
+}
+
+// Mutes lists the active mutes, soonest to expire first
+func (e *Engine) Mutes() []Mute {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := time.Now()
+	mutes := make([]Mute, 0, len(e.mutes))
+	for _, mute := range e.mutes {
+		if now.Before(mute.ExpiresAt) {
+			mutes = append(mutes, *mute)
+		}
+	}
+	sort.Slice(mutes, func(i, j int) bool { return mutes[i].ExpiresAt.Before(mutes[j].ExpiresAt) })
+	return mutes
+}
+
+// active reports whether there are rules to check, loading them on first use
+func (e *Engine) active() bool {
+	e.mu.Lock()
+	loaded, count := e.loaded, len(e.rules)
+	e.mu.Unlock()
+
+	if !loaded {
+		if err := e.Reload(); err != nil {
+			logger.Error("Failed to load chat filter rules", zap.Error(err))
+			return false
+		}
+		e.mu.Lock()
+		count = len(e.rules)
+		e.mu.Unlock()
+	}
+	return count > 0
+}
+
+// Handle checks a chat line from a server and acts on any rule it breaks
+func (e *Engine) Handle(client *rcon.Client, line Line) {
+	if line.GUID == "" || strings.TrimSpace(line.Message) == "" {
+		return
+	}
+
+	if mute := e.Muted(line.GUID); mute != nil {
+		e.kickMuted(client, line, mute)
+		return
+	}
+
+	if !e.active() {
+		return
+	}
+
+	violation := e.Check(line, models.GetPlayerPower(line.GUID), time.Now())
+	if violation == nil {
+		return
+	}
+
+	if err := e.enforce(client, line, violation); err != nil {
+		logger.Error("Failed to enforce chat filter rule",
+			zap.Uint("rule_id", violation.Rule.ID),
+			zap.String("guid", line.GUID),
+			zap.Error(err))
+	}
+}
+
+// enforce applies the action of the rule a line broke
+func (e *Engine) enforce(client *rcon.Client, line Line, v *Violation) error {
+	r := v.Rule
+	reason := r.Message
+	if reason == "" {
+		reason = r.Name
+	}
+	metadata := map[string]interface{}{
+		"rule_id": r.ID,
+		"rule":    r.Name,
+		"type":    r.Type,
+		"match":   v.Match,
+		"message": line.Message,
+	}
+
+	var err error
+	switch r.Action {
+	case models.ChatFilterTell:
+		_, err = client.Tell(line.Slot, "^1Warning: ^7"+reason)
+		audit(models.ActionChatFilter, line, metadata, err,
+			fmt.Sprintf("Warned by chat filter '%s' for: %s", r.Name, v.Match))
+
+	case models.ChatFilterMute:
+		expires := time.Now().Add(r.Duration())
+		e.Mute(Mute{
+			PlayerGUID: line.GUID,
+			PlayerName: line.Name,
+			Reason:     reason,
+			RuleID:     r.ID,
+			ExpiresAt:  expires,
+		})
+		_, err = client.Tell(line.Slot, fmt.Sprintf("^1You are muted for %s: ^7%s. Talking while muted will get you kicked.", formatMinutes(r.DurationMinutes), reason))
+		metadata["duration_minutes"] = r.DurationMinutes
+		audit(models.ActionMutePlayer, line, metadata, nil,
+			fmt.Sprintf("Muted for %s by chat filter '%s' for: %s", formatMinutes(r.DurationMinutes), r.Name, v.Match))
+
+	case models.ChatFilterKick:
+		_, err = client.Kick(line.Slot, reason)
+		if err == nil {
+			events.Publish(events.PlayerKicked, line.ServerID, events.KickPayload{
+				PlayerName: line.Name,
+				PlayerID:   line.Slot,
+				KickedBy:   "Chat Filter",
+				Reason:     reason,
+				Source:     "chatfilter",
+			})
+		}
+		audit(models.ActionKickPlayer, line, metadata, err,
+			fmt.Sprintf("Kicked by chat filter '%s' for: %s", r.Name, v.Match))
+
+	case models.ChatFilterTempBan:
+		var tempBan *models.TempBan
+		tempBan, err = models.CreateTempBan(line.Name, line.GUID, reason, r.Duration(), nil, line.ServerID)
+		if err == nil {
+			events.Publish(events.PlayerBanned, line.ServerID, events.BanPayload{
+				PlayerName: line.Name,
+				PlayerGUID: line.GUID,
+				BannedBy:   "Chat Filter",
+				Reason:     reason,
+				Duration:   r.Duration().String(),
+				ExpiresAt:  tempBan.ExpiresAt.Format(time.RFC3339),
+				BanType:    "temporary",
+				Source:     "chatfilter",
+			})
+			client.Kick(line.Slot, fmt.Sprintf("Temp banned: %s (Expires: %s)", reason, tempBan.ExpiresAt.Format("2006-01-02 15:04")))
+		}
+		metadata["duration_minutes"] = r.DurationMinutes
+		audit(models.ActionTempBanPlayer, line, metadata, err,
+			fmt.Sprintf("Temporarily banned for %s by chat filter '%s' for: %s", formatMinutes(r.DurationMinutes), r.Name, v.Match))
+
+	default:
+		err = fmt.Errorf("unknown chat filter action: %s", r.Action)
+	}
+
+	if err == nil {
+		logger.Info(fmt.Sprintf("Chat filter '%s' applied %s to %s (GUID: %s) for: %s", r.Name, r.Action, line.Name, line.GUID, v.Match))
+	}
+	return err
+}
+
+// kickMuted kicks a muted player for talking
+func (e *Engine) kickMuted(client *rcon.Client, line Line, mute *Mute) {
+	reason := "Talking while muted: " + mute.Reason
+	_, err := client.Kick(line.Slot, reason)
+	if err == nil {
+		events.Publish(events.PlayerKicked, line.ServerID, events.KickPayload{
+			PlayerName: line.Name,
+			PlayerID:   line.Slot,
+			KickedBy:   "Chat Filter",
+			Reason:     reason,
+			Source:     "chatfilter",
+		})
+	} else {
+		logger.Error("Failed to kick muted player", zap.String("guid", line.GUID), zap.Error(err))
+	}
+	audit(models.ActionKickPlayer, line, map[string]interface{}{
+		"rule_id":     mute.RuleID,
+		"message":     line.Message,
+		"muted_until": mute.ExpiresAt,
+	}, err, "Kicked by chat filter for talking while muted")
+}
+
+// audit records a filter action in the audit log
+func audit(action models.ActionType, line Line, metadata map[string]interface{}, err error, result string) {
+	var errMsg string
+	if err != nil {
+		errMsg = err.Error()
+	}
+	metadataJSON, _ := json.Marshal(metadata)
+	models.CreateAuditLog(
+		database.DB,
+		nil,
+		"SYSTEM",
+		"",
+		action,
+		models.SourceSystem,
+		err == nil,
+		errMsg,
+		"player",
+		line.GUID,
+		line.Name,
+		string(metadataJSON),
+		result,
+	)
+}
+
+// formatMinutes formats a rule duration, e.g. "90m" or "2h"
+func formatMinutes(minutes int) string {
+	if minutes%60 == 0 {
+		return fmt.Sprintf("%dh", minutes/60)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
@@ -0,0 +1,212 @@
+package chatfilter
+
+import (
+	"net"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// leet maps look-alike characters to the letters they stand in for
+var leet = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b', '9': 'g',
+	'@': 'a', '$': 's', '|': 'i', '+': 't',
+}
+
+var (
+	// An address only counts with a port or after "connect", so version
+	// numbers such as 1.7.0.1 aren't taken for servers
+	ipPattern = regexp.MustCompile(`(?i)(\bconnect\s+)?\b(\d{1,3}(?:\.\d{1,3}){3})(:\d{1,5})?\b`)
+	// Bare domains need a common TLD or a path, so "nice.gg" isn't a link
+	// but "discord.gg/invite" is
+	urlPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+|\b[a-z0-9-]+(?:\.[a-z0-9-]+)*\.(?:com|net|org)\b(?:/\S*)?|\b[a-z0-9-]+(?:\.[a-z0-9-]+)*\.(?:gg|io|me|tv|co|uk|de|ru|eu|info|xyz|tk|biz)/\S+`)
+)
+
+// normalize lowercases a message, undoes leet-speak and reduces everything
+// but letters to single spaces, so "Y0u $uck!!" becomes "you suck"
+func normalize(message string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(message) {
+		if l, ok := leet[r]; ok {
+			r = l
+		}
+		switch {
+		case unicode.IsLetter(r):
+			b.WriteRune(r)
+		case r == '\'':
+			// Keep contractions whole
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// tokens splits a normalized message into words. Runs of single letters are
+// also joined, so spelling a word out as "f u c k" or "f.u.c.k" still
+// matches.
+func tokens(normalized string) []string {
+	words := strings.Fields(normalized)
+	result := append([]string(nil), words...)
+
+	var run []string
+	flush := func() {
+		if len(run) > 1 {
+			result = append(result, strings.Join(run, ""))
+		}
+		run = run[:0]
+	}
+	for _, word := range words {
+		if utf8.RuneCountInString(word) == 1 {
+			run = append(run, word)
+		} else {
+			flush()
+		}
+	}
+	flush()
+
+	return result
+}
+
+// wordTerm is a normalized entry of a word list
+type wordTerm struct {
+	word   string
+	prefix bool // Entry ended in "*"
+}
+
+func newWordTerm(entry string) (wordTerm, bool) {
+	prefix := strings.HasSuffix(entry, "*")
+	word := strings.ReplaceAll(normalize(strings.TrimSuffix(entry, "*")), " ", "")
+	return wordTerm{word: word, prefix: prefix}, word != ""
+}
+
+// matches reports whether a token is the term. Drawn-out tokens such as
+// "fuuuuck" are compared with repeated letters squashed on both sides.
+func (t wordTerm) matches(token string) bool {
+	if t.equal(token, t.word) {
+		return true
+	}
+	if hasRun(token, 3) {
+		return t.equal(squash(token), squash(t.word))
+	}
+	return false
+}
+
+func (t wordTerm) equal(token, word string) bool {
+	if t.prefix {
+		return strings.HasPrefix(token, word)
+	}
+	return token == word
+}
+
+// hasRun reports whether s repeats a letter n or more times in a row
+func hasRun(s string, n int) bool {
+	var last rune
+	count := 0
+	for _, r := range s {
+		if r == last {
+			count++
+		} else {
+			last, count = r, 1
+		}
+		if count >= n {
+			return true
+		}
+	}
+	return false
+}
+
+// squash collapses repeated letters, "fuuuck" to "fuck"
+func squash(s string) string {
+	var b strings.Builder
+	var last rune
+	for i, r := range s {
+		if i == 0 || r != last {
+			b.WriteRune(r)
+		}
+		last = r
+	}
+	return b.String()
+}
+
+// findAdvert returns the first IP address or URL in a message that isn't on
+// one of the allowed hosts
+func findAdvert(message string, allowed []string) string {
+	for _, m := range ipPattern.FindAllStringSubmatchIndex(message, -1) {
+		connect, port := m[2] >= 0, m[6] >= 0
+		start, end := m[4], m[1]
+		// Part of a longer dotted number, e.g. 1.2.3.4.5
+		before := start > 0 && message[start-1] == '.'
+		after := end+1 < len(message) && message[end] == '.' && unicode.IsDigit(rune(message[end+1]))
+		if before || after {
+			continue
+		}
+		ip := message[start:end]
+		if (connect || port) && net.ParseIP(message[start:m[5]]) != nil && !isAllowed(ip, allowed) {
+			return ip
+		}
+	}
+	for _, url := range urlPattern.FindAllString(message, -1) {
+		if !isAllowed(url, allowed) {
+			return url
+		}
+	}
+	return ""
+}
+
+// isAllowed reports whether an IP or URL found in chat is on one of the
+// allowed hosts or a subdomain of one. Allowed entries are normalized with
+// advertHost.
+func isAllowed(found string, allowed []string) bool {
+	host := advertHost(found)
+	if host == "" {
+		return false
+	}
+	for _, entry := range allowed {
+		if host == entry || strings.HasSuffix(host, "."+entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// advertHost returns the lowercased host of an IP, "ip:port" or URL,
+// without scheme, credentials, port or path
+func advertHost(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if i := strings.Index(s, "://"); i >= 0 {
+		s = s[i+3:]
+	}
+	if i := strings.IndexAny(s, "/?#"); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.LastIndex(s, "@"); i >= 0 {
+		s = s[i+1:]
+	}
+	// Punctuation ending the sentence the URL was in
+	s = strings.TrimRight(s, ",;!?)'\"")
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	return strings.TrimRight(s, ".:")
+}
+
+// capsPercent returns the share of capital letters in a message and how many
+// letters it has
+func capsPercent(message string) (percent, letters int) {
+	upper := 0
+	for _, r := range message {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if unicode.IsUpper(r) {
+			upper++
+		}
+	}
+	if letters == 0 {
+		return 0, 0
+	}
+	return upper * 100 / letters, letters
+}
@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethanburkett/goadmin/app/models"
+)
+
+// aliasLimit is how many names and linked accounts !aliases lists
+const aliasLimit = 5
+
+// handleAliasesCommand shows an online player's other names and any GUIDs
+// that share an IP or name with them
+func (ch *CommandHandler) handleAliasesCommand(ch2 *CommandHandler, playerName, playerGUID string, args []string) error {
+	if len(args) < 1 {
+		ch.sendPlayerMessage(playerName, "Usage: !aliases <player>")
+		return nil
+	}
+
+	status, err := ch.rcon.Status()
+	if err != nil {
+		ch.sendPlayerMessage(playerName, "Failed to get server status")
+		return err
+	}
+
+	var targetGUID, targetName string
+	searchName := strings.ToLower(strings.Join(args, " "))
+	for _, player := range status.Players {
+		if strings.ToLower(player.StrippedName) == searchName || strings.Contains(strings.ToLower(player.StrippedName), searchName) {
+			targetGUID = player.Uuid
+			targetName = player.StrippedName
+			break
+		}
+	}
+
+	if targetGUID == "" {
+		ch.sendPlayerMessage(playerName, fmt.Sprintf("Player '%s' not found online", strings.Join(args, " ")))
+		return nil
+	}
+
+	aliases, err := models.GetPlayerAliases(targetGUID)
+	if err != nil {
+		ch.sendPlayerMessage(playerName, "Failed to fetch aliases")
+		return err
+	}
+
+	linked, err := models.FindLinkedPlayers(targetGUID)
+	if err != nil {
+		ch.sendPlayerMessage(playerName, "Failed to fetch linked players")
+		return err
+	}
+
+	var names []string
+	for _, alias := range aliases {
+		if alias.Name != targetName {
+			names = append(names, alias.Name)
+		}
+	}
+
+	ch.sendPlayerMessage(playerName, fmt.Sprintf("^3Aliases for ^2%s^7:", targetName))
+	if len(names) == 0 {
+		ch.sendPlayerMessage(playerName, "^7No other names recorded")
+	} else {
+		if len(names) > aliasLimit {
+			names = append(names[:aliasLimit], fmt.Sprintf("+%d more", len(names)-aliasLimit))
+		}
+		ch.sendPlayerMessage(playerName, "^7"+strings.Join(names, "^7, "))
+	}
+
+	if len(linked) == 0 {
+		ch.sendPlayerMessage(playerName, "^7No linked accounts")
+		return nil
+	}
+
+	ch.sendPlayerMessage(playerName, fmt.Sprintf("^1%d linked account(s):", len(linked)))
+	for i, lp := range linked {
+		if i == aliasLimit {
+			ch.sendPlayerMessage(playerName, fmt.Sprintf("^7...and %d more", len(linked)-aliasLimit))
+			break
+		}
+		var via []string
+		if len(lp.SharedIPs) > 0 {
+			via = append(via, "IP")
+		}
+		if len(lp.SharedNames) > 0 {
+			via = append(via, "name")
+		}
+		ch.sendPlayerMessage(playerName, fmt.Sprintf("^2%s ^7(%s) - shared %s", lp.Name, lp.GUID, strings.Join(via, " + ")))
+	}
+
+	return nil
+}
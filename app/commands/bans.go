@@ -0,0 +1,248 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/events"
+	"github.com/ethanburkett/goadmin/app/logger"
+	"github.com/ethanburkett/goadmin/app/models"
+	"gorm.io/gorm"
+)
+
+// handleBanCommand permanently bans an online player through the ban list,
+// so the ban holds on every server
+func (ch *CommandHandler) handleBanCommand(ch2 *CommandHandler, playerName, playerGUID string, args []string) error {
+	if len(args) < 1 {
+		ch.sendPlayerMessage(playerName, "Usage: !ban <player> [reason]")
+		return nil
+	}
+
+	if disabled, info := models.GlobalEmergencyShutdown.IsCommandDisabled("ban"); disabled {
+		ch.sendPlayerMessage(playerName, "^1This command is temporarily disabled")
+		ch.sendPlayerMessage(playerName, fmt.Sprintf("^1Reason: %s", info.Reason))
+		return nil
+	}
+
+	bannedPlayerName := args[0]
+	reason := strings.Join(args[1:], " ")
+	if reason == "" {
+		reason = "Banned by " + playerName
+	}
+
+	status, err := ch.rcon.Status()
+	if err != nil {
+		ch.sendPlayerMessage(playerName, "Failed to get server status")
+		return err
+	}
+
+	var bannedGUID, bannedIP string
+	var bannedEntityID int
+	searchName := strings.ToLower(bannedPlayerName)
+	for _, player := range status.Players {
+		if strings.ToLower(player.StrippedName) == searchName || strings.Contains(strings.ToLower(player.StrippedName), searchName) {
+			bannedGUID = player.Uuid
+			bannedPlayerName = player.StrippedName
+			bannedEntityID = player.ID
+			bannedIP = player.Address
+			break
+		}
+	}
+
+	if bannedGUID == "" {
+		ch.sendPlayerMessage(playerName, fmt.Sprintf("Player '%s' not found online", bannedPlayerName))
+		return nil
+	}
+
+	if bannedGUID == playerGUID {
+		ch.sendPlayerMessage(playerName, "You cannot ban yourself")
+		return nil
+	}
+
+	throttleResult := models.CommandThrottlerInstance.CheckThrottle(playerGUID, bannedGUID, "ban", 30*time.Second)
+	if !throttleResult.Allowed {
+		ch.sendPlayerMessage(playerName, fmt.Sprintf("^1%s", throttleResult.Reason))
+		return nil
+	}
+
+	if existing, _ := models.FindMatchingBan(bannedGUID, bannedIP, bannedPlayerName); existing != nil {
+		ch.rcon.Kick(strconv.Itoa(bannedEntityID), existing.KickMessage())
+		ch.sendPlayerMessage(playerName, fmt.Sprintf("^3%s is already banned (Ban #%d)", bannedPlayerName, existing.ID))
+		return nil
+	}
+
+	ban := &models.Ban{
+		Type:         models.BanTypeGUID,
+		Value:        bannedGUID,
+		PlayerName:   bannedPlayerName,
+		PlayerGUID:   bannedGUID,
+		Reason:       reason,
+		BannedByName: playerName,
+		ServerID:     ch.serverID,
+		Source:       "in-game",
+	}
+	if err := models.CreateBan(ban); err != nil {
+		ch.sendPlayerMessage(playerName, "Failed to create ban")
+		return err
+	}
+
+	events.Publish(events.PlayerBanned, ch.serverID, events.BanPayload{
+		PlayerName: bannedPlayerName,
+		PlayerGUID: bannedGUID,
+		BannedBy:   playerName,
+		Reason:     reason,
+		BanType:    "permanent",
+		Source:     "in-game",
+		BanID:      &ban.ID,
+	})
+
+	ch.logPlayerAudit(playerName, models.ActionBanPlayer, bannedGUID, bannedPlayerName,
+		map[string]interface{}{"reason": reason, "ban_id": ban.ID, "issued_by": playerName},
+		fmt.Sprintf("Banned (Ban #%d): %s", ban.ID, reason))
+
+	ch.rcon.Kick(strconv.Itoa(bannedEntityID), ban.KickMessage())
+
+	ch.sendPlayerMessage(playerName, fmt.Sprintf("^2%s has been banned (Ban #%d)", bannedPlayerName, ban.ID))
+	logger.Info(fmt.Sprintf("Player %s banned %s (GUID: %s), ban #%d: %s", playerName, bannedPlayerName, bannedGUID, ban.ID, reason))
+
+	return nil
+}
+
+// handleUnbanCommand revokes bans by ban ID, GUID or player name
+func (ch *CommandHandler) handleUnbanCommand(ch2 *CommandHandler, playerName, playerGUID string, args []string) error {
+	if len(args) < 1 {
+		ch.sendPlayerMessage(playerName, "Usage: !unban <banId|guid|name>")
+		return nil
+	}
+
+	target := strings.Join(args, " ")
+	bans, err := findBans(target)
+	if err == models.ErrAmbiguousBan {
+		ch.sendPlayerMessage(playerName, fmt.Sprintf("More than one ban matches '%s' - use the ban ID", target))
+		return nil
+	}
+	if err != nil {
+		ch.sendPlayerMessage(playerName, "Failed to look up bans")
+		return err
+	}
+
+	revoked := 0
+	for _, ban := range bans {
+		if !ban.IsInEffect() {
+			continue
+		}
+		if err := models.RevokeBan(ban.ID, playerName, "Unbanned in-game"); err != nil {
+			continue
+		}
+		revoked++
+
+		events.Publish(events.PlayerUnbanned, ch.serverID, events.BanPayload{
+			PlayerName: ban.PlayerName,
+			PlayerGUID: ban.PlayerGUID,
+			BannedBy:   playerName,
+			Source:     "in-game",
+			BanID:      &ban.ID,
+		})
+		ch.logPlayerAudit(playerName, models.ActionUnbanPlayer, ban.PlayerGUID, ban.PlayerName,
+			map[string]interface{}{"ban_id": ban.ID, "issued_by": playerName},
+			fmt.Sprintf("Revoked ban #%d", ban.ID))
+	}
+
+	if revoked == 0 {
+		ch.sendPlayerMessage(playerName, fmt.Sprintf("No active ban found for '%s'", target))
+		return nil
+	}
+
+	ch.sendPlayerMessage(playerName, fmt.Sprintf("^2Revoked %d ban(s) for '%s'", revoked, target))
+	logger.Info(fmt.Sprintf("Player %s revoked %d ban(s) for %s", playerName, revoked, target))
+
+	return nil
+}
+
+// handleBanInfoCommand shows the details of a ban by ban ID, GUID or player name
+func (ch *CommandHandler) handleBanInfoCommand(ch2 *CommandHandler, playerName, playerGUID string, args []string) error {
+	if len(args) < 1 {
+		ch.sendPlayerMessage(playerName, "Usage: !baninfo <banId|guid|name>")
+		return nil
+	}
+
+	target := strings.Join(args, " ")
+	bans, err := findBans(target)
+	if err == models.ErrAmbiguousBan {
+		ch.sendPlayerMessage(playerName, fmt.Sprintf("More than one ban matches '%s' - use the ban ID", target))
+		return nil
+	}
+	if err != nil {
+		ch.sendPlayerMessage(playerName, "Failed to look up bans")
+		return err
+	}
+
+	if len(bans) == 0 {
+		ch.sendPlayerMessage(playerName, fmt.Sprintf("No ban found for '%s'", target))
+		return nil
+	}
+
+	ban := bans[0]
+	state := "^2active"
+	switch {
+	case ban.RevokedAt != nil:
+		state = fmt.Sprintf("^3revoked by %s", ban.RevokedByName)
+	case !ban.IsInEffect():
+		state = "^3expired"
+	}
+
+	expiry := "permanent"
+	if ban.ExpiresAt != nil {
+		expiry = "until " + ban.ExpiresAt.Format("2006-01-02 15:04")
+	}
+
+	ch.sendPlayerMessage(playerName, fmt.Sprintf("^3Ban #%d ^7(%s^7) - %s %s", ban.ID, state, ban.Type, ban.Value))
+	ch.sendPlayerMessage(playerName, fmt.Sprintf("^7Player: ^2%s ^7By: ^3%s ^7On: %s, %s",
+		ban.PlayerName, ban.BannedByName, ban.CreatedAt.Format("2006-01-02"), expiry))
+	ch.sendPlayerMessage(playerName, fmt.Sprintf("^7Reason: %s", ban.Reason))
+	if len(bans) > 1 {
+		ch.sendPlayerMessage(playerName, fmt.Sprintf("^7%d more ban(s) match - use the ban ID", len(bans)-1))
+	}
+
+	return nil
+}
+
+// findBans resolves a ban ID ("12" or "#12") to that ban, or a GUID, IP or
+// name to the bans in effect for it, newest first (see FindBansForPlayer)
+func findBans(target string) ([]models.Ban, error) {
+	if id, err := strconv.ParseUint(strings.TrimPrefix(target, "#"), 10, 32); err == nil {
+		ban, err := models.GetBanByID(uint(id))
+		if err == models.ErrBanNotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []models.Ban{*ban}, nil
+	}
+
+	return models.FindBansForPlayer(target)
+}
+
+// logPlayerAudit records an in-game action against a player in the audit log
+func (ch *CommandHandler) logPlayerAudit(playerName string, action models.ActionType, targetGUID, targetName string, metadata map[string]interface{}, result string) {
+	metadataJSON, _ := json.Marshal(metadata)
+	models.CreateAuditLog(
+		ch.db.(*gorm.DB),
+		nil,
+		playerName,
+		"",
+		action,
+		models.SourceInGame,
+		true,
+		"",
+		"player",
+		targetGUID,
+		targetName,
+		string(metadataJSON),
+		result,
+	)
+}
@@ -16,21 +16,27 @@ import (
 
 type CommandHandler struct {
 	rcon             *rcon.Client
+	serverID         *uint
 	db               interface{}
 	callbacks        map[string]CommandCallback
 	recentCommands   map[string]time.Time // Track recent commands to prevent duplicates
 	commandMutex     sync.Mutex           // Mutex for thread-safe access to recentCommands
 	pluginCommandAPI *plugins.CommandAPIImpl
+	voting           mapVoting
+	stopChan         chan struct{}
+	stopOnce         sync.Once
 }
 
 type CommandCallback func(ch *CommandHandler, playerName, playerGUID string, args []string) error
 
-func NewCommandHandler(rconClient *rcon.Client, db interface{}) *CommandHandler {
+func NewCommandHandler(rconClient *rcon.Client, db interface{}, serverID *uint) *CommandHandler {
 	handler := &CommandHandler{
 		rcon:           rconClient,
+		serverID:       serverID,
 		db:             db,
 		callbacks:      make(map[string]CommandCallback),
 		recentCommands: make(map[string]time.Time),
+		stopChan:       make(chan struct{}),
 	}
 
 	handler.registerBuiltInCallbacks()
@@ -41,6 +47,13 @@ func NewCommandHandler(rconClient *rcon.Client, db interface{}) *CommandHandler
 	return handler
 }
 
+// Stop stops the handler's background cleanup
+func (ch *CommandHandler) Stop() {
+	ch.stopOnce.Do(func() {
+		close(ch.stopChan)
+	})
+}
+
 // SetPluginCommandAPI sets the plugin command API
 func (ch *CommandHandler) SetPluginCommandAPI(api *plugins.CommandAPIImpl) {
 	ch.pluginCommandAPI = api
@@ -51,15 +64,20 @@ func (ch *CommandHandler) cleanupRecentCommands() {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		ch.commandMutex.Lock()
-		cutoff := time.Now().Add(-5 * time.Second)
-		for key, timestamp := range ch.recentCommands {
-			if timestamp.Before(cutoff) {
-				delete(ch.recentCommands, key)
+	for {
+		select {
+		case <-ticker.C:
+			ch.commandMutex.Lock()
+			cutoff := time.Now().Add(-5 * time.Second)
+			for key, timestamp := range ch.recentCommands {
+				if timestamp.Before(cutoff) {
+					delete(ch.recentCommands, key)
+				}
 			}
+			ch.commandMutex.Unlock()
+		case <-ch.stopChan:
+			return
 		}
-		ch.commandMutex.Unlock()
 	}
 }
 
@@ -110,7 +128,7 @@ func (ch *CommandHandler) ProcessChatCommand(playerName, playerGUID, message str
 		pluginCmds := ch.pluginCommandAPI.GetRegisteredCommands()
 		if _, isPluginCmd := pluginCmds[commandName]; isPluginCmd {
 			// Process the plugin command
-			if err := ch.pluginCommandAPI.ProcessPluginCommand(playerName, playerGUID, commandName, args); err != nil {
+			if err := ch.pluginCommandAPI.ProcessPluginCommand(ch.serverID, playerName, playerGUID, commandName, args); err != nil {
 				// Command execution failed
 				return err
 			}
@@ -314,6 +332,27 @@ func (ch *CommandHandler) buildRconCommand(template string, args []string, playe
 	return strings.TrimSpace(result)
 }
 
+// BuildCustomCommand expands a custom command's RCON template with the given
+// arguments, for running the command outside of chat. Built-in commands
+// have no template and can't be expanded.
+func (ch *CommandHandler) BuildCustomCommand(name string, args []string, playerName string) (string, error) {
+	cmd, err := models.GetCustomCommand(strings.ToLower(name))
+	if err != nil {
+		return "", fmt.Errorf("command '%s' not found", name)
+	}
+	if !cmd.Enabled {
+		return "", fmt.Errorf("command '%s' is disabled", name)
+	}
+	if cmd.RconCommand == "" {
+		return "", fmt.Errorf("command '%s' has no RCON template", name)
+	}
+	if len(args) < cmd.MinArgs || (cmd.MaxArgs >= 0 && len(args) > cmd.MaxArgs) {
+		return "", fmt.Errorf("usage: %s", cmd.Usage)
+	}
+
+	return ch.buildRconCommand(cmd.RconCommand, args, playerName, ""), nil
+}
+
 // getPlayerPermissions gets all permissions for a player based on their group
 func (ch *CommandHandler) getPlayerPermissions(guid string) []string {
 	player, err := models.GetInGamePlayerByGUID(guid)
@@ -334,8 +373,7 @@ func (ch *CommandHandler) getPlayerPermissions(guid string) []string {
 
 // sendPlayerMessage sends a message to a specific player
 func (ch *CommandHandler) sendPlayerMessage(playerName, message string) {
-	cmd := fmt.Sprintf("tell %s ^7%s", playerName, message)
-	ch.rcon.SendCommand(cmd)
+	ch.rcon.Tell(playerName, "^7"+message)
 }
 
 // resolveArgsFromPlaceholders resolves {argsFrom:N} placeholders by joining args from index N onwards
@@ -423,4 +461,14 @@ func (ch *CommandHandler) registerBuiltInCallbacks() {
 	ch.callbacks["help"] = ch.handleHelpCommand
 	ch.callbacks["report"] = ch.handleReportCommand
 	ch.callbacks["tempban"] = ch.handleTempBanCommand
+	ch.callbacks["stats"] = ch.handleStatsCommand
+	ch.callbacks["top"] = ch.handleTopCommand
+	ch.callbacks["aliases"] = ch.handleAliasesCommand
+	ch.callbacks["ban"] = ch.handleBanCommand
+	ch.callbacks["unban"] = ch.handleUnbanCommand
+	ch.callbacks["baninfo"] = ch.handleBanInfoCommand
+	ch.callbacks["warn"] = ch.handleWarnCommand
+	ch.callbacks["votemap"] = ch.handleVoteMapCommand
+	ch.callbacks["nextmap"] = ch.handleNextMapCommand
+	ch.callbacks["rtv"] = ch.handleRTVCommand
 }
@@ -0,0 +1,536 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/games"
+	"github.com/ethanburkett/goadmin/app/logger"
+	"github.com/ethanburkett/goadmin/app/models"
+	"github.com/ethanburkett/goadmin/app/rcon"
+)
+
+// rtvChangeDelay gives players time to read a rock-the-vote result before
+// the map changes
+const rtvChangeDelay = 5 * time.Second
+
+// mapVote is an open vote on the next map
+type mapVote struct {
+	candidates []string
+	votes      map[string]string // Map by voter GUID
+	rtv        bool              // Started by !rtv: the winner is loaded straight away
+	generation int               // mapVoting.generation when the vote opened
+}
+
+// mapVoting is a server's map vote state
+type mapVoting struct {
+	mu         sync.Mutex
+	vote       *mapVote
+	rockers    map[string]bool // GUIDs that typed !rtv on this map
+	lastVote   time.Time       // When the last vote closed
+	rtvOpened  bool            // A rock-the-vote ballot is being prepared
+	closing    bool            // The last vote is being counted
+	nextMap    string          // Winner set as the next map, until a map starts
+	generation int             // Bumped when a map starts, so votes from earlier maps are dropped
+}
+
+// candidate resolves a ballot number or map name to a candidate, or ""
+func (v *mapVote) candidate(query string) string {
+	if n, err := strconv.Atoi(query); err == nil {
+		if n >= 1 && n <= len(v.candidates) {
+			return v.candidates[n-1]
+		}
+		return ""
+	}
+	return matchMap(v.candidates, query)
+}
+
+// tally returns the map with the most votes. Ties go to the map that was
+// on the ballot first.
+func (v *mapVote) tally() (string, int) {
+	counts := make(map[string]int)
+	for _, m := range v.votes {
+		counts[m]++
+	}
+
+	var winner string
+	var best int
+	for _, m := range v.candidates {
+		if counts[m] > best {
+			winner, best = m, counts[m]
+		}
+	}
+	return winner, best
+}
+
+// ballot formats the candidates as "1. mp_crash  2. mp_strike"
+func (v *mapVote) ballot() string {
+	parts := make([]string, len(v.candidates))
+	for i, m := range v.candidates {
+		parts[i] = fmt.Sprintf("^3%d. ^7%s", i+1, m)
+	}
+	return strings.Join(parts, "  ")
+}
+
+// handleVoteMapCommand starts a vote on the next map, or votes in the open
+// one. Each player has one vote; voting again moves it.
+func (ch *CommandHandler) handleVoteMapCommand(ch2 *CommandHandler, playerName, playerGUID string, args []string) error {
+	settings := models.GetMapVoteSettings()
+	query := strings.ToLower(strings.Join(args, " "))
+
+	status, err := ch.rcon.Status()
+	if err != nil {
+		ch.sendPlayerMessage(playerName, "Failed to get server status")
+		return err
+	}
+
+	known, err := ch.knownMaps()
+	if err != nil {
+		ch.sendPlayerMessage(playerName, "Failed to load the map list")
+		return err
+	}
+	match := matchMap(known, query)
+
+	ch.voting.mu.Lock()
+	if vote := ch.voting.vote; vote != nil {
+		choice := vote.candidate(query)
+		if choice == "" {
+			switch {
+			case vote.rtv || len(vote.candidates) >= settings.MaxCandidates:
+				ch.voting.mu.Unlock()
+				ch.sendPlayerMessage(playerName, "Vote for one of: "+vote.ballot())
+				return nil
+			case match == "":
+				ch.voting.mu.Unlock()
+				ch.sendPlayerMessage(playerName, fmt.Sprintf("Unknown map: %s", query))
+				return nil
+			case settings.IsBlacklisted(match) || strings.EqualFold(match, status.Map):
+				ch.voting.mu.Unlock()
+				ch.sendPlayerMessage(playerName, fmt.Sprintf("%s can't be voted for", match))
+				return nil
+			}
+			choice = match
+			vote.candidates = append(vote.candidates, choice)
+		}
+		vote.votes[playerGUID] = choice
+		votes := 0
+		for _, m := range vote.votes {
+			if m == choice {
+				votes++
+			}
+		}
+		ch.voting.mu.Unlock()
+
+		ch.rcon.Say(fmt.Sprintf("^2%s ^7voted for ^3%s ^7(%d)", playerName, choice, votes))
+		return nil
+	}
+
+	if ch.voting.rtvOpened {
+		ch.voting.mu.Unlock()
+		ch.sendPlayerMessage(playerName, "A rock-the-vote ballot is opening")
+		return nil
+	}
+	if wait := ch.voteCooldown(settings); wait > 0 {
+		ch.voting.mu.Unlock()
+		ch.sendPlayerMessage(playerName, fmt.Sprintf("The next map vote can start in %s", formatDuration(wait)))
+		return nil
+	}
+	switch {
+	case match == "":
+		ch.voting.mu.Unlock()
+		ch.sendPlayerMessage(playerName, fmt.Sprintf("Unknown map: %s", query))
+		return nil
+	case settings.IsBlacklisted(match) || strings.EqualFold(match, status.Map):
+		ch.voting.mu.Unlock()
+		ch.sendPlayerMessage(playerName, fmt.Sprintf("%s can't be voted for", match))
+		return nil
+	}
+
+	ch.openMapVote([]string{match}, false, settings)
+	ch.voting.vote.votes[playerGUID] = match
+	ch.voting.mu.Unlock()
+
+	logger.Info(fmt.Sprintf("Player %s started a map vote for %s", playerName, match))
+	ch.rcon.Say(fmt.Sprintf("^3Map vote: ^2%s ^7wants ^3%s ^7next. Type ^2!votemap <map> ^7within %ds",
+		playerName, match, settings.DurationSeconds))
+	return nil
+}
+
+// handleNextMapCommand tells the player which map the rotation loads next
+func (ch *CommandHandler) handleNextMapCommand(ch2 *CommandHandler, playerName, playerGUID string, args []string) error {
+	var mapName, gametype string
+	for _, cvar := range []string{"sv_mapRotationCurrent", "sv_mapRotation"} {
+		value, err := ch.rcon.GetCvar(cvar)
+		if err != nil && !errors.Is(err, rcon.ErrUnknownCvar) {
+			ch.sendPlayerMessage(playerName, "Failed to read the map rotation")
+			return err
+		}
+		if value != nil {
+			if mapName, gametype = nextRotationMap(value.Value); mapName != "" {
+				break
+			}
+		}
+	}
+
+	if mapName == "" {
+		ch.sendPlayerMessage(playerName, "No map rotation is set")
+		return nil
+	}
+
+	message := fmt.Sprintf("Next map: ^2%s", mapName)
+	if gametype != "" {
+		message += fmt.Sprintf(" ^7(%s)", gametype)
+	}
+
+	ch.voting.mu.Lock()
+	voting := ch.voting.vote != nil
+	ch.voting.mu.Unlock()
+	if voting {
+		message += " ^7- a map vote is open"
+	}
+
+	ch.sendPlayerMessage(playerName, message)
+	return nil
+}
+
+// handleRTVCommand counts a player towards rocking the vote. Once enough
+// players have, a vote opens and its winner is loaded straight away.
+func (ch *CommandHandler) handleRTVCommand(ch2 *CommandHandler, playerName, playerGUID string, args []string) error {
+	settings := models.GetMapVoteSettings()
+
+	status, err := ch.rcon.Status()
+	if err != nil {
+		ch.sendPlayerMessage(playerName, "Failed to get server status")
+		return err
+	}
+	needed := quorum(voters(status), settings.RTVQuorum)
+
+	ch.voting.mu.Lock()
+	if ch.voting.vote != nil || ch.voting.rtvOpened {
+		ch.voting.mu.Unlock()
+		ch.sendPlayerMessage(playerName, "A map vote is already open. Type !votemap <number>")
+		return nil
+	}
+	if wait := ch.voteCooldown(settings); wait > 0 {
+		ch.voting.mu.Unlock()
+		ch.sendPlayerMessage(playerName, fmt.Sprintf("The next map vote can start in %s", formatDuration(wait)))
+		return nil
+	}
+	if ch.voting.rockers == nil {
+		ch.voting.rockers = make(map[string]bool)
+	}
+	if ch.voting.rockers[playerGUID] {
+		count := len(ch.voting.rockers)
+		ch.voting.mu.Unlock()
+		ch.sendPlayerMessage(playerName, fmt.Sprintf("You already rocked the vote (%d/%d)", count, needed))
+		return nil
+	}
+	ch.voting.rockers[playerGUID] = true
+	count := len(ch.voting.rockers)
+	if count < needed {
+		ch.voting.mu.Unlock()
+		ch.rcon.Say(fmt.Sprintf("^2%s ^7wants a new map (%d/%d). Type ^3!rtv", playerName, count, needed))
+		return nil
+	}
+	ch.voting.rockers = nil
+	ch.voting.rtvOpened = true
+	generation := ch.voting.generation
+	ch.voting.mu.Unlock()
+
+	candidates, err := ch.rtvCandidates(status.Map, settings)
+
+	ch.voting.mu.Lock()
+	if ch.voting.generation != generation {
+		// The map changed while the ballot was being prepared
+		ch.voting.mu.Unlock()
+		return nil
+	}
+	ch.voting.rtvOpened = false
+	if err != nil || len(candidates) == 0 {
+		ch.voting.mu.Unlock()
+		ch.rcon.Say("^3Rock the vote: ^7no maps to vote on")
+		return err
+	}
+	ch.openMapVote(candidates, true, settings)
+	ballot := ch.voting.vote.ballot()
+	ch.voting.mu.Unlock()
+
+	logger.Info(fmt.Sprintf("Rock the vote reached %d/%d players, opening a map vote", count, needed))
+	ch.rcon.Say(fmt.Sprintf("^3Rock the vote! ^7Type ^2!votemap <number> ^7within %ds", settings.DurationSeconds))
+	ch.rcon.Say(ballot)
+	return nil
+}
+
+// ResetMapVoting drops the open vote or rock-the-vote ballot, who rocked
+// the vote and the winner waiting to be loaded. It is called when a new map
+// starts.
+func (ch *CommandHandler) ResetMapVoting() {
+	ch.voting.mu.Lock()
+	ch.voting.vote = nil
+	ch.voting.rtvOpened = false
+	ch.voting.rockers = nil
+	ch.voting.nextMap = ""
+	ch.voting.generation++
+	ch.voting.mu.Unlock()
+}
+
+// MapVotePending reports whether a map vote is open or its winner hasn't
+// been loaded yet. Rotation switches wait for it, since pushing a rotation
+// would drop the winner from sv_mapRotationCurrent.
+func (ch *CommandHandler) MapVotePending() bool {
+	ch.voting.mu.Lock()
+	defer ch.voting.mu.Unlock()
+	return ch.voting.vote != nil || ch.voting.rtvOpened || ch.voting.closing || ch.voting.nextMap != ""
+}
+
+// openMapVote opens a vote and schedules its close. The caller holds the
+// voting lock.
+func (ch *CommandHandler) openMapVote(candidates []string, rtv bool, settings models.MapVoteSettings) {
+	vote := &mapVote{
+		candidates: candidates,
+		votes:      make(map[string]string),
+		rtv:        rtv,
+		generation: ch.voting.generation,
+	}
+	ch.voting.vote = vote
+	time.AfterFunc(time.Duration(settings.DurationSeconds)*time.Second, func() {
+		ch.closeMapVote(vote)
+	})
+}
+
+// closeMapVote counts a vote if it is still the open one. With enough
+// voters the winner is put at the front of sv_mapRotationCurrent, and
+// loaded at once for !rtv. A vote dropped by a map change does nothing.
+func (ch *CommandHandler) closeMapVote(vote *mapVote) {
+	select {
+	case <-ch.stopChan:
+		return
+	default:
+	}
+
+	ch.voting.mu.Lock()
+	if ch.voting.vote != vote {
+		ch.voting.mu.Unlock()
+		return
+	}
+	ch.voting.vote = nil
+	ch.voting.lastVote = time.Now()
+	ch.voting.closing = true
+	ch.voting.mu.Unlock()
+
+	defer func() {
+		ch.voting.mu.Lock()
+		ch.voting.closing = false
+		ch.voting.mu.Unlock()
+	}()
+
+	settings := models.GetMapVoteSettings()
+	status, err := ch.rcon.Status()
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to get server status to close the map vote: %v", err))
+		return
+	}
+
+	needed := quorum(voters(status), settings.VoteQuorum)
+	if len(vote.votes) < needed {
+		ch.rcon.Say(fmt.Sprintf("^3Map vote failed: ^7%d of the %d votes needed", len(vote.votes), needed))
+		return
+	}
+
+	winner, votes := vote.tally()
+	if !ch.sameMap(vote) {
+		return
+	}
+	if err := ch.setNextMap(winner); err != nil {
+		logger.Error(fmt.Sprintf("Failed to set the next map to %s: %v", winner, err))
+		ch.rcon.Say("^3Map vote: ^7failed to set the next map")
+		return
+	}
+	logger.Info(fmt.Sprintf("Map vote won by %s with %d of %d votes", winner, votes, len(vote.votes)))
+
+	ch.voting.mu.Lock()
+	if ch.voting.generation == vote.generation {
+		ch.voting.nextMap = winner
+	}
+	ch.voting.mu.Unlock()
+
+	if !vote.rtv {
+		ch.rcon.Say(fmt.Sprintf("^3Map vote: ^2%s ^7wins with %d votes and is up next", winner, votes))
+		return
+	}
+
+	ch.rcon.Say(fmt.Sprintf("^3Rock the vote: ^2%s ^7wins with %d votes. Changing map...", winner, votes))
+	time.Sleep(rtvChangeDelay)
+	if _, err := ch.rcon.MapRotate(); err != nil {
+		logger.Error(fmt.Sprintf("Failed to rotate to %s: %v", winner, err))
+	}
+}
+
+// sameMap reports whether the map a vote was opened on is still running
+func (ch *CommandHandler) sameMap(vote *mapVote) bool {
+	ch.voting.mu.Lock()
+	defer ch.voting.mu.Unlock()
+	return ch.voting.generation == vote.generation
+}
+
+// setNextMap makes a map the next one the rotation loads, leaving the rest
+// of the rotation as it was
+func (ch *CommandHandler) setNextMap(mapName string) error {
+	rotation := "map " + mapName
+
+	current, err := ch.rcon.GetCvar("sv_mapRotationCurrent")
+	if err != nil && !errors.Is(err, rcon.ErrUnknownCvar) {
+		return err
+	}
+	if current != nil && strings.TrimSpace(current.Value) != "" {
+		rotation += " " + strings.TrimSpace(current.Value)
+	}
+
+	_, err = ch.rcon.SetCvar("sv_mapRotationCurrent", rotation)
+	return err
+}
+
+// rtvCandidates picks the maps for a rock-the-vote ballot: the next maps in
+// the rotation, topped up with random known maps
+func (ch *CommandHandler) rtvCandidates(currentMap string, settings models.MapVoteSettings) ([]string, error) {
+	known, err := ch.knownMaps()
+	if err != nil {
+		return nil, err
+	}
+
+	var pool []string
+	for _, cvar := range []string{"sv_mapRotationCurrent", "sv_mapRotation"} {
+		if value, err := ch.rcon.GetCvar(cvar); err == nil {
+			pool = append(pool, rotationMaps(value.Value)...)
+		}
+	}
+	shuffled := append([]string{}, known...)
+	rand.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	pool = append(pool, shuffled...)
+
+	var candidates []string
+	for _, m := range pool {
+		if len(candidates) >= settings.MaxCandidates {
+			break
+		}
+		m = strings.ToLower(m)
+		if strings.EqualFold(m, currentMap) || settings.IsBlacklisted(m) || hasMap(candidates, m) {
+			continue
+		}
+		candidates = append(candidates, m)
+	}
+	return candidates, nil
+}
+
+// knownMaps lists the maps this server can load
+func (ch *CommandHandler) knownMaps() ([]string, error) {
+	var server *models.Server
+	var serverID uint
+	if ch.serverID != nil {
+		serverID = *ch.serverID
+		server, _ = models.GetServerByID(serverID)
+	}
+	return games.KnownMaps(games.ForServer(server), serverID)
+}
+
+// voteCooldown returns how long until the next vote can start. The caller
+// holds the voting lock.
+func (ch *CommandHandler) voteCooldown(settings models.MapVoteSettings) time.Duration {
+	if ch.voting.lastVote.IsZero() {
+		return 0
+	}
+	wait := time.Until(ch.voting.lastVote.Add(time.Duration(settings.CooldownMinutes) * time.Minute))
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// matchMap finds a map by exact name, by name without the "mp_" prefix, or
+// by a part of its name that matches only one map
+func matchMap(maps []string, query string) string {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return ""
+	}
+
+	for _, m := range maps {
+		if strings.EqualFold(m, query) || strings.EqualFold(m, "mp_"+query) {
+			return m
+		}
+	}
+
+	var found string
+	for _, m := range maps {
+		if strings.Contains(strings.ToLower(m), query) {
+			if found != "" {
+				return ""
+			}
+			found = m
+		}
+	}
+	return found
+}
+
+func hasMap(maps []string, mapName string) bool {
+	for _, m := range maps {
+		if strings.EqualFold(m, mapName) {
+			return true
+		}
+	}
+	return false
+}
+
+// rotationMaps lists the maps in an sv_mapRotation value, in order
+func rotationMaps(rotation string) []string {
+	var maps []string
+	fields := strings.Fields(rotation)
+	for i := 0; i+1 < len(fields); i += 2 {
+		if strings.EqualFold(fields[i], "map") {
+			maps = append(maps, fields[i+1])
+		}
+	}
+	return maps
+}
+
+// nextRotationMap returns the first map in an sv_mapRotation value and the
+// gametype set before it
+func nextRotationMap(rotation string) (mapName, gametype string) {
+	fields := strings.Fields(rotation)
+	for i := 0; i+1 < len(fields); i += 2 {
+		switch strings.ToLower(fields[i]) {
+		case "gametype":
+			gametype = fields[i+1]
+		case "map":
+			return fields[i+1], gametype
+		}
+	}
+	return "", ""
+}
+
+// voters counts the players who can vote: connected and not bots
+func voters(status *rcon.StatusResponse) int {
+	count := 0
+	for _, p := range status.Players {
+		if p.State == rcon.PlayerStateActive && !p.IsBot {
+			count++
+		}
+	}
+	return count
+}
+
+// quorum is how many of players make up percent of them, at least one
+func quorum(players, percent int) int {
+	needed := (players*percent + 99) / 100
+	if needed < 1 {
+		return 1
+	}
+	return needed
+}
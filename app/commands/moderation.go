@@ -6,9 +6,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ethanburkett/goadmin/app/events"
 	"github.com/ethanburkett/goadmin/app/logger"
 	"github.com/ethanburkett/goadmin/app/models"
-	"github.com/ethanburkett/goadmin/app/webhook"
 	"gorm.io/gorm"
 )
 
@@ -48,23 +48,22 @@ func (ch *CommandHandler) handleReportCommand(ch2 *CommandHandler, playerName, p
 		return nil
 	}
 
-	report, err := models.CreateReport(playerName, playerGUID, reportedPlayerName, reportedGUID, reason, nil)
+	report, err := models.CreateReport(playerName, playerGUID, reportedPlayerName, reportedGUID, reason, ch.serverID)
 	if err != nil {
 		ch.sendPlayerMessage(playerName, "Failed to submit report")
 		return err
 	}
 
-	// Dispatch webhook event
-	go webhook.GlobalDispatcher.Dispatch(models.WebhookEventReportCreated, map[string]interface{}{
-		"report_id":     report.ID,
-		"reporter_name": playerName,
-		"reporter_guid": playerGUID,
-		"reported_name": reportedPlayerName,
-		"reported_guid": reportedGUID,
-		"reason":        reason,
-		"status":        report.Status,
-		"source":        "in-game",
-		"created_at":    report.CreatedAt.Format(time.RFC3339),
+	events.Publish(events.ReportCreated, ch.serverID, events.ReportPayload{
+		ReportID:     report.ID,
+		ReporterName: playerName,
+		ReporterGUID: playerGUID,
+		ReportedName: reportedPlayerName,
+		ReportedGUID: reportedGUID,
+		Reason:       reason,
+		Status:       report.Status,
+		Source:       "in-game",
+		CreatedAt:    report.CreatedAt.Format(time.RFC3339),
 	})
 
 	ch.sendPlayerMessage(playerName, fmt.Sprintf("^2Report submitted for %s (ID: #%d)", reportedPlayerName, report.ID))
@@ -189,25 +188,24 @@ func (ch *CommandHandler) handleTempBanCommand(ch2 *CommandHandler, playerName,
 		}
 	}
 
-	tempBan, err := models.CreateTempBan(bannedPlayerName, bannedGUID, reason, duration, nil, nil)
+	tempBan, err := models.CreateTempBan(bannedPlayerName, bannedGUID, reason, duration, nil, ch.serverID)
 	if err != nil {
 		ch.sendPlayerMessage(playerName, "Failed to create temp ban")
 		return err
 	}
 
-	// Dispatch webhook event
-	go webhook.GlobalDispatcher.Dispatch(models.WebhookEventPlayerBanned, map[string]interface{}{
-		"player_name":    bannedPlayerName,
-		"player_guid":    bannedGUID,
-		"banned_by":      playerName,
-		"reason":         reason,
-		"duration":       durationStr,
-		"expires_at":     tempBan.ExpiresAt.Format(time.RFC3339),
-		"ban_type":       "temporary",
-		"source":         "in-game",
-		"recent_bans":    banLoopResult.RecentBanCount,
-		"time_window":    banLoopResult.TimeWindow.String(),
-		"abuse_detected": banLoopResult.IsAbuse,
+	events.Publish(events.PlayerBanned, ch.serverID, events.BanPayload{
+		PlayerName:    bannedPlayerName,
+		PlayerGUID:    bannedGUID,
+		BannedBy:      playerName,
+		Reason:        reason,
+		Duration:      durationStr,
+		ExpiresAt:     tempBan.ExpiresAt.Format(time.RFC3339),
+		BanType:       "temporary",
+		Source:        "in-game",
+		RecentBans:    banLoopResult.RecentBanCount,
+		TimeWindow:    banLoopResult.TimeWindow.String(),
+		AbuseDetected: banLoopResult.IsAbuse,
 	})
 
 	// Log audit entry for in-game temp ban
@@ -227,11 +225,8 @@ func (ch *CommandHandler) handleTempBanCommand(ch2 *CommandHandler, playerName,
 		fmt.Sprintf("Temporarily banned for %s: %s", durationStr, reason),
 	)
 
-	kickCmd := fmt.Sprintf("clientkick %d \"Temp banned: %s (Expires: %s)\"",
-		bannedEntityID,
-		reason,
-		tempBan.ExpiresAt.Format("2006-01-02 15:04"))
-	ch.rcon.SendCommand(kickCmd)
+	kickReason := fmt.Sprintf("Temp banned: %s (Expires: %s)", reason, tempBan.ExpiresAt.Format("2006-01-02 15:04"))
+	ch.rcon.Kick(strconv.Itoa(bannedEntityID), kickReason)
 
 	ch.sendPlayerMessage(playerName, fmt.Sprintf("^2%s has been temp banned for %s", bannedPlayerName, durationStr))
 	logger.Info(fmt.Sprintf("Player %s temp banned %s (GUID: %s) for %s: %s", playerName, bannedPlayerName, bannedGUID, durationStr, reason))
@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/models"
+)
+
+// topLimit is how many players !top lists
+const topLimit = 5
+
+// handleStatsCommand shows kill stats for the player or another online player
+func (ch *CommandHandler) handleStatsCommand(ch2 *CommandHandler, playerName, playerGUID string, args []string) error {
+	targetName := playerName
+	targetGUID := playerGUID
+
+	if len(args) > 0 {
+		status, err := ch.rcon.Status()
+		if err != nil {
+			ch.sendPlayerMessage(playerName, "Failed to get server status")
+			return err
+		}
+
+		targetGUID = ""
+		searchName := strings.ToLower(strings.Join(args, " "))
+		for _, player := range status.Players {
+			if strings.ToLower(player.StrippedName) == searchName || strings.Contains(strings.ToLower(player.StrippedName), searchName) {
+				targetGUID = player.Uuid
+				targetName = player.StrippedName
+				break
+			}
+		}
+
+		if targetGUID == "" {
+			ch.sendPlayerMessage(playerName, fmt.Sprintf("Player '%s' not found online", strings.Join(args, " ")))
+			return nil
+		}
+	}
+
+	filter := models.StatsFilter{ServerID: ch.serverID}
+	summary, err := models.GetPlayerStatSummary(targetGUID, filter)
+	if err != nil {
+		ch.sendPlayerMessage(playerName, "Failed to fetch stats")
+		return err
+	}
+
+	if summary.Kills == 0 && summary.Deaths == 0 {
+		ch.sendPlayerMessage(playerName, fmt.Sprintf("No stats recorded for %s yet", targetName))
+		return nil
+	}
+
+	rank, err := models.GetPlayerRank(targetGUID, filter)
+	if err != nil {
+		ch.sendPlayerMessage(playerName, "Failed to fetch stats")
+		return err
+	}
+
+	rankText := "unranked"
+	if rank > 0 {
+		rankText = fmt.Sprintf("#%d", rank)
+	}
+
+	ch.sendPlayerMessage(playerName, fmt.Sprintf("^3Stats for ^2%s ^7(Rank: ^3%s^7)", targetName, rankText))
+	ch.sendPlayerMessage(playerName, fmt.Sprintf("^7Kills: ^2%d ^7Deaths: ^1%d ^7K/D: ^3%.2f",
+		summary.Kills, summary.Deaths, summary.KDRatio))
+	ch.sendPlayerMessage(playerName, fmt.Sprintf("^7Headshots: ^3%d ^7Best Streak: ^3%d ^7Playtime: ^3%s",
+		summary.Headshots, summary.BestStreak, formatDuration(time.Duration(summary.PlaytimeSeconds)*time.Second)))
+
+	return nil
+}
+
+// handleTopCommand shows the leaderboard for this server
+func (ch *CommandHandler) handleTopCommand(ch2 *CommandHandler, playerName, playerGUID string, args []string) error {
+	sort := models.LeaderboardKills
+	if len(args) > 0 {
+		sort = strings.ToLower(args[0])
+	}
+
+	if !models.ValidLeaderboardSort(sort) {
+		ch.sendPlayerMessage(playerName, "Usage: !top [kills|kd|headshots|streak|playtime]")
+		return nil
+	}
+
+	minKills := 0
+	if sort == models.LeaderboardKD {
+		minKills = 10
+	}
+
+	leaderboard, err := models.GetLeaderboard(models.StatsFilter{ServerID: ch.serverID}, sort, minKills, topLimit)
+	if err != nil {
+		ch.sendPlayerMessage(playerName, "Failed to fetch leaderboard")
+		return err
+	}
+
+	if len(leaderboard) == 0 {
+		ch.sendPlayerMessage(playerName, "No stats recorded yet")
+		return nil
+	}
+
+	ch.sendPlayerMessage(playerName, fmt.Sprintf("^3Top %d by %s:", len(leaderboard), sort))
+	for i, entry := range leaderboard {
+		var value string
+		switch sort {
+		case models.LeaderboardKD:
+			value = fmt.Sprintf("%.2f K/D", entry.KDRatio)
+		case models.LeaderboardHeadshots:
+			value = fmt.Sprintf("%d headshots", entry.Headshots)
+		case models.LeaderboardStreak:
+			value = fmt.Sprintf("%d streak", entry.BestStreak)
+		case models.LeaderboardPlaytime:
+			value = formatDuration(time.Duration(entry.PlaytimeSeconds) * time.Second)
+		case models.LeaderboardDeaths:
+			value = fmt.Sprintf("%d deaths", entry.Deaths)
+		default:
+			value = fmt.Sprintf("%d kills", entry.Kills)
+		}
+		ch.sendPlayerMessage(playerName, fmt.Sprintf("^3%d. ^2%s ^7- %s", i+1, entry.Name, value))
+	}
+
+	return nil
+}
@@ -0,0 +1,203 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/events"
+	"github.com/ethanburkett/goadmin/app/logger"
+	"github.com/ethanburkett/goadmin/app/models"
+)
+
+// handleWarnCommand warns an online player and applies the escalation
+// ladder. The reason may be a preset key, optionally followed by details.
+func (ch *CommandHandler) handleWarnCommand(ch2 *CommandHandler, playerName, playerGUID string, args []string) error {
+	if len(args) < 2 {
+		ch.sendPlayerMessage(playerName, "Usage: !warn <player> <reason|preset>")
+		return nil
+	}
+
+	warnedPlayerName := args[0]
+
+	status, err := ch.rcon.Status()
+	if err != nil {
+		ch.sendPlayerMessage(playerName, "Failed to get server status")
+		return err
+	}
+
+	var warnedGUID string
+	var warnedEntityID int
+	searchName := strings.ToLower(warnedPlayerName)
+	for _, player := range status.Players {
+		if strings.ToLower(player.StrippedName) == searchName || strings.Contains(strings.ToLower(player.StrippedName), searchName) {
+			warnedGUID = player.Uuid
+			warnedPlayerName = player.StrippedName
+			warnedEntityID = player.ID
+			break
+		}
+	}
+
+	if warnedGUID == "" {
+		ch.sendPlayerMessage(playerName, fmt.Sprintf("Player '%s' not found online", warnedPlayerName))
+		return nil
+	}
+
+	if warnedGUID == playerGUID {
+		ch.sendPlayerMessage(playerName, "You cannot warn yourself")
+		return nil
+	}
+
+	throttleResult := models.CommandThrottlerInstance.CheckThrottle(playerGUID, warnedGUID, "warn", 10*time.Second)
+	if !throttleResult.Allowed {
+		ch.sendPlayerMessage(playerName, fmt.Sprintf("^1%s", throttleResult.Reason))
+		return nil
+	}
+
+	warning := &models.Warning{
+		PlayerName:   warnedPlayerName,
+		PlayerGUID:   warnedGUID,
+		Reason:       strings.Join(args[1:], " "),
+		IssuedByName: playerName,
+		ServerID:     ch.serverID,
+	}
+	message := warning.Reason
+
+	preset, err := models.GetWarningPresetByKey(args[1])
+	if err != nil {
+		ch.sendPlayerMessage(playerName, "Failed to look up warning presets")
+		return err
+	}
+	if preset != nil {
+		warning.PresetID = &preset.ID
+		warning.Preset = preset
+		warning.Reason = preset.Reason
+		message = preset.PlayerMessage()
+		if details := strings.Join(args[2:], " "); details != "" {
+			warning.Reason += ": " + details
+		}
+	}
+
+	if err := models.CreateWarning(warning); err != nil {
+		ch.sendPlayerMessage(playerName, "Failed to record warning")
+		return err
+	}
+
+	count, _ := models.CountActiveWarnings(warnedGUID, 0)
+
+	ch.logPlayerAudit(playerName, models.ActionWarnPlayer, warnedGUID, warnedPlayerName,
+		map[string]interface{}{"reason": warning.Reason, "warning_id": warning.ID, "active_warnings": count, "issued_by": playerName},
+		fmt.Sprintf("Warned (%d active): %s", count, warning.Reason))
+
+	ch.rcon.Say(fmt.Sprintf("^1WARNING ^7%s^7: %s ^3[%d]", warnedPlayerName, message, count))
+	logger.Info(fmt.Sprintf("Player %s warned %s (GUID: %s), %d active: %s", playerName, warnedPlayerName, warnedGUID, count, warning.Reason))
+
+	rule, reached, err := models.MatchEscalation(warnedGUID)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to check warning escalation for %s: %v", warnedGUID, err))
+		return nil
+	}
+	if rule == nil {
+		ch.sendPlayerMessage(playerName, fmt.Sprintf("^2%s warned (%d active)", warnedPlayerName, count))
+		return nil
+	}
+
+	if err := ch.escalateWarnings(playerName, warnedPlayerName, warnedGUID, warnedEntityID, warning, rule, reached); err != nil {
+		ch.sendPlayerMessage(playerName, fmt.Sprintf("^1Escalation to %s failed", rule.Describe()))
+		return err
+	}
+	ch.sendPlayerMessage(playerName, fmt.Sprintf("^2%s warned (%d active), escalated to %s", warnedPlayerName, count, rule.Describe()))
+
+	return nil
+}
+
+// escalateWarnings applies the escalation rule a player's warnings reached
+func (ch *CommandHandler) escalateWarnings(issuedBy, name, guid string, entityID int, warning *models.Warning, rule *models.WarningRule, reached int64) error {
+	slot := strconv.Itoa(entityID)
+	reason := rule.Message
+	if reason == "" {
+		reason = fmt.Sprintf("%d warnings in %dh", reached, rule.WindowHours)
+	}
+	metadata := map[string]interface{}{
+		"reason":     reason,
+		"warning_id": warning.ID,
+		"rule_id":    rule.ID,
+		"warnings":   reached,
+		"issued_by":  issuedBy,
+	}
+
+	switch rule.Action {
+	case models.EscalationKick:
+		if _, err := ch.rcon.Kick(slot, reason); err != nil {
+			return err
+		}
+		events.Publish(events.PlayerKicked, ch.serverID, events.KickPayload{
+			PlayerName: name,
+			PlayerID:   slot,
+			KickedBy:   issuedBy,
+			Reason:     reason,
+			Source:     "warnings",
+		})
+		ch.logPlayerAudit(issuedBy, models.ActionKickPlayer, guid, name, metadata,
+			fmt.Sprintf("Kicked after %d warnings: %s", reached, reason))
+
+	case models.EscalationTempBan:
+		tempBan, err := models.CreateTempBan(name, guid, reason, rule.Duration(), nil, ch.serverID)
+		if err != nil {
+			return err
+		}
+		events.Publish(events.PlayerBanned, ch.serverID, events.BanPayload{
+			PlayerName: name,
+			PlayerGUID: guid,
+			BannedBy:   issuedBy,
+			Reason:     reason,
+			Duration:   rule.Duration().String(),
+			ExpiresAt:  tempBan.ExpiresAt.Format(time.RFC3339),
+			BanType:    "temporary",
+			Source:     "warnings",
+		})
+		metadata["duration_minutes"] = rule.DurationMinutes
+		ch.logPlayerAudit(issuedBy, models.ActionTempBanPlayer, guid, name, metadata,
+			fmt.Sprintf("Temporarily banned for %s after %d warnings: %s", formatDuration(rule.Duration()), reached, reason))
+		ch.rcon.Kick(slot, fmt.Sprintf("Temp banned: %s (Expires: %s)", reason, tempBan.ExpiresAt.Format("2006-01-02 15:04")))
+
+	case models.EscalationBan:
+		ban := &models.Ban{
+			Type:         models.BanTypeGUID,
+			Value:        guid,
+			PlayerName:   name,
+			PlayerGUID:   guid,
+			Reason:       reason,
+			BannedByName: issuedBy,
+			ServerID:     ch.serverID,
+			Source:       "warnings",
+		}
+		if err := models.CreateBan(ban); err != nil {
+			return err
+		}
+		events.Publish(events.PlayerBanned, ch.serverID, events.BanPayload{
+			PlayerName: name,
+			PlayerGUID: guid,
+			BannedBy:   issuedBy,
+			Reason:     reason,
+			BanType:    "permanent",
+			Source:     "warnings",
+			BanID:      &ban.ID,
+		})
+		metadata["ban_id"] = ban.ID
+		ch.logPlayerAudit(issuedBy, models.ActionBanPlayer, guid, name, metadata,
+			fmt.Sprintf("Banned (Ban #%d) after %d warnings: %s", ban.ID, reached, reason))
+		ch.rcon.Kick(slot, ban.KickMessage())
+
+	default:
+		return fmt.Errorf("unknown escalation action: %s", rule.Action)
+	}
+
+	if err := models.SetWarningEscalation(warning.ID, rule.Describe()); err != nil {
+		logger.Error(fmt.Sprintf("Failed to record escalation of warning #%d: %v", warning.ID, err))
+	}
+	logger.Info(fmt.Sprintf("Escalated warnings for %s (GUID: %s) to %s after %d warnings", name, guid, rule.Describe(), reached))
+
+	return nil
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/spf13/viper"
 )
@@ -10,13 +11,39 @@ type ServerConfig struct {
 	Host         string `mapstructure:"host"`
 	Port         int    `mapstructure:"port"`
 	RconPassword string `mapstructure:"rcon_password"`
+	Game         string `mapstructure:"game"` // Game adapter, defaults to cod4
+
+	// Optional RCON tuning, zero values fall back to client defaults
+	RconMaxResponseSize   int `mapstructure:"rcon_max_response_size"`
+	RconQuietIntervalMs   int `mapstructure:"rcon_quiet_interval_ms"`
+	RconCommandIntervalMs int `mapstructure:"rcon_command_interval_ms"`
+}
+
+// LogWatchConfig controls how games_mp.log is followed
+type LogWatchConfig struct {
+	Mode           string `mapstructure:"mode"`             // "poll" (default) or "fsnotify"
+	PollIntervalMs int    `mapstructure:"poll_interval_ms"` // Defaults to 500
+
+	// Log source for the server created from this config: file (default),
+	// udp, http or poll. Address is the UDP listen address or polled path.
+	Source  string `mapstructure:"source"`
+	Address string `mapstructure:"address"`
+}
+
+// BanFeedConfig controls sharing bans with other GoAdmin instances. Peers
+// and their trust policy are managed from the dashboard.
+type BanFeedConfig struct {
+	Enabled      bool   `mapstructure:"enabled"`       // Serve our feed and pull peers' feeds
+	InstanceName string `mapstructure:"instance_name"` // How peers know this instance, defaults to the hostname
 }
 
 type Config struct {
-	Server      ServerConfig `mapstructure:"server"`
-	GamesMpPath string       `mapstructure:"games_mp_path"`
-	RestPort    int          `mapstructure:"rest_port"`
-	Environment string       `mapstructure:"environment"`
+	Server      ServerConfig   `mapstructure:"server"`
+	GamesMpPath string         `mapstructure:"games_mp_path"`
+	LogWatch    LogWatchConfig `mapstructure:"log_watch"`
+	BanFeed     BanFeedConfig  `mapstructure:"ban_feed"`
+	RestPort    int            `mapstructure:"rest_port"`
+	Environment string         `mapstructure:"environment"`
 }
 
 func LoadConfig() (*Config, error) {
@@ -35,5 +62,9 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("cannot unmarshal config: %w", err)
 	}
 
+	if config.BanFeed.InstanceName == "" {
+		config.BanFeed.InstanceName, _ = os.Hostname()
+	}
+
 	return &config, nil
 }
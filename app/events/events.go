@@ -0,0 +1,173 @@
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/logger"
+	"go.uber.org/zap"
+)
+
+// SchemaVersion is bumped whenever a payload changes in a way consumers
+// need to know about
+const SchemaVersion = 1
+
+// Type names an event. Names match the webhook and plugin event names.
+type Type string
+
+// Game events, published from the log watcher
+const (
+	PlayerConnect    Type = "player.connect"
+	PlayerDisconnect Type = "player.disconnect"
+	PlayerChat       Type = "player.chat"
+	PlayerTeamChat   Type = "player.team_chat"
+	PlayerKill       Type = "player.kill"
+	PlayerDamage     Type = "player.damage"
+	PlayerWeapon     Type = "player.weapon"
+	PlayerAction     Type = "player.action"
+	PlayerNameChange Type = "player.name_change"
+	MapStart         Type = "map.start"
+	MapEnd           Type = "map.end"
+	RoundEnd         Type = "round.end"
+)
+
+// Panel events, published when an admin or in-game command acts on a player
+const (
+	PlayerBanned   Type = "player.banned"
+	PlayerUnbanned Type = "player.unbanned"
+	PlayerKicked   Type = "player.kicked"
+	PlayerFlagged  Type = "player.flagged"
+	ReportCreated  Type = "report.created"
+	ReportActioned Type = "report.actioned"
+)
+
+// IsHighVolume reports whether a type fires many times per second on a busy
+// server. Sinks that do I/O per event usually skip these.
+func (t Type) IsHighVolume() bool {
+	return t == PlayerDamage || t == PlayerWeapon
+}
+
+// Event is the envelope every event is published in
+type Event struct {
+	ID        string      `json:"id"`
+	Version   int         `json:"version"`
+	Type      Type        `json:"type"`
+	ServerID  *uint       `json:"serverId,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// New creates an event stamped with a new ID and the current time
+func New(eventType Type, serverID *uint, data interface{}) Event {
+	return Event{
+		ID:        newID(),
+		Version:   SchemaVersion,
+		Type:      eventType,
+		ServerID:  serverID,
+		Timestamp: time.Now(),
+		Data:      data,
+	}
+}
+
+// DataMap returns the payload as a flat map using its JSON field names, for
+// consumers that work with untyped data (plugins, webhooks)
+func (e Event) DataMap() map[string]interface{} {
+	data := make(map[string]interface{})
+	if m, ok := e.Data.(map[string]interface{}); ok {
+		for k, v := range m {
+			data[k] = v
+		}
+		return data
+	}
+
+	raw, err := json.Marshal(e.Data)
+	if err == nil {
+		json.Unmarshal(raw, &data)
+	}
+	return data
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// Handler receives events. Each subscriber gets events in publish order on
+// its own goroutine, so a slow handler only delays itself.
+type Handler func(Event)
+
+// sinkBuffer is how many events can queue for a subscriber before new ones
+// are dropped
+const sinkBuffer = 1024
+
+type subscriber struct {
+	name    string
+	queue   chan Event
+	dropped atomic.Int64
+}
+
+// Bus fans events out to subscribers
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers []*subscriber
+}
+
+// Default is the bus used by the application
+var Default = NewBus()
+
+// NewBus creates an empty bus
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers a handler under a name used in logs
+func (b *Bus) Subscribe(name string, handler Handler) {
+	sub := &subscriber{name: name, queue: make(chan Event, sinkBuffer)}
+
+	go func() {
+		for event := range sub.queue {
+			handler(event)
+		}
+	}()
+
+	b.mu.Lock()
+	b.subscribers = append(b.subscribers, sub)
+	b.mu.Unlock()
+}
+
+// Publish queues an event for every subscriber without blocking
+func (b *Bus) Publish(event Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, sub := range b.subscribers {
+		select {
+		case sub.queue <- event:
+		default:
+			dropped := sub.dropped.Add(1)
+			if dropped%100 == 1 {
+				logger.Warn("Event subscriber is falling behind, dropping events",
+					zap.String("subscriber", sub.name),
+					zap.String("event", string(event.Type)),
+					zap.Int64("dropped", dropped))
+			}
+		}
+	}
+}
+
+// Subscribe registers a handler on the default bus
+func Subscribe(name string, handler Handler) {
+	Default.Subscribe(name, handler)
+}
+
+// Publish creates and publishes an event on the default bus
+func Publish(eventType Type, serverID *uint, data interface{}) {
+	Default.Publish(New(eventType, serverID, data))
+}
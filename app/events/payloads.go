@@ -0,0 +1,155 @@
+package events
+
+import (
+	"strconv"
+
+	"github.com/ethanburkett/goadmin/app/parser"
+)
+
+// PlayerPayload is the data of player.connect and player.disconnect
+type PlayerPayload struct {
+	PlayerName string `json:"playerName"`
+	PlayerGUID string `json:"playerGUID"`
+	PlayerID   string `json:"playerID"`
+}
+
+// NewPlayerPayload builds a PlayerPayload from a logged player
+func NewPlayerPayload(player parser.Combatant) PlayerPayload {
+	return PlayerPayload{
+		PlayerName: player.Name,
+		PlayerGUID: player.GUID,
+		PlayerID:   strconv.Itoa(player.Slot),
+	}
+}
+
+// ChatPayload is the data of player.chat and player.team_chat
+type ChatPayload struct {
+	PlayerPayload
+	Message string `json:"message"`
+}
+
+// NameChangePayload is the data of player.name_change
+type NameChangePayload struct {
+	PlayerPayload
+	OldName string `json:"oldName"`
+}
+
+// HitPayload is the data of player.kill and player.damage
+type HitPayload struct {
+	Victim       parser.Combatant `json:"victim"`
+	Attacker     parser.Combatant `json:"attacker"`
+	Weapon       string           `json:"weapon"`
+	Damage       int              `json:"damage"`
+	MeansOfDeath string           `json:"meansOfDeath"`
+	HitLocation  string           `json:"hitLocation"`
+	Headshot     bool             `json:"headshot"`
+	Suicide      bool             `json:"suicide,omitempty"`
+	TeamKill     bool             `json:"teamKill,omitempty"`
+}
+
+// NewHitPayload builds a HitPayload from a damage line
+func NewHitPayload(hit *parser.DamageEvent) HitPayload {
+	return HitPayload{
+		Victim:       hit.Victim,
+		Attacker:     hit.Attacker,
+		Weapon:       hit.Weapon,
+		Damage:       hit.Damage,
+		MeansOfDeath: hit.MeansOfDeath,
+		HitLocation:  hit.HitLocation,
+		Headshot:     hit.IsHeadshot(),
+	}
+}
+
+// NewKillPayload builds a HitPayload from a kill line
+func NewKillPayload(kill *parser.KillEvent) HitPayload {
+	payload := NewHitPayload(&kill.DamageEvent)
+	payload.Suicide = kill.IsSuicide()
+	payload.TeamKill = kill.IsTeamKill()
+	return payload
+}
+
+// WeaponPayload is the data of player.weapon
+type WeaponPayload struct {
+	PlayerPayload
+	Weapon string `json:"weapon"`
+}
+
+// ActionPayload is the data of player.action
+type ActionPayload struct {
+	PlayerPayload
+	Team   string `json:"team,omitempty"`
+	Action string `json:"action"`
+}
+
+// MapPayload is the data of map.start and map.end
+type MapPayload struct {
+	Map      string            `json:"map"`
+	GameType string            `json:"gameType"`
+	Settings map[string]string `json:"settings,omitempty"` // map.start only
+	Reason   string            `json:"reason,omitempty"`   // map.end: exit_level or shutdown
+}
+
+// RoundPayload is the data of round.end, published for each W and L line
+type RoundPayload struct {
+	Team    string              `json:"team"`
+	Win     bool                `json:"win"`
+	Players []parser.TeamMember `json:"players"`
+}
+
+// BanPayload is the data of player.banned and player.unbanned. Field names
+// match the webhook payloads that predate the event stream.
+type BanPayload struct {
+	PlayerName    string `json:"player_name"`
+	PlayerGUID    string `json:"player_guid"`
+	BannedBy      string `json:"banned_by,omitempty"`
+	BannedByID    *uint  `json:"banned_by_id,omitempty"`
+	Reason        string `json:"reason"`
+	Duration      string `json:"duration,omitempty"`
+	ExpiresAt     string `json:"expires_at,omitempty"`
+	BanType       string `json:"ban_type,omitempty"` // permanent or temporary
+	Source        string `json:"source"`
+	ReportID      *uint  `json:"report_id,omitempty"`
+	BanID         *uint  `json:"ban_id,omitempty"` // GoAdmin ban list entry, when one was created
+	AbuseDetected bool   `json:"abuse_detected"`
+	RecentBans    int    `json:"recent_bans,omitempty"`
+	TimeWindow    string `json:"time_window,omitempty"`
+}
+
+// KickPayload is the data of player.kicked
+type KickPayload struct {
+	PlayerName string `json:"player_name,omitempty"`
+	PlayerID   string `json:"player_id"`
+	KickedBy   string `json:"kicked_by,omitempty"`
+	KickedByID *uint  `json:"kicked_by_id,omitempty"`
+	Reason     string `json:"reason"`
+	Source     string `json:"source"`
+}
+
+// FlagPayload is the data of player.flagged, published when a player a
+// peer instance banned joins and we only watch them
+type FlagPayload struct {
+	PlayerName      string `json:"player_name"`
+	PlayerGUID      string `json:"player_guid"`
+	PlayerID        string `json:"player_id"`
+	Category        string `json:"category"`
+	IssuingInstance string `json:"issuing_instance"`
+	BannedAt        string `json:"banned_at"`
+	Flags           int    `json:"flags"` // Peers that have the player flagged
+}
+
+// ReportPayload is the data of report.created and report.actioned
+type ReportPayload struct {
+	ReportID     uint   `json:"report_id"`
+	Status       string `json:"status,omitempty"`
+	Action       string `json:"action,omitempty"`
+	ActionTaken  string `json:"action_taken,omitempty"`
+	ActionedBy   string `json:"actioned_by,omitempty"`
+	ActionedByID *uint  `json:"actioned_by_id,omitempty"`
+	Reason       string `json:"reason"`
+	ReportedName string `json:"reported_name"`
+	ReportedGUID string `json:"reported_guid"`
+	ReporterName string `json:"reporter_name"`
+	ReporterGUID string `json:"reporter_guid"`
+	Source       string `json:"source"`
+	CreatedAt    string `json:"created_at,omitempty"`
+}
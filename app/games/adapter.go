@@ -0,0 +1,204 @@
+package games
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ethanburkett/goadmin/app/models"
+	"github.com/ethanburkett/goadmin/app/parser"
+	"github.com/ethanburkett/goadmin/app/rcon"
+)
+
+// DefaultGame is used for servers that don't specify a game
+const DefaultGame = "cod4"
+
+// Adapter bundles everything that differs between supported games.
+// The embedded Dialect is handed to rcon.Client so its typed commands and
+// status parsing match the server.
+type Adapter interface {
+	rcon.Dialect
+
+	// Name is the identifier stored on models.Server (e.g. "cod4")
+	Name() string
+
+	// DisplayName is shown in the panel
+	DisplayName() string
+
+	// ParseLogLine parses one line of the server's games_mp.log
+	ParseLogLine(line string) (*parser.LogEntry, bool)
+
+	// StripColorCodes removes in-game color codes from a name or message
+	StripColorCodes(input string) string
+
+	// ValidGUID reports whether a string looks like a GUID for this game
+	ValidGUID(guid string) bool
+
+	// FindPlayer looks up a player in a status reply by GUID, slot or name
+	FindPlayer(status *rcon.StatusResponse, query string) (*rcon.StatusPlayer, error)
+
+	// Maps lists the stock maps shipped with the game
+	Maps() []string
+
+	// Gametypes lists the stock gametypes, by their g_gametype name
+	Gametypes() []string
+}
+
+// AdapterInfo describes an adapter for API responses
+type AdapterInfo struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Adapter)
+)
+
+// Register makes an adapter available by name
+func Register(adapter Adapter) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[strings.ToLower(adapter.Name())] = adapter
+}
+
+// Get returns the adapter with the given name
+func Get(name string) (Adapter, error) {
+	if name == "" {
+		name = DefaultGame
+	}
+
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	adapter, ok := registry[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported game: %s", name)
+	}
+	return adapter, nil
+}
+
+// ForServer returns the adapter for a server, falling back to the default
+// game if the server's game is unknown
+func ForServer(server *models.Server) Adapter {
+	if server != nil {
+		if adapter, err := Get(server.Game); err == nil {
+			return adapter
+		}
+	}
+
+	adapter, _ := Get(DefaultGame)
+	return adapter
+}
+
+// List returns all registered adapters sorted by name
+func List() []AdapterInfo {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	infos := make([]AdapterInfo, 0, len(registry))
+	for _, adapter := range registry {
+		infos = append(infos, AdapterInfo{
+			Name:        adapter.Name(),
+			DisplayName: adapter.DisplayName(),
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// IsKnownMap reports whether a map is one of the adapter's stock maps
+func IsKnownMap(adapter Adapter, mapName string) bool {
+	for _, m := range adapter.Maps() {
+		if strings.EqualFold(m, mapName) {
+			return true
+		}
+	}
+	return false
+}
+
+// KnownMaps lists the maps a server can load: the game's stock maps
+// followed by the server's custom maps
+func KnownMaps(adapter Adapter, serverID uint) ([]string, error) {
+	custom, err := models.GetCustomMaps(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	maps := append([]string{}, adapter.Maps()...)
+	for _, m := range custom {
+		maps = append(maps, m.Name)
+	}
+	return maps, nil
+}
+
+// IsKnownGametype reports whether a gametype is one of the adapter's stock
+// gametypes
+func IsKnownGametype(adapter Adapter, gametype string) bool {
+	for _, g := range adapter.Gametypes() {
+		if strings.EqualFold(g, gametype) {
+			return true
+		}
+	}
+	return false
+}
+
+// findPlayer is the shared lookup used by all adapters. Exact GUID, slot and
+// name matches win over partial name matches.
+func findPlayer(status *rcon.StatusResponse, query string, strip func(string) string) (*rcon.StatusPlayer, error) {
+	if status == nil {
+		return nil, rcon.ErrPlayerNotFound
+	}
+
+	search := strings.ToLower(strings.TrimSpace(query))
+	if search == "" {
+		return nil, rcon.ErrPlayerNotFound
+	}
+
+	for i := range status.Players {
+		p := &status.Players[i]
+		if strings.ToLower(p.Uuid) == search || strings.ToLower(p.SteamID) == search ||
+			strconv.Itoa(p.ID) == search || strings.ToLower(strip(p.Name)) == search {
+			return p, nil
+		}
+	}
+
+	for i := range status.Players {
+		p := &status.Players[i]
+		if strings.Contains(strings.ToLower(strip(p.Name)), search) {
+			return p, nil
+		}
+	}
+
+	return nil, rcon.ErrPlayerNotFound
+}
+
+// isDigits reports whether s is a non-empty string of ASCII digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isHex reports whether s is a non-empty string of hex digits
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !((r >= '0' && r <= '9') || (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F')) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package games
+
+import (
+	"testing"
+
+	"github.com/ethanburkett/goadmin/app/parser"
+)
+
+// Unrecognised lines used to reach entry.Event on a nil entry and panic
+func TestParseLogLineIgnoresJunk(t *testing.T) {
+	lines := []string{
+		"",
+		"garbage",
+		"  0:00 ------------------------------------------------------------",
+		"12:34 NotAnEvent;with;fields",
+	}
+
+	for _, adapter := range []Adapter{CoD4{}, CoD2{}, WaW{}} {
+		for _, line := range lines {
+			entry, ok := adapter.ParseLogLine(line)
+			if ok || entry != nil {
+				t.Errorf("%s: ParseLogLine(%q) = %v, %v; want nil, false", adapter.Name(), line, entry, ok)
+			}
+		}
+	}
+}
+
+func TestParseLogLineStripsChatPrefix(t *testing.T) {
+	line := "12:34 say;1234567;3;Player;\x15hello"
+
+	for _, adapter := range []Adapter{CoD2{}, WaW{}} {
+		name := adapter.Name()
+		entry, ok := adapter.ParseLogLine(line)
+		if !ok {
+			t.Fatalf("%s: chat line not parsed", name)
+		}
+		chat, isChat := entry.Event.(*parser.ChatEvent)
+		if !isChat {
+			t.Fatalf("%s: event is %T, want *parser.ChatEvent", name, entry.Event)
+		}
+		if entry.Message != "hello" || chat.Message != "hello" {
+			t.Errorf("%s: message = %q / %q, want %q", name, entry.Message, chat.Message, "hello")
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package games
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/parser"
+	"github.com/ethanburkett/goadmin/app/rcon"
+	"github.com/ethanburkett/goadmin/app/rcon/commands"
+)
+
+// CoD2 supports Call of Duty 2 1.3 servers. CoD2 ignores kick reasons and
+// has no timed ban, so temp bans are enforced by the panel only.
+type CoD2 struct{}
+
+func init() {
+	Register(CoD2{})
+}
+
+func (CoD2) Name() string {
+	return "cod2"
+}
+
+func (CoD2) DisplayName() string {
+	return "Call of Duty 2"
+}
+
+func (CoD2) ParseStatus(response string) (*rcon.StatusResponse, error) {
+	return rcon.ParseStatus(response)
+}
+
+func (CoD2) KickCommand(target, reason string) string {
+	return "clientkick " + commands.Quote(target)
+}
+
+func (CoD2) BanCommand(target, reason string) string {
+	return "banClient " + commands.Quote(target)
+}
+
+func (CoD2) TempBanCommand(target string, duration time.Duration, reason string) (string, error) {
+	return "", fmt.Errorf("cod2 tempban: %w", rcon.ErrUnsupported)
+}
+
+func (CoD2) UnbanCommand(target string) string {
+	return "unbanUser " + commands.Quote(target)
+}
+
+// ParseLogLine handles the CoD2 log format, which matches CoD4 except that
+// chat messages are prefixed with a \x15 control character
+func (CoD2) ParseLogLine(line string) (*parser.LogEntry, bool) {
+	entry, ok := parser.ParseGamesMpLine(line)
+	if !ok || entry == nil {
+		return nil, false
+	}
+	if chat, isChat := entry.Event.(*parser.ChatEvent); isChat {
+		entry.Message = strings.TrimPrefix(entry.Message, "\x15")
+		chat.Message = entry.Message
+	}
+	return entry, ok
+}
+
+func (CoD2) StripColorCodes(input string) string {
+	return parser.StripColorCodes(input)
+}
+
+// ValidGUID accepts CoD2 numeric GUIDs. Cracked clients report 0.
+func (CoD2) ValidGUID(guid string) bool {
+	return isDigits(guid) && guid != "0"
+}
+
+func (a CoD2) FindPlayer(status *rcon.StatusResponse, query string) (*rcon.StatusPlayer, error) {
+	return findPlayer(status, query, a.StripColorCodes)
+}
+
+func (CoD2) Maps() []string {
+	return []string{
+		"mp_breakout", "mp_brecourt", "mp_burgundy", "mp_carentan",
+		"mp_dawnville", "mp_decoy", "mp_downtown", "mp_farmhouse",
+		"mp_leningrad", "mp_matmata", "mp_railyard", "mp_toujane",
+		"mp_trainstation", "mp_harbor", "mp_rhine",
+	}
+}
+
+func (CoD2) Gametypes() []string {
+	return []string{"dm", "tdm", "sd", "ctf", "hq"}
+}
@@ -0,0 +1,54 @@
+package games
+
+import (
+	"github.com/ethanburkett/goadmin/app/parser"
+	"github.com/ethanburkett/goadmin/app/rcon"
+)
+
+// CoD4 supports Call of Duty 4 1.7/1.8 and CoD4x servers
+type CoD4 struct {
+	rcon.CoD4Dialect
+}
+
+func init() {
+	Register(CoD4{})
+}
+
+func (CoD4) Name() string {
+	return "cod4"
+}
+
+func (CoD4) DisplayName() string {
+	return "Call of Duty 4 / CoD4x"
+}
+
+func (CoD4) ParseLogLine(line string) (*parser.LogEntry, bool) {
+	return parser.ParseGamesMpLine(line)
+}
+
+func (CoD4) StripColorCodes(input string) string {
+	return parser.StripColorCodes(input)
+}
+
+// ValidGUID accepts 32 character PunkBuster GUIDs and CoD4x numeric player IDs
+func (CoD4) ValidGUID(guid string) bool {
+	return (len(guid) == 32 && isHex(guid)) || (len(guid) >= 8 && isDigits(guid))
+}
+
+func (a CoD4) FindPlayer(status *rcon.StatusResponse, query string) (*rcon.StatusPlayer, error) {
+	return findPlayer(status, query, a.StripColorCodes)
+}
+
+func (CoD4) Maps() []string {
+	return []string{
+		"mp_backlot", "mp_bloc", "mp_bog", "mp_broadcast", "mp_carentan",
+		"mp_cargoship", "mp_citystreets", "mp_convoy", "mp_countdown",
+		"mp_crash", "mp_crash_snow", "mp_creek", "mp_crossfire", "mp_farm",
+		"mp_killhouse", "mp_overgrown", "mp_pipeline", "mp_shipment",
+		"mp_showdown", "mp_strike", "mp_vacant",
+	}
+}
+
+func (CoD4) Gametypes() []string {
+	return []string{"dm", "war", "sd", "sab", "dom", "koth"}
+}
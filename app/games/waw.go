@@ -0,0 +1,91 @@
+package games
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/parser"
+	"github.com/ethanburkett/goadmin/app/rcon"
+	"github.com/ethanburkett/goadmin/app/rcon/commands"
+)
+
+// WaW supports Call of Duty: World at War servers. WaW's tempBanClient has a
+// fixed server-side length, so the requested duration is enforced by the panel.
+type WaW struct{}
+
+// WaW also uses ^; and ^: for embedded material codes
+var wawColorRegex = regexp.MustCompile(`\^[0-9;:]`)
+
+func init() {
+	Register(WaW{})
+}
+
+func (WaW) Name() string {
+	return "codwaw"
+}
+
+func (WaW) DisplayName() string {
+	return "Call of Duty: World at War"
+}
+
+func (WaW) ParseStatus(response string) (*rcon.StatusResponse, error) {
+	return rcon.ParseStatus(response)
+}
+
+func (WaW) KickCommand(target, reason string) string {
+	return "clientkick " + commands.Quote(target)
+}
+
+func (WaW) BanCommand(target, reason string) string {
+	return "banClient " + commands.Quote(target)
+}
+
+func (WaW) TempBanCommand(target string, duration time.Duration, reason string) (string, error) {
+	return "tempBanClient " + commands.Quote(target), nil
+}
+
+func (WaW) UnbanCommand(target string) string {
+	return "unbanUser " + commands.Quote(target)
+}
+
+// ParseLogLine handles the WaW log format. Chat messages carry the same
+// \x15 prefix as CoD2.
+func (WaW) ParseLogLine(line string) (*parser.LogEntry, bool) {
+	entry, ok := parser.ParseGamesMpLine(line)
+	if !ok || entry == nil {
+		return nil, false
+	}
+	if chat, isChat := entry.Event.(*parser.ChatEvent); isChat {
+		entry.Message = strings.TrimPrefix(entry.Message, "\x15")
+		chat.Message = entry.Message
+	}
+	return entry, ok
+}
+
+func (WaW) StripColorCodes(input string) string {
+	return wawColorRegex.ReplaceAllString(input, "")
+}
+
+// ValidGUID accepts WaW numeric GUIDs
+func (WaW) ValidGUID(guid string) bool {
+	return isDigits(guid) && guid != "0"
+}
+
+func (a WaW) FindPlayer(status *rcon.StatusResponse, query string) (*rcon.StatusPlayer, error) {
+	return findPlayer(status, query, a.StripColorCodes)
+}
+
+func (WaW) Maps() []string {
+	return []string{
+		"mp_airfield", "mp_asylum", "mp_castle", "mp_courtyard", "mp_dome",
+		"mp_downfall", "mp_hangar", "mp_makin", "mp_makin_day", "mp_outskirts",
+		"mp_roundhouse", "mp_seelow", "mp_shrine", "mp_suburban", "mp_subway",
+		"mp_kneedeep", "mp_nachtfeuer", "mp_stalingrad", "mp_docks", "mp_kwai",
+		"mp_bgate", "mp_vodka",
+	}
+}
+
+func (WaW) Gametypes() []string {
+	return []string{"dm", "tdm", "sd", "sab", "dom", "koth", "ctf", "twar"}
+}
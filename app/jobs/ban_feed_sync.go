@@ -0,0 +1,61 @@
+package jobs
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/banfeed"
+	"github.com/ethanburkett/goadmin/app/logger"
+	"go.uber.org/zap"
+)
+
+// BanFeedSyncer pulls subscribed peers' ban feeds as their intervals come due
+type BanFeedSyncer struct {
+	instance string
+	client   *http.Client
+	stopChan chan bool
+}
+
+// NewBanFeedSyncer creates a syncer that identifies itself to peers as instance
+func NewBanFeedSyncer(instance string) *BanFeedSyncer {
+	return &BanFeedSyncer{
+		instance: instance,
+		client:   &http.Client{Timeout: 15 * time.Second},
+		stopChan: make(chan bool),
+	}
+}
+
+// Start begins syncing (checks peers every minute)
+func (s *BanFeedSyncer) Start() {
+	logger.Info("Starting ban feed syncer", zap.String("instance", s.instance))
+
+	go s.schedule()
+}
+
+// Stop halts syncing
+func (s *BanFeedSyncer) Stop() {
+	logger.Info("Stopping ban feed syncer")
+	close(s.stopChan)
+}
+
+// schedule checks for due peers every minute; each peer has its own interval
+func (s *BanFeedSyncer) schedule() {
+	// Pull right away, off the startup path since peers may be slow to answer
+	s.sync()
+
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.sync()
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+func (s *BanFeedSyncer) sync() {
+	banfeed.SyncDue(s.client, s.instance)
+}
@@ -0,0 +1,78 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/logger"
+	"github.com/ethanburkett/goadmin/app/models"
+	"go.uber.org/zap"
+)
+
+// ChatLogArchiver deletes chat older than the retention period
+type ChatLogArchiver struct {
+	retentionDays int
+	stopChan      chan bool
+}
+
+// NewChatLogArchiver creates a new chat log archiver
+func NewChatLogArchiver(retentionDays int) *ChatLogArchiver {
+	if retentionDays <= 0 {
+		retentionDays = 30 // Default to 30 days
+	}
+
+	return &ChatLogArchiver{
+		retentionDays: retentionDays,
+		stopChan:      make(chan bool),
+	}
+}
+
+// Start begins the cleanup process (runs daily at 3 AM)
+func (a *ChatLogArchiver) Start() {
+	logger.Info("Starting chat log archiver",
+		zap.Int("retention_days", a.retentionDays))
+
+	// Run immediately on startup
+	a.purge()
+
+	// Then run daily at 3 AM, after the audit log archiver
+	go a.schedule()
+}
+
+// Stop halts the cleanup process
+func (a *ChatLogArchiver) Stop() {
+	logger.Info("Stopping chat log archiver")
+	close(a.stopChan)
+}
+
+// schedule runs the cleanup daily at 3 AM
+func (a *ChatLogArchiver) schedule() {
+	ticker := time.NewTicker(1 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if time.Now().Hour() == 3 {
+				a.purge()
+			}
+		case <-a.stopChan:
+			return
+		}
+	}
+}
+
+// purge deletes chat past the retention period
+func (a *ChatLogArchiver) purge() {
+	cutoff := time.Now().AddDate(0, 0, -a.retentionDays)
+	deleted, err := models.DeleteChatMessagesBefore(cutoff)
+	if err != nil {
+		logger.Error("Failed to purge old chat messages", zap.Error(err))
+		return
+	}
+
+	if deleted > 0 {
+		logger.Info("Purged old chat messages",
+			zap.Int64("count", deleted),
+			zap.Int("retention_days", a.retentionDays))
+	}
+}
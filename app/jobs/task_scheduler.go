@@ -0,0 +1,223 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/database"
+	"github.com/ethanburkett/goadmin/app/logger"
+	"github.com/ethanburkett/goadmin/app/models"
+	"github.com/ethanburkett/goadmin/app/supervisor"
+	"go.uber.org/zap"
+)
+
+// misfireGrace is how late a scheduled run may start. Runs missed by more,
+// e.g. while GoAdmin was down, are skipped rather than run late.
+const misfireGrace = 5 * time.Minute
+
+// taskRunner is the name scheduled tasks act as, e.g. for {player} in
+// custom command templates
+const taskRunner = "Scheduler"
+
+// TaskScheduler runs scheduled tasks when their cron expressions come due
+type TaskScheduler struct {
+	servers  *supervisor.Supervisor
+	stopChan chan bool
+}
+
+// NewTaskScheduler creates a scheduler running tasks on the given servers
+func NewTaskScheduler(servers *supervisor.Supervisor) *TaskScheduler {
+	return &TaskScheduler{
+		servers:  servers,
+		stopChan: make(chan bool),
+	}
+}
+
+// Start begins checking for due tasks every 15 seconds
+func (s *TaskScheduler) Start() {
+	logger.Info("Starting task scheduler")
+	go s.schedule()
+}
+
+// Stop halts the scheduler. A task already running finishes.
+func (s *TaskScheduler) Stop() {
+	logger.Info("Stopping task scheduler")
+	close(s.stopChan)
+}
+
+func (s *TaskScheduler) schedule() {
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+
+	s.runDue(time.Now())
+	for {
+		select {
+		case <-ticker.C:
+			s.runDue(time.Now())
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// runDue runs the tasks that have come due and schedules their next runs
+func (s *TaskScheduler) runDue(now time.Time) {
+	unscheduled, err := models.GetUnscheduledTasks()
+	if err != nil {
+		logger.Error("Failed to load scheduled tasks", zap.Error(err))
+		return
+	}
+	for i := range unscheduled {
+		s.reschedule(&unscheduled[i], now)
+	}
+
+	due, err := models.GetDueScheduledTasks(now)
+	if err != nil {
+		logger.Error("Failed to load due scheduled tasks", zap.Error(err))
+		return
+	}
+
+	for i := range due {
+		task := &due[i]
+		if late := now.Sub(*task.NextRunAt); late > misfireGrace {
+			logger.Warn("Skipping missed scheduled task run",
+				zap.Uint("task_id", task.ID),
+				zap.String("task", task.Name),
+				zap.Time("due", *task.NextRunAt))
+		} else {
+			runs, err := RunTask(s.servers, task, false)
+			if err != nil {
+				logger.Error("Failed to record scheduled task run", zap.Uint("task_id", task.ID), zap.Error(err))
+			}
+			auditTaskRun(task, runs)
+		}
+		s.reschedule(task, now)
+	}
+}
+
+// reschedule sets a task's next run after now. Tasks whose expression
+// no longer parses or never matches are left unscheduled.
+func (s *TaskScheduler) reschedule(task *models.ScheduledTask, now time.Time) {
+	next, err := NextRun(task.Cron, now)
+	if err != nil {
+		logger.Error("Invalid cron expression on scheduled task",
+			zap.Uint("task_id", task.ID),
+			zap.String("cron", task.Cron),
+			zap.Error(err))
+	}
+	if err := models.SetTaskNextRun(task.ID, next); err != nil {
+		logger.Error("Failed to schedule task", zap.Uint("task_id", task.ID), zap.Error(err))
+	}
+}
+
+// NextRun returns when a cron expression next matches after t, or nil if it
+// never does
+func NextRun(expr string, t time.Time) (*time.Time, error) {
+	schedule, err := ParseCron(expr)
+	if err != nil {
+		return nil, err
+	}
+	next := schedule.Next(t)
+	if next.IsZero() {
+		return nil, nil
+	}
+	return &next, nil
+}
+
+// RunTask runs a task now on its server, or on every running server when it
+// has none, and records the results
+func RunTask(servers *supervisor.Supervisor, task *models.ScheduledTask, manual bool) ([]models.ScheduledTaskRun, error) {
+	var runs []models.ScheduledTaskRun
+
+	if task.ServerID != nil {
+		if inst, ok := servers.Get(*task.ServerID); ok {
+			runs = append(runs, runTaskOn(inst, task, manual))
+		} else {
+			runs = append(runs, models.ScheduledTaskRun{
+				ServerID: task.ServerID,
+				Manual:   manual,
+				Error:    fmt.Sprintf("server %d is not running", *task.ServerID),
+			})
+		}
+	} else {
+		for _, inst := range servers.Instances() {
+			runs = append(runs, runTaskOn(inst, task, manual))
+		}
+	}
+
+	err := models.RecordTaskRuns(task, runs, time.Now())
+	return runs, err
+}
+
+// runTaskOn performs a task's action on one server
+func runTaskOn(inst *supervisor.Instance, task *models.ScheduledTask, manual bool) models.ScheduledTaskRun {
+	run := models.ScheduledTaskRun{ServerID: inst.ServerID(), Manual: manual}
+	payload := strings.TrimSpace(task.Payload)
+
+	var err error
+	switch task.Action {
+	case models.TaskActionRcon:
+		run.Command = payload
+		run.Output, err = inst.RCON.SendCommand(payload)
+
+	case models.TaskActionSay:
+		result, sayErr := inst.RCON.Say(payload)
+		run.Command, run.Output, err = result.Command, result.Response, sayErr
+
+	case models.TaskActionCommand:
+		fields := strings.Fields(payload)
+		run.Command, err = inst.Handler.BuildCustomCommand(fields[0], fields[1:], taskRunner)
+		if err == nil {
+			run.Output, err = inst.RCON.SendCommand(run.Command)
+		}
+
+	case models.TaskActionMap:
+		result, mapErr := inst.RCON.Map(payload)
+		run.Command, run.Output, err = result.Command, result.Response, mapErr
+
+	default:
+		err = fmt.Errorf("unknown action: %s", task.Action)
+	}
+
+	run.Success = err == nil
+	if err != nil {
+		run.Error = fmt.Sprintf("%s: %v", inst.Server.Name, err)
+		logger.Warn("Scheduled task failed",
+			zap.Uint("task_id", task.ID),
+			zap.String("server", inst.Server.Name),
+			zap.Error(err))
+	}
+	return run
+}
+
+// auditTaskRun records a scheduled run in the audit log
+func auditTaskRun(task *models.ScheduledTask, runs []models.ScheduledTaskRun) {
+	success := task.LastStatus == models.TaskStatusSuccess
+	var errMsg string
+	if !success {
+		errMsg = task.LastResult
+	}
+	metadataJSON, _ := json.Marshal(map[string]interface{}{
+		"action":  task.Action,
+		"payload": task.Payload,
+		"servers": len(runs),
+		"status":  task.LastStatus,
+	})
+	models.CreateAuditLog(
+		database.DB,
+		nil,
+		"SYSTEM",
+		"",
+		models.ActionTaskRun,
+		models.SourceSystem,
+		success,
+		errMsg,
+		"scheduled_task",
+		fmt.Sprintf("%d", task.ID),
+		task.Name,
+		string(metadataJSON),
+		fmt.Sprintf("Ran scheduled task '%s': %s", task.Name, task.LastResult),
+	)
+}
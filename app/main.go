@@ -7,20 +7,21 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"strings"
 	"syscall"
 	"time"
 
-	"github.com/ethanburkett/goadmin/app/commands"
+	"github.com/ethanburkett/goadmin/app/automation"
 	"github.com/ethanburkett/goadmin/app/config"
 	"github.com/ethanburkett/goadmin/app/database"
+	"github.com/ethanburkett/goadmin/app/events"
 	"github.com/ethanburkett/goadmin/app/jobs"
 	"github.com/ethanburkett/goadmin/app/logger"
+	"github.com/ethanburkett/goadmin/app/maprotation"
 	"github.com/ethanburkett/goadmin/app/models"
-	"github.com/ethanburkett/goadmin/app/parser"
 	"github.com/ethanburkett/goadmin/app/plugins"
-	"github.com/ethanburkett/goadmin/app/rcon"
 	"github.com/ethanburkett/goadmin/app/rest"
+	"github.com/ethanburkett/goadmin/app/stats"
+	"github.com/ethanburkett/goadmin/app/supervisor"
 	"github.com/ethanburkett/goadmin/app/watcher"
 	"github.com/ethanburkett/goadmin/app/webhook"
 
@@ -79,24 +80,12 @@ func main() {
 	initializeDefaultCommands()
 	initializeDefaultServer(cfg)
 
-	rconClient := rcon.NewClient(cfg)
-	err = rconClient.Connect()
-	if err != nil {
-		panic(err)
-	}
-	defer rconClient.Close()
-
-	_, err = rconClient.Status()
-	if err != nil {
-		logger.Error("Error getting RCON status", zap.Error(err))
-		return
-	} else {
-		logger.Info("RCON connection established successfully.")
-	}
+	// One RCON client, log watcher and stats collector per active server
+	supervisor.Global = supervisor.New(cfg)
 
 	restServer := &http.Server{
 		Addr:    fmt.Sprintf(":%d", cfg.RestPort),
-		Handler: rest.New(cfg, rconClient, getMigrations()).Engine(),
+		Handler: rest.New(cfg, supervisor.Global, getMigrations()).Engine(),
 	}
 
 	go func() {
@@ -106,8 +95,9 @@ func main() {
 		}
 	}()
 
-	// Initialize RCON API for plugins BEFORE starting watcher
-	rconAPI := plugins.NewRCONAPI(rconClient)
+	// Initialize RCON API for plugins BEFORE starting servers so their
+	// command handlers are linked to the plugin command API
+	rconAPI := plugins.NewRCONAPIWithResolver(supervisor.Global.Client)
 	plugins.GlobalPluginManager.SetRCONClient(rconAPI)
 
 	// Load and start plugins
@@ -119,12 +109,26 @@ func main() {
 		logger.Error("Failed to start plugins", zap.Error(err))
 	}
 
-	go startGamesMpWatcher(cfg, rconClient)
+	// Fan the event stream out to plugins, webhooks and the audit stream
+	events.Subscribe("plugins", plugins.PublishEvent)
+	events.Subscribe("webhooks", webhook.GlobalDispatcher.HandleEvent)
+	events.Subscribe("audit_stream", rest.GlobalAuditStreamManager.BroadcastEvent)
+	events.Subscribe("stats", stats.NewTracker().HandleEvent)
+
+	// Automation rules fire on events and on stats snapshots
+	automation.Global = automation.NewEngine(supervisor.Global)
+	automation.Global.Start()
+	defer automation.Global.Stop()
+	events.Subscribe("automation", automation.Global.HandleEvent)
+	watcher.OnSnapshot(automation.Global.HandleSnapshot)
 
-	// Start stats collector
-	statsCollector := watcher.NewStatsCollector(rconClient)
-	statsCollector.Start()
-	defer statsCollector.Stop()
+	// Switch map rotations as player counts change
+	watcher.OnSnapshot(maprotation.NewSwitcher(supervisor.Global).HandleSnapshot)
+
+	if err := supervisor.Global.StartAll(); err != nil {
+		logger.Error("Failed to start servers", zap.Error(err))
+	}
+	defer supervisor.Global.StopAll()
 
 	// Start webhook retry worker
 	go webhook.GlobalDispatcher.StartRetryWorker()
@@ -135,6 +139,22 @@ func main() {
 	auditArchiver.Start()
 	defer auditArchiver.Stop()
 
+	// Start chat log cleanup (default 30 day retention)
+	chatArchiver := jobs.NewChatLogArchiver(30)
+	chatArchiver.Start()
+	defer chatArchiver.Stop()
+
+	if cfg.BanFeed.Enabled {
+		banFeedSyncer := jobs.NewBanFeedSyncer(cfg.BanFeed.InstanceName)
+		banFeedSyncer.Start()
+		defer banFeedSyncer.Stop()
+	}
+
+	// Start scheduled tasks
+	taskScheduler := jobs.NewTaskScheduler(supervisor.Global)
+	taskScheduler.Start()
+	defer taskScheduler.Stop()
+
 	go startTempBanChecker()
 
 	sigChan := make(chan os.Signal, 1)
@@ -195,6 +215,17 @@ func initializeSuperAdminRole() {
 		{"servers.manage", "Manage server instances"},
 		{"plugins.view", "View plugin list and status"},
 		{"plugins.manage", "Manage plugins (start, stop, reload)"},
+		{"bans.view", "View the ban list and appeals"},
+		{"bans.manage", "Create, edit and revoke bans and review appeals"},
+		{"warnings.manage", "Clear warnings and edit escalation rules and presets"},
+		{"chat.view", "Search and export the chat log"},
+		{"chatfilter.manage", "Edit chat filter rules and lift mutes"},
+		{"tasks.view", "View scheduled tasks and their run history"},
+		{"tasks.manage", "Create, edit and run scheduled tasks"},
+		{"automation.view", "View automation rules and their history"},
+		{"automation.manage", "Create and edit automation rules"},
+		{"rotations.view", "View map rotations and the known map list"},
+		{"rotations.manage", "Edit and push map rotations and custom maps"},
 	}
 
 	for _, perm := range permissions {
@@ -314,13 +345,13 @@ func initializeDefaultCommands() {
 		{
 			name:        "ban",
 			usage:       "!ban <player> [reason]",
-			description: "Ban a player from the server",
-			rconCommand: "banClient {playerId:arg0} {argsFrom:1}",
+			description: "Permanently ban a player on every server (built-in Go function)",
+			rconCommand: "",
 			minArgs:     1,
 			maxArgs:     -1,
 			minPower:    50,
 			permissions: []string{"ban"},
-			isBuiltIn:   false,
+			isBuiltIn:   true,
 		},
 		{
 			name:        "say",
@@ -432,6 +463,105 @@ func initializeDefaultCommands() {
 			permissions: []string{"tempban"},
 			isBuiltIn:   true,
 		},
+		{
+			name:        "stats",
+			usage:       "!stats [player]",
+			description: "Show kill stats for yourself or another player (built-in Go function)",
+			rconCommand: "",
+			minArgs:     0,
+			maxArgs:     -1,
+			minPower:    0,
+			permissions: []string{},
+			isBuiltIn:   true,
+		},
+		{
+			name:        "top",
+			usage:       "!top [kills|kd|headshots|streak|playtime]",
+			description: "Show the top players on this server (built-in Go function)",
+			rconCommand: "",
+			minArgs:     0,
+			maxArgs:     1,
+			minPower:    0,
+			permissions: []string{},
+			isBuiltIn:   true,
+		},
+		{
+			name:        "aliases",
+			usage:       "!aliases <player>",
+			description: "Show a player's other names and accounts sharing their IP or name (built-in Go function)",
+			rconCommand: "",
+			minArgs:     1,
+			maxArgs:     -1,
+			minPower:    50,
+			permissions: []string{},
+			isBuiltIn:   true,
+		},
+		{
+			name:        "unban",
+			usage:       "!unban <banId|guid|name>",
+			description: "Revoke a player's bans (built-in Go function)",
+			rconCommand: "",
+			minArgs:     1,
+			maxArgs:     -1,
+			minPower:    80,
+			permissions: []string{"ban"},
+			isBuiltIn:   true,
+		},
+		{
+			name:        "baninfo",
+			usage:       "!baninfo <banId|guid|name>",
+			description: "Show the details of a ban (built-in Go function)",
+			rconCommand: "",
+			minArgs:     1,
+			maxArgs:     -1,
+			minPower:    50,
+			permissions: []string{},
+			isBuiltIn:   true,
+		},
+		{
+			name:        "warn",
+			usage:       "!warn <player> <reason|preset>",
+			description: "Warn a player; repeated warnings escalate to a kick or ban (built-in Go function)",
+			rconCommand: "",
+			minArgs:     2,
+			maxArgs:     -1,
+			minPower:    50,
+			permissions: []string{},
+			isBuiltIn:   true,
+		},
+		{
+			name:        "votemap",
+			usage:       "!votemap <map|number>",
+			description: "Start a vote on the next map, or vote in the open one (built-in Go function)",
+			rconCommand: "",
+			minArgs:     1,
+			maxArgs:     -1,
+			minPower:    0,
+			permissions: []string{},
+			isBuiltIn:   true,
+		},
+		{
+			name:        "nextmap",
+			usage:       "!nextmap",
+			description: "Show the next map in the rotation (built-in Go function)",
+			rconCommand: "",
+			minArgs:     0,
+			maxArgs:     0,
+			minPower:    0,
+			permissions: []string{},
+			isBuiltIn:   true,
+		},
+		{
+			name:        "rtv",
+			usage:       "!rtv",
+			description: "Rock the vote: once enough players ask, vote on a map to change to now (built-in Go function)",
+			rconCommand: "",
+			minArgs:     0,
+			maxArgs:     0,
+			minPower:    0,
+			permissions: []string{},
+			isBuiltIn:   true,
+		},
 	}
 
 	for _, cmd := range defaultCommands {
@@ -489,6 +619,9 @@ func initializeDefaultServer(cfg *config.Config) {
 		cfg.Server.Host,
 		cfg.Server.RconPassword,
 		cfg.GamesMpPath,
+		cfg.Server.Game,
+		cfg.LogWatch.Source,
+		cfg.LogWatch.Address,
 		"Auto-created from config file",
 		"",
 		cfg.Server.Port,
@@ -505,74 +638,6 @@ func initializeDefaultServer(cfg *config.Config) {
 	logger.Info("Created default server", zap.String("name", server.Name), zap.Uint("id", server.ID))
 }
 
-func startGamesMpWatcher(cfg *config.Config, rconClient *rcon.Client) {
-	changesChan := watcher.WatchGamesMp(cfg)
-
-	cmdHandler := commands.NewCommandHandler(rconClient, database.DB)
-
-	// Link plugin command API to command handler
-	cmdHandler.SetPluginCommandAPI(plugins.GlobalPluginManager.GetCommandAPI())
-
-	for event := range changesChan {
-		entry, ok := parser.ParseGamesMpLine(event.NewLine)
-		if !ok {
-			continue
-		}
-
-		switch entry.CommandType {
-		case parser.SAY, parser.SAYTEAM:
-			cleanMsg := strings.Map(func(r rune) rune {
-				if r < 32 || r == 127 {
-					return -1
-				}
-				return r
-			}, entry.Message)
-			cleanMsg = strings.TrimSpace(cleanMsg)
-
-			if len(cleanMsg) > 0 && cleanMsg[0] == '!' {
-				models.CreateOrUpdateInGamePlayer(entry.PlayerGUID, entry.PlayerName)
-
-				if err := cmdHandler.ProcessChatCommand(entry.PlayerName, entry.PlayerGUID, cleanMsg); err != nil {
-					logger.Error("Failed to process command", zap.Error(err))
-				}
-			}
-
-		case parser.JOIN:
-			fmt.Printf("[JOIN] %s (GUID: %s, ID: %s) joined the server\n", entry.PlayerName, entry.PlayerGUID, entry.PlayerID)
-			models.CreateOrUpdateInGamePlayer(entry.PlayerGUID, entry.PlayerName)
-
-			// Publish player.connect event
-			plugins.GlobalEventBus.Publish("player.connect", map[string]interface{}{
-				"playerName": entry.PlayerName,
-				"playerGUID": entry.PlayerGUID,
-				"playerID":   entry.PlayerID,
-			})
-
-			if models.IsPlayerTempBanned(entry.PlayerGUID) {
-				ban, _ := models.GetTempBanByGUID(entry.PlayerGUID)
-				if ban != nil {
-					timeRemaining := time.Until(ban.ExpiresAt)
-					hours := int(timeRemaining.Hours())
-					minutes := int(timeRemaining.Minutes()) % 60
-					kickMsg := fmt.Sprintf("You are temporarily banned. %dh %dm remaining. Reason: %s", hours, minutes, ban.Reason)
-					rconClient.SendCommand(fmt.Sprintf("clientkick %s %s", entry.PlayerID, kickMsg))
-					logger.Info(fmt.Sprintf("Kicked temp-banned player %s (%s)", entry.PlayerName, entry.PlayerGUID))
-				}
-			}
-
-		case parser.LEAVE:
-			fmt.Printf("[LEAVE] %s (GUID: %s, ID: %s) left the server\n", entry.PlayerName, entry.PlayerGUID, entry.PlayerID)
-
-			// Publish player.disconnect event
-			plugins.GlobalEventBus.Publish("player.disconnect", map[string]interface{}{
-				"playerName": entry.PlayerName,
-				"playerGUID": entry.PlayerGUID,
-				"playerID":   entry.PlayerID,
-			})
-		}
-	}
-}
-
 func startTempBanChecker() {
 	ticker := time.NewTicker(1 * time.Minute)
 	defer ticker.Stop()
@@ -581,6 +646,12 @@ func startTempBanChecker() {
 		if err := models.ExpireTempBans(); err != nil {
 			logger.Error("Failed to expire temp bans", zap.Error(err))
 		}
+		if err := models.ExpireBans(); err != nil {
+			logger.Error("Failed to expire bans", zap.Error(err))
+		}
+
+		// Catch banned players whose join line was missed or who match by IP
+		supervisor.Global.SweepBans()
 	}
 }
 
@@ -755,5 +826,336 @@ func getMigrations() []database.MigrationDefinition {
 				return db.Migrator().DropTable(&models.Server{})
 			},
 		},
+		{
+			Version:     "009",
+			Name:        "add_server_game",
+			Description: "Add game adapter selection to servers",
+			Up: func(db *gorm.DB) error {
+				if err := db.AutoMigrate(&models.Server{}); err != nil {
+					return err
+				}
+
+				// Existing servers were all CoD4
+				return db.Model(&models.Server{}).
+					Where("game IS NULL OR game = ?", "").
+					Update("game", "cod4").Error
+			},
+			Down: func(db *gorm.DB) error {
+				return db.Migrator().DropColumn(&models.Server{}, "game")
+			},
+		},
+		{
+			Version:     "010",
+			Name:        "add_server_to_stats",
+			Description: "Add server_id to system and player stats for multi-server collection",
+			Up: func(db *gorm.DB) error {
+				return db.AutoMigrate(&models.SystemStats{}, &models.PlayerStats{})
+			},
+			Down: func(db *gorm.DB) error {
+				if err := db.Migrator().DropColumn(&models.SystemStats{}, "server_id"); err != nil {
+					return err
+				}
+				return db.Migrator().DropColumn(&models.PlayerStats{}, "server_id")
+			},
+		},
+		{
+			Version:     "011",
+			Name:        "add_server_log_source",
+			Description: "Add remote log source settings to servers",
+			Up: func(db *gorm.DB) error {
+				if err := db.AutoMigrate(&models.Server{}); err != nil {
+					return err
+				}
+				return db.Model(&models.Server{}).
+					Where("log_source IS NULL OR log_source = ?", "").
+					Update("log_source", "file").Error
+			},
+			Down: func(db *gorm.DB) error {
+				for _, column := range []string{"log_source", "log_address", "log_token"} {
+					if err := db.Migrator().DropColumn(&models.Server{}, column); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Version:     "012",
+			Name:        "add_player_stats",
+			Description: "Add kill log and daily player statistics tables",
+			Up: func(db *gorm.DB) error {
+				return db.AutoMigrate(&models.Kill{}, &models.PlayerDailyStat{})
+			},
+			Down: func(db *gorm.DB) error {
+				return db.Migrator().DropTable(&models.PlayerDailyStat{}, &models.Kill{})
+			},
+		},
+		{
+			Version:     "013",
+			Name:        "add_player_sessions",
+			Description: "Add player sessions and first/last seen times on in-game players",
+			Up: func(db *gorm.DB) error {
+				if err := db.AutoMigrate(&models.PlayerSession{}, &models.InGamePlayer{}); err != nil {
+					return err
+				}
+				// Best guess for players recorded before sessions existed
+				return db.Exec("UPDATE in_game_players SET first_seen = created_at, last_seen = updated_at WHERE first_seen IS NULL OR first_seen = ''").Error
+			},
+			Down: func(db *gorm.DB) error {
+				if err := db.Migrator().DropTable(&models.PlayerSession{}); err != nil {
+					return err
+				}
+				for _, column := range []string{"first_seen", "last_seen"} {
+					if err := db.Migrator().DropColumn(&models.InGamePlayer{}, column); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Version:     "014",
+			Name:        "add_player_aliases",
+			Description: "Add alias and IP history per GUID",
+			Up: func(db *gorm.DB) error {
+				if err := db.AutoMigrate(&models.PlayerAlias{}, &models.PlayerIP{}); err != nil {
+					return err
+				}
+
+				// Seed alias history with the one name we already know
+				var players []models.InGamePlayer
+				if err := db.Where("name <> ''").Find(&players).Error; err != nil {
+					return err
+				}
+				for _, p := range players {
+					alias := models.PlayerAlias{
+						GUID:           p.GUID,
+						Name:           p.Name,
+						NormalizedName: models.NormalizeAliasName(p.Name),
+						FirstSeen:      p.FirstSeen,
+						LastSeen:       p.LastSeen,
+						TimesSeen:      1,
+					}
+					if err := db.Where(models.PlayerAlias{GUID: p.GUID, Name: p.Name}).FirstOrCreate(&alias).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			Down: func(db *gorm.DB) error {
+				return db.Migrator().DropTable(&models.PlayerAlias{}, &models.PlayerIP{})
+			},
+		},
+		{
+			Version:     "015",
+			Name:        "add_bans",
+			Description: "Add the GoAdmin ban list and ban appeals, and make !ban use it",
+			Up: func(db *gorm.DB) error {
+				if err := db.AutoMigrate(&models.Ban{}, &models.BanAppeal{}); err != nil {
+					return err
+				}
+
+				// !ban used to send banClient; it is now a built-in backed by the ban list
+				return db.Model(&models.CustomCommand{}).
+					Where("name = ? AND rcon_command = ?", "ban", "banClient {playerId:arg0} {argsFrom:1}").
+					Updates(map[string]interface{}{
+						"rcon_command": "",
+						"is_built_in":  true,
+						"description":  "Permanently ban a player on every server (built-in Go function)",
+					}).Error
+			},
+			Down: func(db *gorm.DB) error {
+				if err := db.Model(&models.CustomCommand{}).
+					Where("name = ? AND is_built_in = ?", "ban", true).
+					Updates(map[string]interface{}{
+						"rcon_command": "banClient {playerId:arg0} {argsFrom:1}",
+						"is_built_in":  false,
+						"description":  "Ban a player from the server",
+					}).Error; err != nil {
+					return err
+				}
+				return db.Migrator().DropTable(&models.BanAppeal{}, &models.Ban{})
+			},
+		},
+		{
+			Version:     "016",
+			Name:        "add_ban_feed",
+			Description: "Add ban categories and ban sharing between GoAdmin instances",
+			Up: func(db *gorm.DB) error {
+				return db.AutoMigrate(&models.Ban{}, &models.BanFeedPeer{}, &models.BanFlag{})
+			},
+			Down: func(db *gorm.DB) error {
+				if err := db.Migrator().DropTable(&models.BanFlag{}, &models.BanFeedPeer{}); err != nil {
+					return err
+				}
+				for _, column := range []string{"feed_ban_id", "feed_peer_id", "category"} {
+					if err := db.Migrator().DropColumn(&models.Ban{}, column); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Version:     "017",
+			Name:        "add_warnings",
+			Description: "Add player warnings with escalation rules and reason presets",
+			Up: func(db *gorm.DB) error {
+				if err := db.AutoMigrate(&models.WarningPreset{}, &models.Warning{}, &models.WarningRule{}); err != nil {
+					return err
+				}
+
+				// Default ladder: a kick at 3 warnings in a day, an hour's tempban at 5
+				var rules int64
+				db.Model(&models.WarningRule{}).Count(&rules)
+				if rules == 0 {
+					defaults := []models.WarningRule{
+						{Threshold: 3, WindowHours: 24, Action: models.EscalationKick, Message: "Kicked after 3 warnings", Enabled: true},
+						{Threshold: 5, WindowHours: 24, Action: models.EscalationTempBan, DurationMinutes: 60, Message: "Banned for 1 hour after 5 warnings", Enabled: true},
+					}
+					if err := db.Create(&defaults).Error; err != nil {
+						return err
+					}
+				}
+
+				var presets int64
+				db.Model(&models.WarningPreset{}).Count(&presets)
+				if presets == 0 {
+					defaults := []models.WarningPreset{
+						{Key: "lang", Reason: "Offensive language", Message: "Watch your language"},
+						{Key: "spam", Reason: "Spamming chat", Message: "Stop spamming the chat"},
+						{Key: "tk", Reason: "Team killing", Message: "Do not kill your teammates"},
+						{Key: "camp", Reason: "Spawn camping", Message: "No spawn camping"},
+						{Key: "rules", Reason: "Breaking server rules", Message: "Follow the server rules"},
+					}
+					if err := db.Create(&defaults).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			Down: func(db *gorm.DB) error {
+				return db.Migrator().DropTable(&models.WarningRule{}, &models.Warning{}, &models.WarningPreset{})
+			},
+		},
+		{
+			Version:     "018",
+			Name:        "add_chat_messages",
+			Description: "Add the chat log with a full-text index",
+			Up: func(db *gorm.DB) error {
+				if err := db.AutoMigrate(&models.ChatMessage{}); err != nil {
+					return err
+				}
+
+				// External content FTS4 index; the triggers keep it in step with the table
+				statements := []string{
+					`CREATE VIRTUAL TABLE IF NOT EXISTS chat_messages_fts USING fts4(content="chat_messages", message)`,
+					`CREATE TRIGGER IF NOT EXISTS chat_messages_fts_ai AFTER INSERT ON chat_messages BEGIN
+						INSERT INTO chat_messages_fts(docid, message) VALUES (new.id, new.message);
+					END`,
+					`CREATE TRIGGER IF NOT EXISTS chat_messages_fts_bd BEFORE DELETE ON chat_messages BEGIN
+						DELETE FROM chat_messages_fts WHERE docid = old.id;
+					END`,
+					`CREATE TRIGGER IF NOT EXISTS chat_messages_fts_bu BEFORE UPDATE ON chat_messages BEGIN
+						DELETE FROM chat_messages_fts WHERE docid = old.id;
+					END`,
+					`CREATE TRIGGER IF NOT EXISTS chat_messages_fts_au AFTER UPDATE ON chat_messages BEGIN
+						INSERT INTO chat_messages_fts(docid, message) VALUES (new.id, new.message);
+					END`,
+				}
+				for _, stmt := range statements {
+					if err := db.Exec(stmt).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			Down: func(db *gorm.DB) error {
+				for _, trigger := range []string{"chat_messages_fts_ai", "chat_messages_fts_bd", "chat_messages_fts_bu", "chat_messages_fts_au"} {
+					if err := db.Exec("DROP TRIGGER IF EXISTS " + trigger).Error; err != nil {
+						return err
+					}
+				}
+				if err := db.Exec("DROP TABLE IF EXISTS chat_messages_fts").Error; err != nil {
+					return err
+				}
+				return db.Migrator().DropTable(&models.ChatMessage{})
+			},
+		},
+		{
+			Version:     "019",
+			Name:        "add_chat_filter",
+			Description: "Add chat filter rules with default spam and advertising rules",
+			Up: func(db *gorm.DB) error {
+				if err := db.AutoMigrate(&models.ChatFilterRule{}); err != nil {
+					return err
+				}
+
+				// Admins (power 50 and up) are exempt. The rules start disabled
+				// so upgraded servers don't act on players until an admin opts in.
+				var rules int64
+				db.Model(&models.ChatFilterRule{}).Count(&rules)
+				if rules == 0 {
+					defaults := []models.ChatFilterRule{
+						{Name: "Advertising", Type: models.ChatFilterAdvertising, Action: models.ChatFilterTell, Message: "No advertising", ExemptPower: 50, Enabled: false},
+						{Name: "Chat flood", Type: models.ChatFilterFlood, Threshold: 6, WindowSeconds: 10, Action: models.ChatFilterMute, DurationMinutes: 5, Message: "Flooding the chat", ExemptPower: 50, Enabled: false},
+						{Name: "Repeated messages", Type: models.ChatFilterRepeat, Threshold: 3, WindowSeconds: 30, Action: models.ChatFilterTell, Message: "Stop repeating yourself", ExemptPower: 50, Enabled: false},
+						{Name: "Caps lock", Type: models.ChatFilterCaps, Threshold: 80, MinLength: 12, Action: models.ChatFilterTell, Message: "Turn off caps lock", ExemptPower: 50, Enabled: false},
+						{Name: "Profanity", Type: models.ChatFilterWords, Pattern: "fuck*, shit*, cunt*", Action: models.ChatFilterTell, Message: "Watch your language", ExemptPower: 50, Enabled: false},
+					}
+					if err := db.Create(&defaults).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			Down: func(db *gorm.DB) error {
+				return db.Migrator().DropTable(&models.ChatFilterRule{})
+			},
+		},
+		{
+			Version:     "020",
+			Name:        "add_scheduled_tasks",
+			Description: "Add scheduled tasks and their run history",
+			Up: func(db *gorm.DB) error {
+				return db.AutoMigrate(&models.ScheduledTask{}, &models.ScheduledTaskRun{})
+			},
+			Down: func(db *gorm.DB) error {
+				return db.Migrator().DropTable(&models.ScheduledTaskRun{}, &models.ScheduledTask{})
+			},
+		},
+		{
+			Version:     "021",
+			Name:        "add_automation_rules",
+			Description: "Add automation rules and their run history",
+			Up: func(db *gorm.DB) error {
+				return db.AutoMigrate(&models.AutomationRule{}, &models.AutomationRun{})
+			},
+			Down: func(db *gorm.DB) error {
+				return db.Migrator().DropTable(&models.AutomationRun{}, &models.AutomationRule{})
+			},
+		},
+		{
+			Version:     "022",
+			Name:        "add_map_rotations",
+			Description: "Add map rotations and custom maps",
+			Up: func(db *gorm.DB) error {
+				return db.AutoMigrate(&models.MapRotation{}, &models.CustomMap{})
+			},
+			Down: func(db *gorm.DB) error {
+				return db.Migrator().DropTable(&models.MapRotation{}, &models.CustomMap{})
+			},
+		},
+		{
+			Version:     "023",
+			Name:        "add_ban_feed_cursor_id",
+			Description: "Track the last ban ID pulled from each peer so feed cursors break ties",
+			Up: func(db *gorm.DB) error {
+				return db.AutoMigrate(&models.BanFeedPeer{})
+			},
+			Down: func(db *gorm.DB) error {
+				return db.Migrator().DropColumn(&models.BanFeedPeer{}, "cursor_id")
+			},
+		},
 	}
 }
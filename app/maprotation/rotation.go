@@ -0,0 +1,73 @@
+// Package maprotation checks map rotations against a server's game, pushes
+// them to the server and switches between them as the player count changes.
+package maprotation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethanburkett/goadmin/app/games"
+	"github.com/ethanburkett/goadmin/app/logger"
+	"github.com/ethanburkett/goadmin/app/models"
+	"github.com/ethanburkett/goadmin/app/rcon"
+	"github.com/ethanburkett/goadmin/app/rcon/commands"
+	"go.uber.org/zap"
+)
+
+// Check validates a rotation and verifies that every entry names a map and
+// gametype the server knows. Names are lowercased.
+func Check(rotation *models.MapRotation, adapter games.Adapter) error {
+	if err := rotation.Validate(); err != nil {
+		return err
+	}
+
+	known, err := games.KnownMaps(adapter, rotation.ServerID)
+	if err != nil {
+		return err
+	}
+
+	for i := range rotation.Entries {
+		entry := &rotation.Entries[i]
+		entry.Map = strings.ToLower(strings.TrimSpace(entry.Map))
+		entry.Gametype = strings.ToLower(strings.TrimSpace(entry.Gametype))
+
+		if !commands.IsIdentifier(entry.Map) || !contains(known, entry.Map) {
+			return fmt.Errorf("entry %d: unknown map: %s", i+1, entry.Map)
+		}
+		if entry.Gametype != "" && !games.IsKnownGametype(adapter, entry.Gametype) {
+			return fmt.Errorf("entry %d: unknown gametype: %s", i+1, entry.Gametype)
+		}
+	}
+	return nil
+}
+
+// Push sets sv_mapRotation and sv_mapRotationCurrent and marks the rotation
+// active. The map in play isn't changed; the rotation starts at the next
+// map change. Only rcon failures are returned; a failure to record the
+// rotation as active is logged.
+func Push(client *rcon.Client, rotation *models.MapRotation) ([]rcon.CommandResult, error) {
+	value := rotation.String()
+
+	var results []rcon.CommandResult
+	for _, cvar := range []string{"sv_mapRotation", "sv_mapRotationCurrent"} {
+		result, err := client.SetCvar(cvar, value)
+		results = append(results, result)
+		if err != nil {
+			return results, err
+		}
+	}
+
+	if err := models.SetActiveMapRotation(rotation); err != nil {
+		logger.Error("Failed to mark map rotation active", zap.Uint("rotation_id", rotation.ID), zap.Error(err))
+	}
+	return results, nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
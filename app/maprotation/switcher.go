@@ -0,0 +1,161 @@
+package maprotation
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/ethanburkett/goadmin/app/database"
+	"github.com/ethanburkett/goadmin/app/logger"
+	"github.com/ethanburkett/goadmin/app/models"
+	"github.com/ethanburkett/goadmin/app/supervisor"
+	"github.com/ethanburkett/goadmin/app/watcher"
+	"go.uber.org/zap"
+)
+
+// settleSnapshots is how many snapshots in a row must call for the same
+// rotation before switching, so a count hovering at a limit doesn't flap
+const settleSnapshots = 3
+
+// pendingSwitch is a rotation a server's player count has called for
+type pendingSwitch struct {
+	rotationID uint
+	snapshots  int
+}
+
+// Switcher pushes the auto-switch rotation that fits each server's player
+// count. Pushing a rotation that isn't auto-switched by hand pauses
+// switching on that server until an auto-switch rotation is pushed again.
+type Switcher struct {
+	servers *supervisor.Supervisor
+
+	mu      sync.Mutex
+	pending map[uint]pendingSwitch
+	busy    map[uint]bool
+}
+
+// NewSwitcher creates a switcher acting on the given servers
+func NewSwitcher(servers *supervisor.Supervisor) *Switcher {
+	return &Switcher{
+		servers: servers,
+		pending: make(map[uint]pendingSwitch),
+		busy:    make(map[uint]bool),
+	}
+}
+
+// Choose picks the auto-switch rotation for a player count. The active
+// rotation is kept while it still fits; otherwise the first that fits wins.
+func Choose(rotations []models.MapRotation, players int) *models.MapRotation {
+	var first *models.MapRotation
+	for i := range rotations {
+		rotation := &rotations[i]
+		if !rotation.AutoSwitch || !rotation.Fits(players) {
+			continue
+		}
+		if rotation.Active {
+			return rotation
+		}
+		if first == nil {
+			first = rotation
+		}
+	}
+	return first
+}
+
+// HandleSnapshot checks a server's player count against its rotations. It
+// is registered with the stats collector.
+func (s *Switcher) HandleSnapshot(snapshot watcher.Snapshot) {
+	if snapshot.ServerID == nil {
+		return
+	}
+	serverID := *snapshot.ServerID
+
+	rotations, err := models.GetAutoSwitchRotations(serverID)
+	if err != nil || len(rotations) == 0 {
+		return
+	}
+
+	active, err := models.GetActiveMapRotation(serverID)
+	if err != nil {
+		logger.Error("Failed to load active map rotation", zap.Uint("server_id", serverID), zap.Error(err))
+		return
+	}
+	if active != nil && !active.AutoSwitch {
+		return
+	}
+
+	// Wait for a map vote to finish and its winner to load
+	if inst, ok := s.servers.Get(serverID); ok && inst.Handler.MapVotePending() {
+		return
+	}
+
+	target := Choose(rotations, snapshot.Players)
+
+	s.mu.Lock()
+	if target == nil || target.Active {
+		delete(s.pending, serverID)
+		s.mu.Unlock()
+		return
+	}
+
+	pending := s.pending[serverID]
+	if pending.rotationID != target.ID {
+		pending = pendingSwitch{rotationID: target.ID}
+	}
+	pending.snapshots++
+	if pending.snapshots < settleSnapshots || s.busy[serverID] {
+		s.pending[serverID] = pending
+		s.mu.Unlock()
+		return
+	}
+	delete(s.pending, serverID)
+	s.busy[serverID] = true
+	s.mu.Unlock()
+
+	go s.switchTo(target, snapshot.Players)
+}
+
+// switchTo pushes a rotation and records the switch in the audit log
+func (s *Switcher) switchTo(rotation *models.MapRotation, players int) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.busy, rotation.ServerID)
+		s.mu.Unlock()
+	}()
+
+	var err error
+	if inst, ok := s.servers.Get(rotation.ServerID); ok {
+		_, err = Push(inst.RCON, rotation)
+	} else {
+		err = fmt.Errorf("server %d is not running", rotation.ServerID)
+	}
+
+	var errMsg string
+	if err != nil {
+		errMsg = err.Error()
+		logger.Error("Failed to switch map rotation", zap.String("rotation", rotation.Name), zap.Error(err))
+	} else {
+		logger.Info(fmt.Sprintf("Switched server %d to map rotation '%s' at %d players", rotation.ServerID, rotation.Name, players))
+	}
+
+	metadataJSON, _ := json.Marshal(map[string]interface{}{
+		"server_id": rotation.ServerID,
+		"players":   players,
+		"rotation":  rotation.String(),
+	})
+	models.CreateAuditLog(
+		database.DB,
+		nil,
+		"SYSTEM",
+		"",
+		models.ActionMapRotationSwitch,
+		models.SourceSystem,
+		err == nil,
+		errMsg,
+		"map_rotation",
+		fmt.Sprintf("%d", rotation.ID),
+		rotation.Name,
+		string(metadataJSON),
+		fmt.Sprintf("Switched to map rotation '%s' at %d players", rotation.Name, players),
+	)
+}
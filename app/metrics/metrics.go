@@ -2,11 +2,13 @@ package metrics
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/ethanburkett/goadmin/app/database"
 	"github.com/ethanburkett/goadmin/app/models"
 	"github.com/ethanburkett/goadmin/app/plugins"
+	"github.com/ethanburkett/goadmin/app/rcon"
 )
 
 // Metrics holds various system metrics
@@ -46,6 +48,9 @@ type Metrics struct {
 	// Cache metrics
 	CacheSize int `json:"cache_size"`
 
+	// RCON command queue metrics, one entry per server
+	RconQueues []rcon.QueueStats `json:"rcon_queues"`
+
 	// Uptime
 	UptimeSeconds int64 `json:"uptime_seconds"`
 }
@@ -105,6 +110,9 @@ func GetMetrics() (*Metrics, error) {
 		}
 	}
 
+	// RCON queue metrics
+	m.RconQueues = rcon.AllQueueStats()
+
 	// Uptime
 	m.UptimeSeconds = int64(time.Since(startTime).Seconds())
 
@@ -182,7 +190,7 @@ goadmin_commands_custom %d
 goadmin_uptime_seconds %d
 `
 
-	return fmt.Sprintf(format,
+	output := fmt.Sprintf(format,
 		m.DBOpenConns,
 		m.DBIdleConns,
 		m.DBWaitCount,
@@ -201,4 +209,41 @@ goadmin_uptime_seconds %d
 		m.CustomCommands,
 		m.UptimeSeconds,
 	)
+
+	return output + m.rconQueueMetrics()
+}
+
+// rconQueueMetrics formats per-server RCON queue metrics with a server label
+func (m *Metrics) rconQueueMetrics() string {
+	if len(m.RconQueues) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+
+	b.WriteString("\n# HELP goadmin_rcon_queue_depth Number of RCON commands waiting to be sent\n")
+	b.WriteString("# TYPE goadmin_rcon_queue_depth gauge\n")
+	for _, q := range m.RconQueues {
+		fmt.Fprintf(&b, "goadmin_rcon_queue_depth{server=%q} %d\n", q.Server, q.Depth)
+	}
+
+	b.WriteString("\n# HELP goadmin_rcon_commands_total Total number of RCON commands sent\n")
+	b.WriteString("# TYPE goadmin_rcon_commands_total counter\n")
+	for _, q := range m.RconQueues {
+		fmt.Fprintf(&b, "goadmin_rcon_commands_total{server=%q} %d\n", q.Server, q.Processed)
+	}
+
+	b.WriteString("\n# HELP goadmin_rcon_queue_wait_avg_ms Average time commands spent queued (ms)\n")
+	b.WriteString("# TYPE goadmin_rcon_queue_wait_avg_ms gauge\n")
+	for _, q := range m.RconQueues {
+		fmt.Fprintf(&b, "goadmin_rcon_queue_wait_avg_ms{server=%q} %.2f\n", q.Server, q.AvgWaitMs)
+	}
+
+	b.WriteString("\n# HELP goadmin_rcon_queue_wait_max_ms Longest time a command spent queued (ms)\n")
+	b.WriteString("# TYPE goadmin_rcon_queue_wait_max_ms gauge\n")
+	for _, q := range m.RconQueues {
+		fmt.Fprintf(&b, "goadmin_rcon_queue_wait_max_ms{server=%q} %.2f\n", q.Server, q.MaxWaitMs)
+	}
+
+	return b.String()
 }
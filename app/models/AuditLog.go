@@ -14,6 +14,22 @@ const (
 	ActionTempBanPlayer     ActionType = "tempban_player"
 	ActionKickPlayer        ActionType = "kick_player"
 	ActionUnbanPlayer       ActionType = "unban_player"
+	ActionBanUpdate         ActionType = "ban_update"
+	ActionBanAppealReview   ActionType = "ban_appeal_review"
+	ActionWarnPlayer        ActionType = "warn_player"
+	ActionWarningClear      ActionType = "warning_clear"
+	ActionWarningConfig     ActionType = "warning_config"
+	ActionChatFilter        ActionType = "chat_filter"
+	ActionChatFilterConfig  ActionType = "chat_filter_config"
+	ActionMutePlayer        ActionType = "mute_player"
+	ActionUnmutePlayer      ActionType = "unmute_player"
+	ActionTaskConfig        ActionType = "task_config"
+	ActionTaskRun           ActionType = "task_run"
+	ActionAutomationConfig  ActionType = "automation_config"
+	ActionAutomationRun     ActionType = "automation_run"
+	ActionMapRotationConfig ActionType = "map_rotation_config"
+	ActionMapRotationPush   ActionType = "map_rotation_push"
+	ActionMapRotationSwitch ActionType = "map_rotation_switch"
 	ActionRconCommand       ActionType = "rcon_command"
 	ActionRoleAssign        ActionType = "role_assign"
 	ActionRoleRevoke        ActionType = "role_revoke"
@@ -0,0 +1,222 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/database"
+	"gorm.io/gorm"
+)
+
+// AutomationTriggerSnapshot fires a rule on each stats collection instead of
+// on an event
+const AutomationTriggerSnapshot = "snapshot"
+
+// Condition operators. Values are compared as numbers when both sides are
+// numeric and as case-insensitive strings otherwise.
+const (
+	ConditionEq       = "eq"
+	ConditionNe       = "ne"
+	ConditionGt       = "gt"
+	ConditionGte      = "gte"
+	ConditionLt       = "lt"
+	ConditionLte      = "lte"
+	ConditionContains = "contains"
+	ConditionMatches  = "matches" // Regular expression
+	ConditionIn       = "in"      // Comma-separated list
+	ConditionBetween  = "between" // "low-high", inclusive; wraps when low > high, e.g. "22:00-06:00"
+	ConditionExists   = "exists"
+)
+
+// What an automation rule can do
+const (
+	AutomationRcon    = "rcon"    // Value is a command
+	AutomationSay     = "say"     // Value is a message for all players
+	AutomationTell    = "tell"    // Value is a message; Target the player, default {slot}
+	AutomationWebhook = "webhook" // Value is a message sent with automation.triggered
+	AutomationGroup   = "group"   // Value is a literal group name; Target the player's GUID, default {guid}
+)
+
+// automationRunsKept is how many runs of each rule are kept for history
+const automationRunsKept = 100
+
+// AutomationCondition compares a field of the trigger's context to a value
+type AutomationCondition struct {
+	Field string `json:"field"`
+	Op    string `json:"op"`
+	Value string `json:"value"`
+}
+
+// AutomationAction is one thing a rule does when it fires. Value and Target
+// may use {field} placeholders from the trigger's context.
+type AutomationAction struct {
+	Type   string `json:"type"`
+	Value  string `json:"value"`
+	Target string `json:"target,omitempty"`
+}
+
+// AutomationRule fires its actions when its trigger happens and all of its
+// conditions hold
+type AutomationRule struct {
+	ID              uint                  `gorm:"primaryKey" json:"id"`
+	Name            string                `gorm:"not null" json:"name"`
+	Trigger         string                `gorm:"not null;index" json:"trigger"`   // Event type, or "snapshot"
+	ServerID        *uint                 `gorm:"index" json:"serverId,omitempty"` // nil matches every server
+	Conditions      []AutomationCondition `gorm:"serializer:json" json:"conditions"`
+	Actions         []AutomationAction    `gorm:"serializer:json" json:"actions"`
+	CooldownSeconds int                   `json:"cooldownSeconds"` // Per server
+	DryRun          bool                  `json:"dryRun"`          // Record what would happen without doing it
+	Enabled         bool                  `json:"enabled"`
+	LastFiredAt     *time.Time            `json:"lastFiredAt"`
+	FireCount       int                   `json:"fireCount"`
+	CreatedByName   string                `json:"createdByName"`
+	CreatedAt       time.Time             `json:"createdAt"`
+	UpdatedAt       time.Time             `json:"updatedAt"`
+}
+
+// AutomationRun is one firing of a rule
+type AutomationRun struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	RuleID    uint      `gorm:"not null;index" json:"ruleId"`
+	ServerID  *uint     `gorm:"index" json:"serverId,omitempty"`
+	Trigger   string    `json:"trigger"`
+	DryRun    bool      `json:"dryRun"`
+	Success   bool      `gorm:"index" json:"success"`
+	Results   []string  `gorm:"serializer:json" json:"results"` // One line per action
+	Error     string    `gorm:"type:text" json:"error,omitempty"`
+	Context   string    `gorm:"type:text" json:"context"` // JSON of the fields the rule saw
+	CreatedAt time.Time `gorm:"index" json:"createdAt"`
+}
+
+// Validate checks a rule's fields. Whether the trigger names a known event
+// is left to the automation engine.
+func (r *AutomationRule) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if strings.TrimSpace(r.Trigger) == "" {
+		return fmt.Errorf("trigger is required")
+	}
+	if r.CooldownSeconds < 0 {
+		return fmt.Errorf("cooldown cannot be negative")
+	}
+
+	for i, cond := range r.Conditions {
+		if strings.TrimSpace(cond.Field) == "" {
+			return fmt.Errorf("condition %d needs a field", i+1)
+		}
+		switch cond.Op {
+		case ConditionEq, ConditionNe, ConditionGt, ConditionGte, ConditionLt, ConditionLte,
+			ConditionContains, ConditionIn, ConditionExists:
+		case ConditionMatches:
+			if _, err := regexp.Compile(cond.Value); err != nil {
+				return fmt.Errorf("condition %d: invalid expression: %v", i+1, err)
+			}
+		case ConditionBetween:
+			if _, _, ok := SplitBetween(cond.Value); !ok {
+				return fmt.Errorf("condition %d: between takes \"low-high\"", i+1)
+			}
+		default:
+			return fmt.Errorf("condition %d: unknown operator: %s", i+1, cond.Op)
+		}
+	}
+
+	if len(r.Actions) == 0 {
+		return fmt.Errorf("at least one action is required")
+	}
+	for i, action := range r.Actions {
+		switch action.Type {
+		case AutomationRcon, AutomationSay, AutomationTell, AutomationWebhook, AutomationGroup:
+		default:
+			return fmt.Errorf("action %d: unknown type: %s", i+1, action.Type)
+		}
+		if strings.TrimSpace(action.Value) == "" {
+			return fmt.Errorf("action %d needs a value", i+1)
+		}
+	}
+	return nil
+}
+
+// SplitBetween splits a between condition's "low-high" value
+func SplitBetween(value string) (low, high string, ok bool) {
+	// Split on the first "-" after the first character, so a negative low
+	// bound still works
+	if len(value) < 3 {
+		return "", "", false
+	}
+	i := strings.Index(value[1:], "-")
+	if i < 0 {
+		return "", "", false
+	}
+	low, high = strings.TrimSpace(value[:i+1]), strings.TrimSpace(value[i+2:])
+	return low, high, low != "" && high != ""
+}
+
+// GetAutomationRules lists the automation rules by name
+func GetAutomationRules() ([]AutomationRule, error) {
+	var rules []AutomationRule
+	err := database.DB.Order("name ASC").Find(&rules).Error
+	return rules, err
+}
+
+// GetEnabledAutomationRules lists the rules the engine evaluates
+func GetEnabledAutomationRules() ([]AutomationRule, error) {
+	var rules []AutomationRule
+	err := database.DB.Where("enabled = ?", true).Order("id ASC").Find(&rules).Error
+	return rules, err
+}
+
+// GetAutomationRule gets an automation rule by ID
+func GetAutomationRule(id uint) (*AutomationRule, error) {
+	var rule AutomationRule
+	if err := database.DB.First(&rule, id).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// SaveAutomationRule creates or updates a rule
+func SaveAutomationRule(rule *AutomationRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	return database.DB.Save(rule).Error
+}
+
+// DeleteAutomationRule removes a rule and its history
+func DeleteAutomationRule(id uint) error {
+	if err := database.DB.Where("rule_id = ?", id).Delete(&AutomationRun{}).Error; err != nil {
+		return err
+	}
+	return database.DB.Delete(&AutomationRule{}, id).Error
+}
+
+// RecordAutomationRun stores a firing of a rule, counts it on the rule and
+// trims old history
+func RecordAutomationRun(run *AutomationRun) error {
+	if err := database.DB.Create(run).Error; err != nil {
+		return err
+	}
+
+	err := database.DB.Model(&AutomationRule{}).Where("id = ?", run.RuleID).Updates(map[string]interface{}{
+		"last_fired_at": run.CreatedAt,
+		"fire_count":    gorm.Expr("fire_count + ?", 1),
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	return database.DB.Where("rule_id = ? AND id NOT IN (?)", run.RuleID,
+		database.DB.Model(&AutomationRun{}).Select("id").Where("rule_id = ?", run.RuleID).
+			Order("id DESC").Limit(automationRunsKept)).
+		Delete(&AutomationRun{}).Error
+}
+
+// GetAutomationRuns lists a rule's runs, newest first
+func GetAutomationRuns(ruleID uint, limit int) ([]AutomationRun, error) {
+	var runs []AutomationRun
+	err := database.DB.Where("rule_id = ?", ruleID).Order("id DESC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
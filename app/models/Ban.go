@@ -0,0 +1,601 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// What a ban matches on
+const (
+	BanTypeGUID    = "guid"     // Exact GUID
+	BanTypeIP      = "ip"       // Exact IP address
+	BanTypeIPRange = "ip_range" // CIDR range, e.g. 203.0.113.0/24
+	BanTypeName    = "name"     // Name pattern with * and ? wildcards, color codes and case ignored
+)
+
+// Ban categories, shared with peer instances in place of the reason
+const (
+	BanCategoryCheating   = "cheating"
+	BanCategoryExploiting = "exploiting"
+	BanCategoryGriefing   = "griefing" // Team killing, spawn camping teammates, etc.
+	BanCategoryAbuse      = "abuse"    // Harassment, hate speech
+	BanCategoryEvasion    = "evasion"  // Returning on a new account
+	BanCategoryOther      = "other"
+)
+
+// BanCategories lists every ban category
+var BanCategories = []string{
+	BanCategoryCheating, BanCategoryExploiting, BanCategoryGriefing,
+	BanCategoryAbuse, BanCategoryEvasion, BanCategoryOther,
+}
+
+// Appeal states
+const (
+	AppealPending  = "pending"
+	AppealAccepted = "accepted"
+	AppealRejected = "rejected"
+)
+
+// Ban is a ban owned by GoAdmin and enforced on every server, as opposed to
+// the game server's own ban file
+type Ban struct {
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	Type           string         `gorm:"not null;index:idx_ban_lookup" json:"type"`
+	Value          string         `gorm:"not null;index:idx_ban_lookup" json:"value"` // GUID, IP, CIDR or name pattern
+	PlayerName     string         `json:"playerName"`                                 // Name of the player when banned
+	PlayerGUID     string         `gorm:"index" json:"playerGuid"`                    // GUID of the player when known, for any ban type
+	Reason         string         `gorm:"type:text;not null" json:"reason"`
+	Category       string         `gorm:"default:'other'" json:"category"`
+	Evidence       []string       `gorm:"serializer:json" json:"evidence"` // Links to demos, screenshots, etc.
+	BannedByUserID *uint          `gorm:"index" json:"bannedByUserId"`
+	BannedByUser   *User          `gorm:"foreignKey:BannedByUserID;constraint:OnDelete:SET NULL" json:"bannedByUser,omitempty"`
+	BannedByName   string         `json:"bannedByName"`                    // Dashboard username or in-game admin name
+	ServerID       *uint          `gorm:"index" json:"serverId,omitempty"` // Server the ban was issued on
+	Server         *Server        `gorm:"foreignKey:ServerID;constraint:OnDelete:SET NULL" json:"server,omitempty"`
+	Source         string         `json:"source"`                            // web, in-game, report, import
+	ExpiresAt      *time.Time     `gorm:"index" json:"expiresAt"`            // Nil for permanent bans
+	FeedPeerID     *uint          `gorm:"index" json:"feedPeerId,omitempty"` // Peer instance the ban came from
+	FeedPeer       *BanFeedPeer   `gorm:"foreignKey:FeedPeerID;constraint:OnDelete:SET NULL" json:"feedPeer,omitempty"`
+	FeedBanID      string         `gorm:"index" json:"feedBanId,omitempty"` // The ban's ID on that peer
+	Active         bool           `gorm:"default:true;index" json:"active"`
+	RevokedAt      *time.Time     `json:"revokedAt,omitempty"`
+	RevokedByName  string         `json:"revokedByName,omitempty"`
+	RevokeReason   string         `gorm:"type:text" json:"revokeReason,omitempty"`
+	Appeals        []BanAppeal    `gorm:"foreignKey:BanID" json:"appeals,omitempty"`
+	CreatedAt      time.Time      `json:"createdAt"`
+	UpdatedAt      time.Time      `json:"updatedAt"`
+	namePattern    *regexp.Regexp `gorm:"-"`
+}
+
+// BanAppeal is a banned player's request to have a ban lifted
+type BanAppeal struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	BanID            uint      `gorm:"not null;index" json:"banId"`
+	Ban              *Ban      `gorm:"foreignKey:BanID;constraint:OnDelete:CASCADE" json:"ban,omitempty"`
+	PlayerName       string    `json:"playerName"`
+	PlayerGUID       string    `gorm:"index" json:"playerGuid"`
+	Contact          string    `json:"contact"` // Email, Discord, etc.
+	Message          string    `gorm:"type:text;not null" json:"message"`
+	Status           string    `gorm:"default:'pending';index" json:"status"`
+	ReviewedByUserID *uint     `gorm:"index" json:"reviewedByUserId"`
+	ReviewedBy       *User     `gorm:"foreignKey:ReviewedByUserID;constraint:OnDelete:SET NULL" json:"reviewedBy,omitempty"`
+	ReviewNote       string    `gorm:"type:text" json:"reviewNote"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+// BanFilter narrows ban listings
+type BanFilter struct {
+	ActiveOnly bool
+	Type       string
+	Search     string // Matches value, player name or GUID
+}
+
+var (
+	// ErrBanNotFound is returned when no ban matches
+	ErrBanNotFound = errors.New("ban not found")
+	// ErrAmbiguousBan is returned when a lookup matches bans for more than
+	// one player and a ban ID is needed to pick one
+	ErrAmbiguousBan = errors.New("more than one ban matches, use the ban ID")
+	// ErrAppealPending is returned when a ban already has an appeal awaiting review
+	ErrAppealPending = errors.New("an appeal for this ban is already pending")
+)
+
+// ValidBanType reports whether t is a known ban type
+func ValidBanType(t string) bool {
+	switch t {
+	case BanTypeGUID, BanTypeIP, BanTypeIPRange, BanTypeName:
+		return true
+	}
+	return false
+}
+
+// ValidBanCategory reports whether c is a known ban category
+func ValidBanCategory(c string) bool {
+	for _, category := range BanCategories {
+		if category == c {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeBanValue validates a ban value for its type and returns it in the
+// form it is stored and matched in
+func NormalizeBanValue(banType, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", errors.New("ban value is required")
+	}
+
+	switch banType {
+	case BanTypeGUID:
+		return strings.ToLower(value), nil
+	case BanTypeIP:
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return "", fmt.Errorf("invalid IP address: %s", value)
+		}
+		return ip.String(), nil
+	case BanTypeIPRange:
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid IP range (expected CIDR such as 203.0.113.0/24): %s", value)
+		}
+		return network.String(), nil
+	case BanTypeName:
+		pattern := NormalizeAliasName(value)
+		if strings.Trim(pattern, "*?") == "" {
+			return "", errors.New("name pattern must contain more than wildcards")
+		}
+		return pattern, nil
+	}
+	return "", fmt.Errorf("unknown ban type: %s", banType)
+}
+
+// IsPermanent reports whether the ban has no expiry
+func (b *Ban) IsPermanent() bool {
+	return b.ExpiresAt == nil
+}
+
+// IsInEffect reports whether the ban is active and not expired
+func (b *Ban) IsInEffect() bool {
+	return b.Active && (b.ExpiresAt == nil || b.ExpiresAt.After(time.Now()))
+}
+
+// Matches reports whether a player is covered by the ban. ip and name may
+// be empty when unknown.
+func (b *Ban) Matches(guid, ip, name string) bool {
+	switch b.Type {
+	case BanTypeGUID:
+		return guid != "" && strings.EqualFold(b.Value, guid)
+	case BanTypeIP:
+		parsed := net.ParseIP(ip)
+		return parsed != nil && parsed.String() == b.Value
+	case BanTypeIPRange:
+		parsed := net.ParseIP(ip)
+		if parsed == nil {
+			return false
+		}
+		_, network, err := net.ParseCIDR(b.Value)
+		return err == nil && network.Contains(parsed)
+	case BanTypeName:
+		if name == "" {
+			return false
+		}
+		if b.namePattern == nil {
+			b.namePattern = compileNamePattern(b.Value)
+		}
+		return b.namePattern.MatchString(NormalizeAliasName(name))
+	}
+	return false
+}
+
+// KickMessage is shown to a player removed by this ban
+func (b *Ban) KickMessage() string {
+	msg := fmt.Sprintf("Banned: %s", b.Reason)
+	if b.ExpiresAt != nil {
+		msg = fmt.Sprintf("Banned until %s: %s", b.ExpiresAt.Format("2006-01-02 15:04"), b.Reason)
+	}
+	return fmt.Sprintf("%s (Ban #%d)", msg, b.ID)
+}
+
+func compileNamePattern(pattern string) *regexp.Regexp {
+	quoted := regexp.QuoteMeta(pattern)
+	quoted = strings.ReplaceAll(quoted, `\*`, ".*")
+	quoted = strings.ReplaceAll(quoted, `\?`, ".")
+	return regexp.MustCompile("^" + quoted + "$")
+}
+
+// CreateBan validates and stores a ban
+func CreateBan(ban *Ban) error {
+	if !ValidBanType(ban.Type) {
+		return fmt.Errorf("unknown ban type: %s", ban.Type)
+	}
+	value, err := NormalizeBanValue(ban.Type, ban.Value)
+	if err != nil {
+		return err
+	}
+	ban.Value = value
+	ban.Active = true
+	if ban.Category == "" {
+		ban.Category = BanCategoryOther
+	}
+	if !ValidBanCategory(ban.Category) {
+		return fmt.Errorf("unknown ban category: %s", ban.Category)
+	}
+	if ban.Type == BanTypeGUID && ban.PlayerGUID == "" {
+		ban.PlayerGUID = value
+	}
+	defer InvalidateBanMatcher()
+	return database.DB.Create(ban).Error
+}
+
+// GetBanByID gets a ban with its appeals
+func GetBanByID(id uint) (*Ban, error) {
+	var ban Ban
+	err := database.DB.Preload("BannedByUser").Preload("Server").Preload("Appeals").First(&ban, id).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrBanNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ban, nil
+}
+
+// GetBans lists bans, newest first
+func GetBans(filter BanFilter, limit, offset int) ([]Ban, int64, error) {
+	var bans []Ban
+	var total int64
+
+	query := database.DB.Model(&Ban{})
+	if filter.ActiveOnly {
+		query = inEffect(query)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Search != "" {
+		like := "%" + strings.ToLower(filter.Search) + "%"
+		query = query.Where("LOWER(value) LIKE ? OR LOWER(player_name) LIKE ? OR LOWER(player_guid) LIKE ?", like, like, like)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("BannedByUser").Preload("Server").
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&bans).Error
+	return bans, total, err
+}
+
+// FindBansForPlayer returns bans in effect for a player who may not be
+// online. The identifier matches the GUID recorded on a ban, or a ban value
+// of its own type: an IP address, a CIDR range, or otherwise a GUID or name
+// pattern. The player name stored on a ban is not searched, as any number
+// of players may have used it. ErrAmbiguousBan is returned when more than
+// one ban matches and they are not all for the same GUID.
+func FindBansForPlayer(identifier string) ([]Ban, error) {
+	identifier = strings.TrimSpace(identifier)
+	guid := strings.ToLower(identifier)
+
+	conditions := database.DB.Where("LOWER(player_guid) = ?", guid)
+	for _, banType := range identifierBanTypes(identifier) {
+		if value, err := NormalizeBanValue(banType, identifier); err == nil {
+			conditions = conditions.Or("type = ? AND value = ?", banType, value)
+		}
+	}
+
+	var bans []Ban
+	err := inEffect(database.DB.Model(&Ban{})).
+		Where(conditions).
+		Order("created_at DESC").
+		Find(&bans).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bans) > 1 {
+		for _, ban := range bans {
+			if !strings.EqualFold(ban.PlayerGUID, guid) && !(ban.Type == BanTypeGUID && ban.Value == guid) {
+				return nil, ErrAmbiguousBan
+			}
+		}
+	}
+	return bans, nil
+}
+
+// identifierBanTypes returns the ban types a free-form identifier could be
+// a value of
+func identifierBanTypes(identifier string) []string {
+	if net.ParseIP(identifier) != nil {
+		return []string{BanTypeIP}
+	}
+	if _, _, err := net.ParseCIDR(identifier); err == nil {
+		return []string{BanTypeIPRange}
+	}
+	return []string{BanTypeGUID, BanTypeName}
+}
+
+// UpdateBan saves changes to a ban's own fields
+func UpdateBan(ban *Ban) error {
+	defer InvalidateBanMatcher()
+	return database.DB.Omit(clause.Associations).Save(ban).Error
+}
+
+// RevokeBan lifts a ban, keeping it for the record
+func RevokeBan(id uint, revokedBy, reason string) error {
+	defer InvalidateBanMatcher()
+	now := time.Now()
+	result := database.DB.Model(&Ban{}).Where("id = ? AND active = ?", id, true).Updates(map[string]interface{}{
+		"active":          false,
+		"revoked_at":      now,
+		"revoked_by_name": revokedBy,
+		"revoke_reason":   reason,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrBanNotFound
+	}
+	return nil
+}
+
+// BanKey identifies a ban by what it matches, for duplicate checks
+func BanKey(banType, value string) string {
+	return banType + "|" + value
+}
+
+// ActiveBanKeys returns the BanKey of every ban in effect
+func ActiveBanKeys() (map[string]bool, error) {
+	var bans []Ban
+	if err := inEffect(database.DB.Model(&Ban{})).Select("type", "value").Find(&bans).Error; err != nil {
+		return nil, err
+	}
+	keys := make(map[string]bool, len(bans))
+	for _, ban := range bans {
+		keys[BanKey(ban.Type, ban.Value)] = true
+	}
+	return keys, nil
+}
+
+// CreateBans stores bans in one transaction. Values must already be
+// normalized with NormalizeBanValue; CreatedAt is kept when set so imported
+// bans keep their original date.
+func CreateBans(bans []Ban) error {
+	for i := range bans {
+		bans[i].Active = true
+		if !ValidBanCategory(bans[i].Category) {
+			bans[i].Category = BanCategoryOther
+		}
+	}
+	defer InvalidateBanMatcher()
+	return database.DB.Omit(clause.Associations).CreateInBatches(bans, 100).Error
+}
+
+// GetBansInEffect returns every ban in effect, oldest first
+func GetBansInEffect() ([]Ban, error) {
+	var bans []Ban
+	err := inEffect(database.DB.Model(&Ban{})).Order("created_at ASC").Find(&bans).Error
+	return bans, err
+}
+
+// ExpireBans marks timed bans past their expiry as inactive
+func ExpireBans() error {
+	defer InvalidateBanMatcher()
+	return database.DB.Model(&Ban{}).
+		Where("active = ? AND expires_at IS NOT NULL AND expires_at <= ?", true, time.Now()).
+		Update("active", false).Error
+}
+
+func inEffect(query *gorm.DB) *gorm.DB {
+	return query.Where("active = ? AND (expires_at IS NULL OR expires_at > ?)", true, time.Now())
+}
+
+// BanMatcher checks players against every ban in effect. Load one per
+// sweep rather than querying per player, or use CachedBanMatcher. A matcher
+// is read-only once loaded and safe to share.
+type BanMatcher struct {
+	byGUID map[string]*Ban
+	byIP   map[string]*Ban
+	others []*Ban // Ranges and name patterns, checked one by one
+}
+
+// LoadBanMatcher loads all bans in effect
+func LoadBanMatcher() (*BanMatcher, error) {
+	var bans []Ban
+	if err := inEffect(database.DB.Model(&Ban{})).Find(&bans).Error; err != nil {
+		return nil, err
+	}
+
+	m := &BanMatcher{byGUID: make(map[string]*Ban), byIP: make(map[string]*Ban)}
+	for i := range bans {
+		ban := &bans[i]
+		switch ban.Type {
+		case BanTypeGUID:
+			m.byGUID[strings.ToLower(ban.Value)] = ban
+		case BanTypeIP:
+			m.byIP[ban.Value] = ban
+		default:
+			if ban.Type == BanTypeName {
+				ban.namePattern = compileNamePattern(ban.Value)
+			}
+			m.others = append(m.others, ban)
+		}
+	}
+	return m, nil
+}
+
+// The shared matcher is dropped whenever a ban is written. generation
+// counts the drops so a load that raced with a write isn't cached.
+var banMatcherCache struct {
+	mu         sync.Mutex
+	matcher    *BanMatcher
+	generation uint64
+}
+
+// CachedBanMatcher returns a shared matcher, loading it on first use and
+// after any ban is created, changed or expired. Bans that run out between
+// loads are skipped by Match.
+func CachedBanMatcher() (*BanMatcher, error) {
+	banMatcherCache.mu.Lock()
+	matcher, generation := banMatcherCache.matcher, banMatcherCache.generation
+	banMatcherCache.mu.Unlock()
+	if matcher != nil {
+		return matcher, nil
+	}
+
+	matcher, err := LoadBanMatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	banMatcherCache.mu.Lock()
+	if banMatcherCache.generation == generation {
+		banMatcherCache.matcher = matcher
+	}
+	banMatcherCache.mu.Unlock()
+	return matcher, nil
+}
+
+// InvalidateBanMatcher drops the shared matcher so the next
+// CachedBanMatcher call reloads the ban list
+func InvalidateBanMatcher() {
+	banMatcherCache.mu.Lock()
+	banMatcherCache.matcher = nil
+	banMatcherCache.generation++
+	banMatcherCache.mu.Unlock()
+}
+
+// Empty reports whether there are no bans to check
+func (m *BanMatcher) Empty() bool {
+	return len(m.byGUID) == 0 && len(m.byIP) == 0 && len(m.others) == 0
+}
+
+// HasAddressBans reports whether any ban needs the player's IP to match
+func (m *BanMatcher) HasAddressBans() bool {
+	if len(m.byIP) > 0 {
+		return true
+	}
+	for _, ban := range m.others {
+		if ban.Type == BanTypeIPRange {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns the ban covering a player, or nil
+func (m *BanMatcher) Match(guid, ip, name string) *Ban {
+	if ban, ok := m.byGUID[strings.ToLower(guid)]; ok && guid != "" && ban.IsInEffect() {
+		return ban
+	}
+	if parsed := net.ParseIP(ip); parsed != nil {
+		if ban, ok := m.byIP[parsed.String()]; ok && ban.IsInEffect() {
+			return ban
+		}
+	}
+	for _, ban := range m.others {
+		if ban.IsInEffect() && ban.Matches(guid, ip, name) {
+			return ban
+		}
+	}
+	return nil
+}
+
+// FindMatchingBan returns the ban in effect covering a player, or nil
+func FindMatchingBan(guid, ip, name string) (*Ban, error) {
+	matcher, err := CachedBanMatcher()
+	if err != nil {
+		return nil, err
+	}
+	return matcher.Match(guid, ip, name), nil
+}
+
+// CreateBanAppeal files an appeal against a ban in effect. guid must be the
+// banned player's GUID so appeals can't be filed against arbitrary bans.
+func CreateBanAppeal(banID uint, playerName, guid, contact, message string) (*BanAppeal, error) {
+	var ban Ban
+	if err := inEffect(database.DB.Model(&Ban{})).First(&ban, banID).Error; err != nil {
+		return nil, ErrBanNotFound
+	}
+	if ban.PlayerGUID == "" || !strings.EqualFold(ban.PlayerGUID, guid) {
+		return nil, ErrBanNotFound
+	}
+
+	var pending int64
+	database.DB.Model(&BanAppeal{}).Where("ban_id = ? AND status = ?", banID, AppealPending).Count(&pending)
+	if pending > 0 {
+		return nil, ErrAppealPending
+	}
+
+	appeal := &BanAppeal{
+		BanID:      banID,
+		PlayerName: playerName,
+		PlayerGUID: guid,
+		Contact:    contact,
+		Message:    message,
+		Status:     AppealPending,
+	}
+	return appeal, database.DB.Create(appeal).Error
+}
+
+// GetBanAppeals lists appeals, optionally by status, newest first
+func GetBanAppeals(status string) ([]BanAppeal, error) {
+	var appeals []BanAppeal
+	query := database.DB.Preload("Ban").Preload("ReviewedBy")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("created_at DESC").Find(&appeals).Error
+	return appeals, err
+}
+
+// ReviewBanAppeal accepts or rejects a pending appeal. Accepting revokes the ban.
+func ReviewBanAppeal(id uint, accept bool, reviewer *User, note string) (*BanAppeal, error) {
+	defer InvalidateBanMatcher()
+	var appeal BanAppeal
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&appeal, id).Error; err != nil {
+			return err
+		}
+		if appeal.Status != AppealPending {
+			return fmt.Errorf("appeal has already been %s", appeal.Status)
+		}
+
+		appeal.Status = AppealRejected
+		if accept {
+			appeal.Status = AppealAccepted
+		}
+		appeal.ReviewedByUserID = &reviewer.ID
+		appeal.ReviewNote = note
+		if err := tx.Save(&appeal).Error; err != nil {
+			return err
+		}
+
+		if !accept {
+			return nil
+		}
+		return tx.Model(&Ban{}).Where("id = ?", appeal.BanID).Updates(map[string]interface{}{
+			"active":          false,
+			"revoked_at":      time.Now(),
+			"revoked_by_name": reviewer.Username,
+			"revoke_reason":   "Appeal accepted: " + note,
+		}).Error
+	})
+	return &appeal, err
+}
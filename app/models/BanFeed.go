@@ -0,0 +1,190 @@
+package models
+
+import (
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// What a subscriber does with a peer's bans
+const (
+	FeedPolicyBan  = "ban"  // Apply as local bans (limited to BanCategories if set)
+	FeedPolicyFlag = "flag" // Only flag the GUID for admins to watch
+)
+
+// BanFeedPeer is another GoAdmin instance we share bans with. The secret is
+// agreed out of band; we sign our feed with it when the peer pulls, and
+// check their feed against it when we pull.
+type BanFeedPeer struct {
+	ID                  uint       `gorm:"primaryKey" json:"id"`
+	Name                string     `gorm:"uniqueIndex;not null" json:"name"` // The peer's instance name
+	URL                 string     `json:"url"`                              // The peer's /feed/bans URL
+	Secret              string     `gorm:"not null" json:"-"`
+	Enabled             bool       `json:"enabled"`
+	Subscribe           bool       `json:"subscribe"` // Pull their feed
+	Publish             bool       `json:"publish"`   // Let them pull ours
+	Policy              string     `gorm:"default:'flag'" json:"policy"`
+	BanCategories       []string   `gorm:"serializer:json" json:"banCategories"` // Categories applied as bans under the ban policy; empty means all
+	PullIntervalMinutes int        `gorm:"default:15" json:"pullIntervalMinutes"`
+	Cursor              *time.Time `json:"cursor"`   // Update time of the newest entry pulled
+	CursorID            uint       `json:"cursorId"` // ID of the newest entry pulled, breaking ties on Cursor
+	LastPulledAt        *time.Time `json:"lastPulledAt"`
+	LastError           string     `gorm:"type:text" json:"lastError"`
+	CreatedAt           time.Time  `json:"createdAt"`
+	UpdatedAt           time.Time  `json:"updatedAt"`
+}
+
+// BanFlag marks a GUID a peer banned that we don't enforce, so admins can
+// keep an eye on the player
+type BanFlag struct {
+	ID              uint         `gorm:"primaryKey" json:"id"`
+	PeerID          uint         `gorm:"not null;uniqueIndex:idx_ban_flag_remote" json:"peerId"`
+	Peer            *BanFeedPeer `gorm:"foreignKey:PeerID;constraint:OnDelete:CASCADE" json:"peer,omitempty"`
+	RemoteBanID     string       `gorm:"not null;uniqueIndex:idx_ban_flag_remote" json:"remoteBanId"`
+	GUID            string       `gorm:"not null;index" json:"guid"`
+	Category        string       `json:"category"`
+	IssuingInstance string       `json:"issuingInstance"`
+	BannedAt        time.Time    `json:"bannedAt"`
+	ExpiresAt       *time.Time   `json:"expiresAt"`
+	Active          bool         `gorm:"index" json:"active"`
+	CreatedAt       time.Time    `json:"createdAt"`
+	UpdatedAt       time.Time    `json:"updatedAt"`
+}
+
+// ShouldBan reports whether the peer's policy applies a ban of the given
+// category as a local ban rather than a flag
+func (p *BanFeedPeer) ShouldBan(category string) bool {
+	if p.Policy != FeedPolicyBan {
+		return false
+	}
+	if len(p.BanCategories) == 0 {
+		return true
+	}
+	for _, c := range p.BanCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// PullDue reports whether the peer's feed should be pulled now
+func (p *BanFeedPeer) PullDue(now time.Time) bool {
+	if !p.Enabled || !p.Subscribe || p.URL == "" {
+		return false
+	}
+	interval := time.Duration(p.PullIntervalMinutes) * time.Minute
+	if interval < time.Minute {
+		interval = time.Minute
+	}
+	return p.LastPulledAt == nil || now.Sub(*p.LastPulledAt) >= interval
+}
+
+// GetBanFeedPeers lists all peers
+func GetBanFeedPeers() ([]BanFeedPeer, error) {
+	var peers []BanFeedPeer
+	err := database.DB.Order("name ASC").Find(&peers).Error
+	return peers, err
+}
+
+// GetBanFeedPeer gets a peer by ID
+func GetBanFeedPeer(id uint) (*BanFeedPeer, error) {
+	var peer BanFeedPeer
+	if err := database.DB.First(&peer, id).Error; err != nil {
+		return nil, err
+	}
+	return &peer, nil
+}
+
+// GetPublishingPeer gets an enabled peer allowed to pull our feed by name
+func GetPublishingPeer(name string) (*BanFeedPeer, error) {
+	var peer BanFeedPeer
+	err := database.DB.Where("name = ? AND enabled = ? AND publish = ?", name, true, true).First(&peer).Error
+	if err != nil {
+		return nil, err
+	}
+	return &peer, nil
+}
+
+// CreateBanFeedPeer adds a peer
+func CreateBanFeedPeer(peer *BanFeedPeer) error {
+	return database.DB.Create(peer).Error
+}
+
+// SaveBanFeedPeer saves all of a peer's fields
+func SaveBanFeedPeer(peer *BanFeedPeer) error {
+	return database.DB.Save(peer).Error
+}
+
+// DeleteBanFeedPeer removes a peer and its flags. Bans applied from its feed
+// stay in the ban list.
+func DeleteBanFeedPeer(id uint) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("peer_id = ?", id).Delete(&BanFlag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&Ban{}).Where("feed_peer_id = ?", id).Update("feed_peer_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&BanFeedPeer{}, id).Error
+	})
+}
+
+// GetLocalBansUpdatedSince returns GUID bans issued on this instance that
+// changed after the (since, afterID) cursor, oldest change first. The ID
+// breaks ties so a page of bans sharing one update time, as ExpireBans
+// leaves, can't be served forever. Bans from peers are left out so feeds
+// don't echo each other.
+func GetLocalBansUpdatedSince(since *time.Time, afterID uint, limit int) ([]Ban, error) {
+	var bans []Ban
+	query := database.DB.Where("type = ? AND feed_peer_id IS NULL AND source NOT LIKE ?", BanTypeGUID, "feed:%")
+	if since != nil {
+		query = query.Where("(updated_at, id) > (?, ?)", ColumnTime(*since), afterID)
+	}
+	err := query.Order("updated_at ASC, id ASC").Limit(limit).Find(&bans).Error
+	return bans, err
+}
+
+// GetFeedBan finds the local ban applied from a peer's ban, or nil if the
+// peer's ban was never applied. Most entries under the flag policy have
+// none, so a miss isn't an error.
+func GetFeedBan(peerID uint, remoteID string) (*Ban, error) {
+	var bans []Ban
+	err := database.DB.Where("feed_peer_id = ? AND feed_ban_id = ?", peerID, remoteID).Limit(1).Find(&bans).Error
+	if err != nil || len(bans) == 0 {
+		return nil, err
+	}
+	return &bans[0], nil
+}
+
+// UpsertBanFlag records or updates a flag from a peer's ban
+func UpsertBanFlag(flag *BanFlag) error {
+	return database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "peer_id"}, {Name: "remote_ban_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"guid", "category", "issuing_instance", "banned_at", "expires_at", "active", "updated_at"}),
+	}).Create(flag).Error
+}
+
+// DeactivateBanFlag clears a flag when the peer lifts its ban
+func DeactivateBanFlag(peerID uint, remoteID string) error {
+	return database.DB.Model(&BanFlag{}).
+		Where("peer_id = ? AND remote_ban_id = ?", peerID, remoteID).
+		Update("active", false).Error
+}
+
+// GetBanFlags lists flags, newest first, optionally for one GUID and only
+// active ones
+func GetBanFlags(guid string, activeOnly bool) ([]BanFlag, error) {
+	var flags []BanFlag
+	query := database.DB.Preload("Peer")
+	if guid != "" {
+		query = query.Where("guid = ?", guid)
+	}
+	if activeOnly {
+		query = query.Where("active = ? AND (expires_at IS NULL OR expires_at > ?)", true, time.Now())
+	}
+	err := query.Order("banned_at DESC").Find(&flags).Error
+	return flags, err
+}
@@ -0,0 +1,157 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/database"
+)
+
+// What a chat filter rule looks for
+const (
+	ChatFilterWords       = "words"       // Pattern lists words, "*" suffix matches prefixes
+	ChatFilterRegex       = "regex"       // Pattern holds one regular expression per line
+	ChatFilterAdvertising = "advertising" // IPs and URLs; Pattern lists allowed hosts
+	ChatFilterRepeat      = "repeat"      // Threshold identical lines within WindowSeconds
+	ChatFilterFlood       = "flood"       // Threshold lines within WindowSeconds
+	ChatFilterCaps        = "caps"        // Threshold percent capitals, lines of MinLength letters or more
+)
+
+// What a chat filter rule does to a player who breaks it
+const (
+	ChatFilterTell    = "tell"
+	ChatFilterMute    = "mute"
+	ChatFilterKick    = "kick"
+	ChatFilterTempBan = "tempban"
+)
+
+// ChatFilterRule is one rule of the chat filter, checked against every
+// say and sayteam line
+type ChatFilterRule struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	Name            string    `gorm:"not null" json:"name"`
+	Type            string    `gorm:"not null" json:"type"`
+	Pattern         string    `gorm:"type:text" json:"pattern"`
+	Threshold       int       `json:"threshold"`
+	WindowSeconds   int       `json:"windowSeconds"`
+	MinLength       int       `json:"minLength"`
+	Action          string    `gorm:"not null" json:"action"` // tell, mute, kick or tempban
+	DurationMinutes int       `json:"durationMinutes"`        // Mute or tempban length
+	Message         string    `gorm:"type:text" json:"message"`
+	ExemptPower     int       `json:"exemptPower"` // Players at or above this power skip the rule, 0 exempts nobody
+	Enabled         bool      `json:"enabled"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// Validate checks a rule's fields
+func (r *ChatFilterRule) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	switch r.Type {
+	case ChatFilterWords:
+		if len(r.Terms()) == 0 {
+			return fmt.Errorf("word rules need at least one word")
+		}
+	case ChatFilterRegex:
+		terms := r.Terms()
+		if len(terms) == 0 {
+			return fmt.Errorf("regex rules need at least one expression")
+		}
+		for _, expr := range terms {
+			if _, err := regexp.Compile(expr); err != nil {
+				return fmt.Errorf("invalid expression %q: %v", expr, err)
+			}
+		}
+	case ChatFilterAdvertising:
+	case ChatFilterRepeat, ChatFilterFlood:
+		if r.Threshold < 2 {
+			return fmt.Errorf("threshold must be at least 2 messages")
+		}
+		if r.WindowSeconds < 1 {
+			return fmt.Errorf("window must be at least 1 second")
+		}
+	case ChatFilterCaps:
+		if r.Threshold < 1 || r.Threshold > 100 {
+			return fmt.Errorf("threshold must be a percentage between 1 and 100")
+		}
+	default:
+		return fmt.Errorf("unknown rule type: %s", r.Type)
+	}
+
+	switch r.Action {
+	case ChatFilterTell, ChatFilterKick:
+	case ChatFilterMute, ChatFilterTempBan:
+		if r.DurationMinutes < 1 {
+			return fmt.Errorf("%s rules need a duration", r.Action)
+		}
+	default:
+		return fmt.Errorf("unknown action: %s", r.Action)
+	}
+
+	if r.ExemptPower < 0 {
+		return fmt.Errorf("exempt power cannot be negative")
+	}
+	return nil
+}
+
+// Terms splits Pattern into its entries. Regex rules take one expression
+// per line; other rules also accept commas.
+func (r *ChatFilterRule) Terms() []string {
+	separators := "\n"
+	if r.Type != ChatFilterRegex {
+		separators = "\n,"
+	}
+	var terms []string
+	for _, term := range strings.FieldsFunc(r.Pattern, func(c rune) bool {
+		return strings.ContainsRune(separators, c)
+	}) {
+		if term = strings.TrimSpace(term); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// Duration is the mute or tempban length of the rule
+func (r *ChatFilterRule) Duration() time.Duration {
+	return time.Duration(r.DurationMinutes) * time.Minute
+}
+
+// Exempts reports whether a player with the given power skips the rule
+func (r *ChatFilterRule) Exempts(power int) bool {
+	return r.ExemptPower > 0 && power >= r.ExemptPower
+}
+
+// GetChatFilterRules lists the chat filter rules in the order they're checked
+func GetChatFilterRules() ([]ChatFilterRule, error) {
+	var rules []ChatFilterRule
+	err := database.DB.Order("id ASC").Find(&rules).Error
+	return rules, err
+}
+
+// GetChatFilterRule gets a chat filter rule by ID
+func GetChatFilterRule(id uint) (*ChatFilterRule, error) {
+	var rule ChatFilterRule
+	if err := database.DB.First(&rule, id).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// SaveChatFilterRule creates or updates a chat filter rule
+func SaveChatFilterRule(rule *ChatFilterRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	return database.DB.Save(rule).Error
+}
+
+// DeleteChatFilterRule removes a chat filter rule
+func DeleteChatFilterRule(id uint) error {
+	return database.DB.Delete(&ChatFilterRule{}, id).Error
+}
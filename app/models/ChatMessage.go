@@ -0,0 +1,126 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ethanburkett/goadmin/app/database"
+	"gorm.io/gorm"
+)
+
+// ChatMessage is one say or sayteam line from a server's log, commands
+// included
+type ChatMessage struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ServerID   *uint     `gorm:"index" json:"serverId,omitempty"`
+	PlayerGUID string    `gorm:"index" json:"playerGuid"`
+	PlayerName string    `json:"playerName"`
+	Message    string    `gorm:"type:text;not null" json:"message"`
+	Team       bool      `json:"team"` // sayteam
+	CreatedAt  time.Time `gorm:"index" json:"createdAt"`
+}
+
+// ChatSearchTable is the FTS4 index over chat_messages.message, kept in sync
+// by triggers created in the add_chat_messages migration
+const ChatSearchTable = "chat_messages_fts"
+
+// ChatFilter narrows a chat log query. Zero values don't filter.
+type ChatFilter struct {
+	Search     string // Full-text; every word must appear, words match as prefixes
+	PlayerGUID string
+	PlayerName string // Substring, ignoring case
+	ServerID   *uint
+	Since      *time.Time
+	Until      *time.Time
+}
+
+// ColumnTime converts a bound for comparison with a time column. SQLite
+// keeps times as text in the zone they were written in, which is local, so
+// a bound in any other zone (client RFC 3339 input, UTC feed cursors) would
+// compare wrongly as text.
+func ColumnTime(t time.Time) time.Time {
+	return t.Local()
+}
+
+// CreateChatMessage records a chat line
+func CreateChatMessage(serverID *uint, guid, name, message string, team bool) error {
+	return database.DB.Create(&ChatMessage{
+		ServerID:   serverID,
+		PlayerGUID: guid,
+		PlayerName: name,
+		Message:    message,
+		Team:       team,
+	}).Error
+}
+
+// GetChatMessages returns a page of chat matching the filter, newest first,
+// with the total number of matches
+func GetChatMessages(filter ChatFilter, limit, offset int) ([]ChatMessage, int64, error) {
+	var messages []ChatMessage
+	var total int64
+
+	query := filteredChat(filter)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&messages).Error
+	return messages, total, err
+}
+
+// GetChatMessagesForExport returns up to limit messages matching the filter
+// in the order they were said
+func GetChatMessagesForExport(filter ChatFilter, limit int) ([]ChatMessage, error) {
+	var messages []ChatMessage
+	err := filteredChat(filter).Order("created_at ASC, id ASC").Limit(limit).Find(&messages).Error
+	return messages, err
+}
+
+// DeleteChatMessagesBefore removes chat older than cutoff and returns how
+// many lines were removed
+func DeleteChatMessagesBefore(cutoff time.Time) (int64, error) {
+	result := database.DB.Where("created_at < ?", cutoff).Delete(&ChatMessage{})
+	return result.RowsAffected, result.Error
+}
+
+func filteredChat(filter ChatFilter) *gorm.DB {
+	query := database.DB.Model(&ChatMessage{})
+
+	if filter.Search != "" {
+		if match := chatMatchQuery(filter.Search); match != "" && database.DB.Migrator().HasTable(ChatSearchTable) {
+			query = query.Where("id IN (SELECT docid FROM "+ChatSearchTable+" WHERE "+ChatSearchTable+" MATCH ?)", match)
+		} else {
+			query = query.Where("LOWER(message) LIKE ?", "%"+strings.ToLower(filter.Search)+"%")
+		}
+	}
+	if filter.PlayerGUID != "" {
+		query = query.Where("player_guid = ?", filter.PlayerGUID)
+	}
+	if filter.PlayerName != "" {
+		query = query.Where("LOWER(player_name) LIKE ?", "%"+strings.ToLower(filter.PlayerName)+"%")
+	}
+	if filter.ServerID != nil {
+		query = query.Where("server_id = ?", *filter.ServerID)
+	}
+	if filter.Since != nil {
+		query = query.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("created_at <= ?", *filter.Until)
+	}
+	return query
+}
+
+// chatMatchQuery turns free text into an FTS MATCH expression of prefix
+// terms. Punctuation is dropped and words lowercased so user input can't
+// form FTS syntax or operators.
+func chatMatchQuery(search string) string {
+	words := strings.FieldsFunc(strings.ToLower(search), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = word + "*"
+	}
+	return strings.Join(words, " ")
+}
@@ -28,6 +28,8 @@ type InGamePlayer struct {
 	ServerID  *uint     `gorm:"index" json:"serverId,omitempty"` // Server where player was seen
 	Server    *Server   `gorm:"foreignKey:ServerID;constraint:OnDelete:CASCADE" json:"server,omitempty"`
 	Enabled   bool      `gorm:"default:true" json:"enabled"` // Can be disabled/banned
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `gorm:"index" json:"lastSeen"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
@@ -63,6 +65,16 @@ func GetGroupByID(id uint) (*Group, error) {
 	return &group, nil
 }
 
+// GetGroupByName gets a group by name, ignoring case
+func GetGroupByName(name string) (*Group, error) {
+	var group Group
+	err := database.DB.Where("LOWER(name) = LOWER(?)", name).First(&group).Error
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
 // UpdateGroup updates a group
 func UpdateGroup(id uint, updates map[string]interface{}) error {
 	db := database.DB
@@ -83,16 +95,31 @@ func DeleteGroup(id uint) error {
 
 // CreateOrUpdateInGamePlayer creates or updates an in-game player by GUID
 func CreateOrUpdateInGamePlayer(guid, name string) (*InGamePlayer, error) {
+	return CreateOrUpdateInGamePlayerOnServer(guid, name, nil)
+}
+
+// CreateOrUpdateInGamePlayerOnServer creates or updates an in-game player and,
+// if serverID is set, records it as the server the player was last seen on.
+// Either way the player's last seen time is bumped and the name is added to
+// their alias history. The alias counts as a new sighting only for a new
+// player, a name change or a player without an open session on the server,
+// since this runs on every status poll.
+func CreateOrUpdateInGamePlayerOnServer(guid, name string, serverID *uint) (*InGamePlayer, error) {
 	db := database.DB
 	var player InGamePlayer
+	now := time.Now()
+	sighting := true
 
 	err := db.Where("guid = ?", guid).First(&player).Error
 	if err == gorm.ErrRecordNotFound {
 		// Create new player
 		player = InGamePlayer{
-			GUID:    guid,
-			Name:    name,
-			Enabled: true,
+			GUID:      guid,
+			Name:      name,
+			Enabled:   true,
+			ServerID:  serverID,
+			FirstSeen: now,
+			LastSeen:  now,
 		}
 		if err := db.Create(&player).Error; err != nil {
 			return nil, err
@@ -100,12 +127,25 @@ func CreateOrUpdateInGamePlayer(guid, name string) (*InGamePlayer, error) {
 	} else if err != nil {
 		return nil, err
 	} else {
+		sighting = NormalizeAliasName(player.Name) != NormalizeAliasName(name) ||
+			(serverID != nil && !hasOpenPlayerSession(db, guid, serverID))
+
+		updates := map[string]interface{}{"last_seen": now}
 		// Update name if changed
 		if player.Name != name {
-			db.Model(&player).Update("name", name)
+			updates["name"] = name
 		}
+		if serverID != nil && (player.ServerID == nil || *player.ServerID != *serverID) {
+			updates["server_id"] = *serverID
+		}
+		if player.FirstSeen.IsZero() {
+			updates["first_seen"] = player.CreatedAt
+		}
+		db.Model(&player).Updates(updates)
 	}
 
+	RecordPlayerAlias(guid, name, sighting)
+
 	// Load group if assigned
 	if player.GroupID != nil {
 		db.Preload("Group").First(&player, player.ID)
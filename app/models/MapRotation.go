@@ -0,0 +1,194 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/database"
+	"gorm.io/gorm"
+)
+
+// maxRotationLength is the longest sv_mapRotation the games accept
+// (MAX_STRING_CHARS less room for the command)
+const maxRotationLength = 1000
+
+// MapRotationEntry is one map in a rotation. An empty gametype keeps the
+// previous entry's.
+type MapRotationEntry struct {
+	Map      string `json:"map"`
+	Gametype string `json:"gametype,omitempty"`
+}
+
+// MapRotation is a named, ordered list of maps for one server. Rotations
+// with AutoSwitch are pushed automatically while the player count is
+// between MinPlayers and MaxPlayers.
+type MapRotation struct {
+	ID            uint               `gorm:"primaryKey" json:"id"`
+	ServerID      uint               `gorm:"not null;uniqueIndex:idx_map_rotation_server_name" json:"serverId"`
+	Name          string             `gorm:"not null;uniqueIndex:idx_map_rotation_server_name" json:"name"`
+	Entries       []MapRotationEntry `gorm:"serializer:json" json:"entries"`
+	AutoSwitch    bool               `json:"autoSwitch"`
+	MinPlayers    int                `json:"minPlayers"`
+	MaxPlayers    int                `json:"maxPlayers"` // 0 for no upper limit
+	Active        bool               `json:"active"`     // Last pushed to the server
+	LastPushedAt  *time.Time         `json:"lastPushedAt"`
+	CreatedByName string             `json:"createdByName"`
+	CreatedAt     time.Time          `json:"createdAt"`
+	UpdatedAt     time.Time          `json:"updatedAt"`
+}
+
+// CustomMap is a map installed on a server on top of the game's stock maps
+type CustomMap struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ServerID  uint      `gorm:"not null;uniqueIndex:idx_custom_map_server_name" json:"serverId"`
+	Name      string    `gorm:"not null;uniqueIndex:idx_custom_map_server_name" json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Validate checks a rotation's fields. Whether its maps and gametypes exist
+// on the server is checked against the game by the maprotation package.
+func (r *MapRotation) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(r.Entries) == 0 {
+		return fmt.Errorf("at least one map is required")
+	}
+	if r.MinPlayers < 0 || r.MaxPlayers < 0 {
+		return fmt.Errorf("player limits cannot be negative")
+	}
+	if r.MaxPlayers > 0 && r.MaxPlayers < r.MinPlayers {
+		return fmt.Errorf("max players must be at least min players")
+	}
+	if length := len(r.String()); length > maxRotationLength {
+		return fmt.Errorf("rotation is %d characters, the limit is %d", length, maxRotationLength)
+	}
+	return nil
+}
+
+// String formats the rotation as an sv_mapRotation value, e.g.
+// "gametype war map mp_crash map mp_strike gametype sd map mp_vacant"
+func (r *MapRotation) String() string {
+	var parts []string
+	var gametype string
+	for _, entry := range r.Entries {
+		if entry.Gametype != "" && !strings.EqualFold(entry.Gametype, gametype) {
+			gametype = entry.Gametype
+			parts = append(parts, "gametype", gametype)
+		}
+		parts = append(parts, "map", entry.Map)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Fits reports whether a player count is within the rotation's limits
+func (r *MapRotation) Fits(players int) bool {
+	return players >= r.MinPlayers && (r.MaxPlayers == 0 || players <= r.MaxPlayers)
+}
+
+// GetMapRotations lists a server's rotations by name, or every server's
+// when serverID is nil
+func GetMapRotations(serverID *uint) ([]MapRotation, error) {
+	var rotations []MapRotation
+	query := database.DB.Order("server_id ASC, name ASC")
+	if serverID != nil {
+		query = query.Where("server_id = ?", *serverID)
+	}
+	err := query.Find(&rotations).Error
+	return rotations, err
+}
+
+// GetAutoSwitchRotations lists the rotations a server switches between by
+// player count, lowest limits first
+func GetAutoSwitchRotations(serverID uint) ([]MapRotation, error) {
+	var rotations []MapRotation
+	err := database.DB.Where("server_id = ? AND auto_switch = ?", serverID, true).
+		Order("min_players ASC, id ASC").Find(&rotations).Error
+	return rotations, err
+}
+
+// GetMapRotation gets a rotation by ID
+func GetMapRotation(id uint) (*MapRotation, error) {
+	var rotation MapRotation
+	if err := database.DB.First(&rotation, id).Error; err != nil {
+		return nil, err
+	}
+	return &rotation, nil
+}
+
+// GetActiveMapRotation gets the rotation last pushed to a server, or nil
+// when none has been
+func GetActiveMapRotation(serverID uint) (*MapRotation, error) {
+	var rotations []MapRotation
+	if err := database.DB.Where("server_id = ? AND active = ?", serverID, true).Limit(1).Find(&rotations).Error; err != nil {
+		return nil, err
+	}
+	if len(rotations) == 0 {
+		return nil, nil
+	}
+	return &rotations[0], nil
+}
+
+// SaveMapRotation creates or updates a rotation
+func SaveMapRotation(rotation *MapRotation) error {
+	if err := rotation.Validate(); err != nil {
+		return err
+	}
+	return database.DB.Save(rotation).Error
+}
+
+// DeleteMapRotation removes a rotation
+func DeleteMapRotation(id uint) error {
+	return database.DB.Delete(&MapRotation{}, id).Error
+}
+
+// SetActiveMapRotation marks a rotation as the one last pushed to its
+// server
+func SetActiveMapRotation(rotation *MapRotation) error {
+	now := time.Now()
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&MapRotation{}).Where("server_id = ? AND id <> ?", rotation.ServerID, rotation.ID).
+			Update("active", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&MapRotation{}).Where("id = ?", rotation.ID).
+			Updates(map[string]interface{}{"active": true, "last_pushed_at": now}).Error
+	})
+	if err != nil {
+		return err
+	}
+	rotation.Active = true
+	rotation.LastPushedAt = &now
+	return nil
+}
+
+// GetCustomMaps lists the custom maps installed on a server
+func GetCustomMaps(serverID uint) ([]CustomMap, error) {
+	var maps []CustomMap
+	err := database.DB.Where("server_id = ?", serverID).Order("name ASC").Find(&maps).Error
+	return maps, err
+}
+
+// GetCustomMap gets a custom map by ID
+func GetCustomMap(id uint) (*CustomMap, error) {
+	var custom CustomMap
+	if err := database.DB.First(&custom, id).Error; err != nil {
+		return nil, err
+	}
+	return &custom, nil
+}
+
+// CreateCustomMap adds a custom map to a server
+func CreateCustomMap(serverID uint, name string) (*CustomMap, error) {
+	custom := &CustomMap{ServerID: serverID, Name: strings.ToLower(strings.TrimSpace(name))}
+	if err := database.DB.Create(custom).Error; err != nil {
+		return nil, err
+	}
+	return custom, nil
+}
+
+// DeleteCustomMap removes a custom map
+func DeleteCustomMap(id uint) error {
+	return database.DB.Delete(&CustomMap{}, id).Error
+}
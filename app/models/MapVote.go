@@ -0,0 +1,94 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// mapVoteSettingKey is the setting map vote settings are stored under
+const mapVoteSettingKey = "map_vote"
+
+// MapVoteSettings configures in-game map voting (!votemap and !rtv). They
+// are shared by every server.
+type MapVoteSettings struct {
+	DurationSeconds int      `json:"durationSeconds"` // How long a vote stays open
+	VoteQuorum      int      `json:"voteQuorum"`      // Percent of players who must vote for the result to count
+	RTVQuorum       int      `json:"rtvQuorum"`       // Percent of players who must !rtv to start a vote
+	CooldownMinutes int      `json:"cooldownMinutes"` // Time after a vote before the next can start
+	MaxCandidates   int      `json:"maxCandidates"`   // Maps on one ballot
+	Blacklist       []string `json:"blacklist"`       // Maps that can't be voted for
+}
+
+// DefaultMapVoteSettings are used until settings are saved
+func DefaultMapVoteSettings() MapVoteSettings {
+	return MapVoteSettings{
+		DurationSeconds: 60,
+		VoteQuorum:      30,
+		RTVQuorum:       60,
+		CooldownMinutes: 10,
+		MaxCandidates:   5,
+		Blacklist:       []string{},
+	}
+}
+
+// Validate checks the settings and lowercases the blacklist
+func (s *MapVoteSettings) Validate() error {
+	if s.DurationSeconds < 10 || s.DurationSeconds > 600 {
+		return fmt.Errorf("duration must be between 10 and 600 seconds")
+	}
+	if s.VoteQuorum < 0 || s.VoteQuorum > 100 || s.RTVQuorum < 0 || s.RTVQuorum > 100 {
+		return fmt.Errorf("quorums are percentages between 0 and 100")
+	}
+	if s.CooldownMinutes < 0 {
+		return fmt.Errorf("cooldown cannot be negative")
+	}
+	if s.MaxCandidates < 2 || s.MaxCandidates > 9 {
+		return fmt.Errorf("a ballot holds between 2 and 9 maps")
+	}
+
+	blacklist := []string{}
+	for _, m := range s.Blacklist {
+		if m = strings.ToLower(strings.TrimSpace(m)); m != "" {
+			blacklist = append(blacklist, m)
+		}
+	}
+	s.Blacklist = blacklist
+	return nil
+}
+
+// IsBlacklisted reports whether a map can't be voted for
+func (s *MapVoteSettings) IsBlacklisted(mapName string) bool {
+	for _, m := range s.Blacklist {
+		if strings.EqualFold(m, mapName) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetMapVoteSettings reads the map vote settings, falling back to the
+// defaults when none are saved
+func GetMapVoteSettings() MapVoteSettings {
+	settings := DefaultMapVoteSettings()
+	setting, err := GetSetting(mapVoteSettingKey)
+	if err != nil {
+		return settings
+	}
+	if err := json.Unmarshal([]byte(setting.Value), &settings); err != nil {
+		return DefaultMapVoteSettings()
+	}
+	return settings
+}
+
+// SaveMapVoteSettings validates and stores the map vote settings
+func SaveMapVoteSettings(settings *MapVoteSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	value, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	return SetSetting(mapVoteSettingKey, string(value))
+}
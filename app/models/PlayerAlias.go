@@ -0,0 +1,196 @@
+package models
+
+import (
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/database"
+	"github.com/ethanburkett/goadmin/app/parser"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PlayerAlias is a name a GUID has played under
+type PlayerAlias struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	GUID           string    `gorm:"not null;uniqueIndex:idx_player_alias" json:"guid"`
+	Name           string    `gorm:"not null;uniqueIndex:idx_player_alias" json:"name"`
+	NormalizedName string    `gorm:"not null;index" json:"-"` // Lowercase, color codes stripped; used for matching
+	FirstSeen      time.Time `json:"firstSeen"`
+	LastSeen       time.Time `json:"lastSeen"`
+	TimesSeen      int       `gorm:"default:1" json:"timesSeen"`
+}
+
+// PlayerIP is an address a GUID has connected from
+type PlayerIP struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	GUID      string    `gorm:"not null;uniqueIndex:idx_player_ip" json:"guid"`
+	IP        string    `gorm:"not null;uniqueIndex:idx_player_ip;index" json:"ip"`
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+	TimesSeen int       `gorm:"default:1" json:"timesSeen"`
+}
+
+// LinkedPlayer is another GUID that shares an IP or name with a player
+type LinkedPlayer struct {
+	GUID        string    `json:"guid"`
+	Name        string    `json:"name"` // Most recent alias
+	SharedIPs   []string  `json:"sharedIps"`
+	SharedNames []string  `json:"sharedNames"`
+	LastSeen    time.Time `json:"lastSeen"`
+}
+
+// commonNames are default names many unrelated players share, so they are
+// recorded but never used to link GUIDs
+var commonNames = map[string]bool{
+	"unknown soldier": true,
+	"unnamedplayer":   true,
+	"player":          true,
+	"cod4 player":     true,
+	"codplayer":       true,
+}
+
+// NormalizeAliasName strips color codes and case so "^1Bob" matches "bob"
+func NormalizeAliasName(name string) string {
+	return strings.ToLower(strings.TrimSpace(parser.StripColorCodes(name)))
+}
+
+// RecordPlayerAlias notes that a GUID used a name just now. TimesSeen is
+// only incremented for a new sighting; otherwise just LastSeen is bumped.
+func RecordPlayerAlias(guid, name string, sighting bool) error {
+	if guid == "" || strings.TrimSpace(name) == "" {
+		return nil
+	}
+	now := time.Now()
+	alias := &PlayerAlias{
+		GUID:           guid,
+		Name:           name,
+		NormalizedName: NormalizeAliasName(name),
+		FirstSeen:      now,
+		LastSeen:       now,
+		TimesSeen:      1,
+	}
+	updates := map[string]interface{}{"last_seen": now}
+	if sighting {
+		updates["times_seen"] = gorm.Expr("times_seen + 1")
+	}
+	return database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "guid"}, {Name: "name"}},
+		DoUpdates: clause.Assignments(updates),
+	}).Create(alias).Error
+}
+
+// RecordPlayerIP notes that a GUID is connected from an address just now.
+// Anything that isn't a routable IP (bots, loopback) is ignored. As with
+// aliases, TimesSeen is only incremented for a new session or a changed
+// address; repeated polls of the same session just bump LastSeen.
+func RecordPlayerIP(guid, ip string, serverID *uint) error {
+	parsed := net.ParseIP(ip)
+	if guid == "" || parsed == nil || parsed.IsLoopback() || parsed.IsUnspecified() {
+		return nil
+	}
+	db := database.DB
+	now := time.Now()
+	record := &PlayerIP{
+		GUID:      guid,
+		IP:        parsed.String(),
+		FirstSeen: now,
+		LastSeen:  now,
+		TimesSeen: 1,
+	}
+
+	sighting := true
+	var session PlayerSession
+	if openSessionQuery(db, serverID).Where("guid = ?", guid).First(&session).Error == nil {
+		sessionIP := net.ParseIP(session.IP)
+		sighting = sessionIP == nil || !sessionIP.Equal(parsed)
+	}
+
+	updates := map[string]interface{}{"last_seen": now}
+	if sighting {
+		updates["times_seen"] = gorm.Expr("times_seen + 1")
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "guid"}, {Name: "ip"}},
+		DoUpdates: clause.Assignments(updates),
+	}).Create(record).Error
+}
+
+// GetPlayerAliases returns every name a GUID has used, most recent first
+func GetPlayerAliases(guid string) ([]PlayerAlias, error) {
+	var aliases []PlayerAlias
+	err := database.DB.Where("guid = ?", guid).Order("last_seen DESC").Find(&aliases).Error
+	return aliases, err
+}
+
+// GetPlayerIPs returns every address a GUID has connected from, most recent first
+func GetPlayerIPs(guid string) ([]PlayerIP, error) {
+	var ips []PlayerIP
+	err := database.DB.Where("guid = ?", guid).Order("last_seen DESC").Find(&ips).Error
+	return ips, err
+}
+
+// FindLinkedPlayers returns other GUIDs that have used one of the player's
+// IPs or names, most recently seen first
+func FindLinkedPlayers(guid string) ([]LinkedPlayer, error) {
+	db := database.DB
+	linked := make(map[string]*LinkedPlayer)
+	get := func(other string) *LinkedPlayer {
+		lp, ok := linked[other]
+		if !ok {
+			lp = &LinkedPlayer{GUID: other, SharedIPs: []string{}, SharedNames: []string{}}
+			linked[other] = lp
+		}
+		return lp
+	}
+
+	var ipMatches []PlayerIP
+	err := db.Where("guid <> ? AND ip IN (?)", guid,
+		db.Model(&PlayerIP{}).Select("ip").Where("guid = ?", guid)).
+		Find(&ipMatches).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range ipMatches {
+		lp := get(m.GUID)
+		lp.SharedIPs = append(lp.SharedIPs, m.IP)
+	}
+
+	var names []string
+	if err := db.Model(&PlayerAlias{}).Where("guid = ?", guid).Distinct().Pluck("normalized_name", &names).Error; err != nil {
+		return nil, err
+	}
+	var matchable []string
+	for _, name := range names {
+		if name != "" && !commonNames[name] {
+			matchable = append(matchable, name)
+		}
+	}
+	if len(matchable) > 0 {
+		var nameMatches []PlayerAlias
+		if err := db.Where("guid <> ? AND normalized_name IN ?", guid, matchable).Find(&nameMatches).Error; err != nil {
+			return nil, err
+		}
+		for _, m := range nameMatches {
+			lp := get(m.GUID)
+			lp.SharedNames = append(lp.SharedNames, m.Name)
+		}
+	}
+
+	result := make([]LinkedPlayer, 0, len(linked))
+	for other, lp := range linked {
+		var latest PlayerAlias
+		if err := db.Where("guid = ?", other).Order("last_seen DESC").First(&latest).Error; err == nil {
+			lp.Name = latest.Name
+			lp.LastSeen = latest.LastSeen
+		}
+		result = append(result, *lp)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LastSeen.After(result[j].LastSeen)
+	})
+	return result, nil
+}
@@ -0,0 +1,323 @@
+package models
+
+import (
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/database"
+	"gorm.io/gorm"
+)
+
+// Reasons a session was closed
+const (
+	SessionEndQuit          = "quit"           // Q line in the log
+	SessionEndMissing       = "missing"        // Player no longer listed in status
+	SessionEndStale         = "stale"          // Not seen for too long, e.g. GoAdmin was down
+	SessionEndServerOffline = "server_offline" // Server stopped answering RCON
+)
+
+// PlayerSession is one continuous stay of a player on a server. Sessions are
+// opened by join lines or status polls and closed by quit lines, or by the
+// status poll when a player disappears without one.
+type PlayerSession struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	GUID            string     `gorm:"not null;index" json:"guid"`
+	ServerID        *uint      `gorm:"index" json:"serverId,omitempty"`
+	Server          *Server    `gorm:"foreignKey:ServerID;constraint:OnDelete:CASCADE" json:"server,omitempty"`
+	Name            string     `json:"name"` // Name used during the session
+	IP              string     `json:"ip"`
+	Slot            int        `json:"slot"`
+	ConnectedAt     time.Time  `gorm:"not null;index" json:"connectedAt"`
+	LastSeenAt      time.Time  `gorm:"not null" json:"lastSeenAt"`
+	DisconnectedAt  *time.Time `gorm:"index" json:"disconnectedAt"` // Nil while the session is open
+	EndReason       string     `json:"endReason,omitempty"`
+	DurationSeconds int        `json:"durationSeconds"` // Set when the session closes
+	PingSamples     int        `json:"pingSamples"`
+	AvgPing         float64    `json:"avgPing"`
+	MinPing         int        `json:"minPing"`
+	MaxPing         int        `json:"maxPing"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+// SessionSample is one player as seen in a status poll
+type SessionSample struct {
+	GUID string
+	Name string
+	IP   string
+	Slot int
+	Ping int
+}
+
+// PlaytimeSummary is a player's session totals
+type PlaytimeSummary struct {
+	GUID         string           `json:"guid"`
+	TotalSeconds int              `json:"totalSeconds"`
+	Sessions     int              `json:"sessions"`
+	FirstSeen    *time.Time       `json:"firstSeen"`
+	LastSeen     *time.Time       `json:"lastSeen"`
+	Online       bool             `json:"online"`
+	Servers      []ServerPlaytime `json:"servers"`
+}
+
+// ServerPlaytime is a player's session totals on one server
+type ServerPlaytime struct {
+	ServerID     *uint `json:"serverId"`
+	TotalSeconds int   `json:"totalSeconds"`
+	Sessions     int   `json:"sessions"`
+}
+
+// addPing folds a ping sample into the session's ping stats
+func (s *PlayerSession) addPing(ping int) {
+	if s.PingSamples == 0 || ping < s.MinPing {
+		s.MinPing = ping
+	}
+	if ping > s.MaxPing {
+		s.MaxPing = ping
+	}
+	s.AvgPing = (s.AvgPing*float64(s.PingSamples) + float64(ping)) / float64(s.PingSamples+1)
+	s.PingSamples++
+}
+
+// close ends the session at the given time
+func (s *PlayerSession) close(at time.Time, reason string) {
+	if at.Before(s.ConnectedAt) {
+		at = s.ConnectedAt
+	}
+	s.DisconnectedAt = &at
+	s.EndReason = reason
+	s.DurationSeconds = int(at.Sub(s.ConnectedAt).Seconds())
+}
+
+// Duration returns how long the session lasted, or has lasted so far if open
+func (s *PlayerSession) Duration() time.Duration {
+	if s.DisconnectedAt != nil {
+		return time.Duration(s.DurationSeconds) * time.Second
+	}
+	return time.Since(s.ConnectedAt)
+}
+
+func openSessionQuery(db *gorm.DB, serverID *uint) *gorm.DB {
+	query := db.Where("disconnected_at IS NULL")
+	if serverID != nil {
+		return query.Where("server_id = ?", *serverID)
+	}
+	return query.Where("server_id IS NULL")
+}
+
+// hasOpenPlayerSession reports whether a player has an open session on a server
+func hasOpenPlayerSession(db *gorm.DB, guid string, serverID *uint) bool {
+	var count int64
+	openSessionQuery(db.Model(&PlayerSession{}), serverID).Where("guid = ?", guid).Count(&count)
+	return count > 0
+}
+
+// OpenPlayerSession starts a session for a player, or refreshes the open one
+// if they are already connected (players rejoin on every map change). An
+// open session not seen for staleAfter is closed and a new one started, as
+// in ReconcilePlayerSessions.
+func OpenPlayerSession(guid, name string, slot int, serverID *uint, staleAfter time.Duration) (*PlayerSession, error) {
+	db := database.DB
+	now := time.Now()
+
+	var session PlayerSession
+	err := openSessionQuery(db, serverID).Where("guid = ?", guid).First(&session).Error
+	if err == nil && now.Sub(session.LastSeenAt) > staleAfter {
+		session.close(session.LastSeenAt, SessionEndStale)
+		if err := db.Save(&session).Error; err != nil {
+			return nil, err
+		}
+		err = gorm.ErrRecordNotFound
+	}
+	if err == nil {
+		session.Name = name
+		session.Slot = slot
+		session.LastSeenAt = now
+		return &session, db.Save(&session).Error
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	session = PlayerSession{
+		GUID:        guid,
+		ServerID:    serverID,
+		Name:        name,
+		Slot:        slot,
+		ConnectedAt: now,
+		LastSeenAt:  now,
+	}
+	return &session, db.Create(&session).Error
+}
+
+// ClosePlayerSession ends a player's open session on a server
+func ClosePlayerSession(guid string, serverID *uint, reason string) error {
+	db := database.DB
+
+	var sessions []PlayerSession
+	if err := openSessionQuery(db, serverID).Where("guid = ?", guid).Find(&sessions).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range sessions {
+		sessions[i].close(now, reason)
+		if err := db.Save(&sessions[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CloseOpenPlayerSessions ends every open session on a server at the time
+// each player was last seen
+func CloseOpenPlayerSessions(serverID *uint, reason string) error {
+	db := database.DB
+
+	var sessions []PlayerSession
+	if err := openSessionQuery(db, serverID).Find(&sessions).Error; err != nil {
+		return err
+	}
+
+	for i := range sessions {
+		sessions[i].close(sessions[i].LastSeenAt, reason)
+		if err := db.Save(&sessions[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReconcilePlayerSessions brings a server's open sessions in line with a
+// status poll. Players in the poll get their session refreshed (or opened if
+// the join line was missed); open sessions for anyone else are closed at the
+// time they were last seen. Sessions not seen for staleAfter are closed and
+// reopened, so downtime of the panel isn't counted as playtime.
+func ReconcilePlayerSessions(serverID *uint, players []SessionSample, staleAfter time.Duration) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		var open []PlayerSession
+		if err := openSessionQuery(tx, serverID).Find(&open).Error; err != nil {
+			return err
+		}
+
+		byGUID := make(map[string]*PlayerSession, len(open))
+		for i := range open {
+			byGUID[open[i].GUID] = &open[i]
+		}
+
+		online := make(map[string]bool, len(players))
+		for _, p := range players {
+			online[p.GUID] = true
+		}
+
+		for guid, session := range byGUID {
+			switch {
+			case now.Sub(session.LastSeenAt) > staleAfter:
+				session.close(session.LastSeenAt, SessionEndStale)
+			case !online[guid]:
+				session.close(session.LastSeenAt, SessionEndMissing)
+			default:
+				continue
+			}
+			if err := tx.Save(session).Error; err != nil {
+				return err
+			}
+			delete(byGUID, guid)
+		}
+
+		for _, p := range players {
+			session, ok := byGUID[p.GUID]
+			if !ok {
+				session = &PlayerSession{
+					GUID:        p.GUID,
+					ServerID:    serverID,
+					ConnectedAt: now,
+				}
+			}
+			session.Name = p.Name
+			if p.IP != "" {
+				session.IP = p.IP
+			}
+			session.Slot = p.Slot
+			session.LastSeenAt = now
+			session.addPing(p.Ping)
+
+			if err := tx.Save(session).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetPlayerSessions returns a player's sessions, newest first, optionally
+// filtered by server
+func GetPlayerSessions(guid string, serverID *uint, limit, offset int) ([]PlayerSession, int64, error) {
+	db := database.DB
+	var sessions []PlayerSession
+	var total int64
+
+	query := db.Model(&PlayerSession{}).Where("guid = ?", guid)
+	if serverID != nil {
+		query = query.Where("server_id = ?", *serverID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Server").
+		Order("connected_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&sessions).Error
+	return sessions, total, err
+}
+
+// GetPlayerPlaytime totals a player's sessions. Open sessions count up to now.
+func GetPlayerPlaytime(guid string) (*PlaytimeSummary, error) {
+	db := database.DB
+	var sessions []PlayerSession
+	if err := db.Where("guid = ?", guid).Order("connected_at ASC").Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	summary := &PlaytimeSummary{GUID: guid, Servers: []ServerPlaytime{}}
+	byServer := make(map[uint]int) // server ID -> index in Servers, 0 for no server
+
+	for i := range sessions {
+		s := &sessions[i]
+		seconds := int(s.Duration().Seconds())
+
+		summary.TotalSeconds += seconds
+		summary.Sessions++
+		if summary.FirstSeen == nil {
+			summary.FirstSeen = &s.ConnectedAt
+		}
+		lastSeen := s.LastSeenAt
+		if s.DisconnectedAt == nil {
+			summary.Online = true
+		} else if s.DisconnectedAt.After(lastSeen) {
+			lastSeen = *s.DisconnectedAt
+		}
+		if summary.LastSeen == nil || lastSeen.After(*summary.LastSeen) {
+			summary.LastSeen = &lastSeen
+		}
+
+		key := uint(0)
+		if s.ServerID != nil {
+			key = *s.ServerID
+		}
+		idx, ok := byServer[key]
+		if !ok {
+			idx = len(summary.Servers)
+			byServer[key] = idx
+			summary.Servers = append(summary.Servers, ServerPlaytime{ServerID: s.ServerID})
+		}
+		summary.Servers[idx].TotalSeconds += seconds
+		summary.Servers[idx].Sessions++
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,409 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// statDayFormat is how PlayerDailyStat.Day is stored, so days sort as strings
+const statDayFormat = "2006-01-02"
+
+// Kill is one kill line from the game log. Per-map and per-weapon stats are
+// aggregated from these rows.
+type Kill struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	ServerID     *uint     `gorm:"index" json:"serverId,omitempty"`
+	Server       *Server   `gorm:"foreignKey:ServerID;constraint:OnDelete:CASCADE" json:"server,omitempty"`
+	MapName      string    `gorm:"index" json:"mapName"`
+	GameType     string    `json:"gameType"`
+	Weapon       string    `gorm:"index" json:"weapon"`
+	MeansOfDeath string    `json:"meansOfDeath"`
+	HitLocation  string    `json:"hitLocation"`
+	AttackerGUID string    `gorm:"index" json:"attackerGuid"` // Empty for world kills
+	AttackerName string    `json:"attackerName"`
+	AttackerTeam string    `json:"attackerTeam"`
+	VictimGUID   string    `gorm:"index;not null" json:"victimGuid"`
+	VictimName   string    `json:"victimName"`
+	VictimTeam   string    `json:"victimTeam"`
+	Headshot     bool      `json:"headshot"`
+	Suicide      bool      `json:"suicide"`
+	TeamKill     bool      `json:"teamKill"`
+	Timestamp    time.Time `gorm:"index;not null" json:"timestamp"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// PlayerDailyStat holds one player's totals on one server for one day.
+// Lifetime and date-range stats are sums over these rows.
+type PlayerDailyStat struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	GUID            string    `gorm:"not null;uniqueIndex:idx_player_daily_stat" json:"guid"`
+	ServerID        uint      `gorm:"not null;uniqueIndex:idx_player_daily_stat" json:"serverId"`
+	Day             string    `gorm:"size:10;not null;uniqueIndex:idx_player_daily_stat;index" json:"day"` // YYYY-MM-DD, server local time
+	Name            string    `json:"name"`                                                                // Last name seen that day
+	Kills           int       `gorm:"default:0" json:"kills"`
+	Deaths          int       `gorm:"default:0" json:"deaths"`
+	Headshots       int       `gorm:"default:0" json:"headshots"`
+	Suicides        int       `gorm:"default:0" json:"suicides"`
+	TeamKills       int       `gorm:"default:0" json:"teamKills"`
+	BestStreak      int       `gorm:"default:0" json:"bestStreak"`
+	PlaytimeSeconds int       `gorm:"default:0" json:"playtimeSeconds"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// PlayerStatSummary is a player's totals over a filter
+type PlayerStatSummary struct {
+	GUID            string  `json:"guid"`
+	Name            string  `json:"name"`
+	Kills           int     `json:"kills"`
+	Deaths          int     `json:"deaths"`
+	KDRatio         float64 `json:"kdRatio"`
+	Headshots       int     `json:"headshots"`
+	Suicides        int     `json:"suicides"`
+	TeamKills       int     `json:"teamKills"`
+	BestStreak      int     `json:"bestStreak"`
+	PlaytimeSeconds int     `json:"playtimeSeconds"`
+}
+
+// KillBreakdown is kill totals grouped by map or weapon
+type KillBreakdown struct {
+	Name      string  `json:"name"`
+	Kills     int     `json:"kills"`
+	Deaths    int     `json:"deaths"`
+	Headshots int     `json:"headshots"`
+	KDRatio   float64 `json:"kdRatio"`
+}
+
+// StatsFilter narrows stats queries. Nil fields are not filtered on.
+type StatsFilter struct {
+	ServerID *uint
+	Start    *time.Time
+	End      *time.Time
+}
+
+// Leaderboard sort orders
+const (
+	LeaderboardKills     = "kills"
+	LeaderboardKD        = "kd"
+	LeaderboardHeadshots = "headshots"
+	LeaderboardStreak    = "streak"
+	LeaderboardPlaytime  = "playtime"
+	LeaderboardDeaths    = "deaths"
+)
+
+var leaderboardOrder = map[string]string{
+	LeaderboardKills:     "kills DESC",
+	LeaderboardKD:        "kd_ratio DESC, kills DESC",
+	LeaderboardHeadshots: "headshots DESC",
+	LeaderboardStreak:    "best_streak DESC",
+	LeaderboardPlaytime:  "playtime_seconds DESC",
+	LeaderboardDeaths:    "deaths DESC",
+}
+
+// ValidLeaderboardSort reports whether sort is a known leaderboard order
+func ValidLeaderboardSort(sort string) bool {
+	_, ok := leaderboardOrder[sort]
+	return ok
+}
+
+const summaryColumns = `guid,
+	SUM(kills) AS kills,
+	SUM(deaths) AS deaths,
+	CASE WHEN SUM(deaths) = 0 THEN CAST(SUM(kills) AS REAL) ELSE CAST(SUM(kills) AS REAL) / SUM(deaths) END AS kd_ratio,
+	SUM(headshots) AS headshots,
+	SUM(suicides) AS suicides,
+	SUM(team_kills) AS team_kills,
+	MAX(best_streak) AS best_streak,
+	SUM(playtime_seconds) AS playtime_seconds`
+
+// RecordKill stores a kill and updates both players' daily totals.
+// attackerStreak is the attacker's kill streak including this kill.
+func RecordKill(kill *Kill, attackerStreak int) error {
+	if kill.Timestamp.IsZero() {
+		kill.Timestamp = time.Now()
+	}
+	day := kill.Timestamp.Format(statDayFormat)
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(kill).Error; err != nil {
+			return err
+		}
+		if kill.ServerID == nil {
+			return nil
+		}
+		serverID := *kill.ServerID
+
+		victim := &PlayerDailyStat{GUID: kill.VictimGUID, ServerID: serverID, Day: day, Name: kill.VictimName, Deaths: 1}
+		victimUpdates := map[string]interface{}{
+			"name":       kill.VictimName,
+			"deaths":     gorm.Expr("deaths + 1"),
+			"updated_at": time.Now(),
+		}
+		if kill.Suicide {
+			victim.Suicides = 1
+			victimUpdates["suicides"] = gorm.Expr("suicides + 1")
+		}
+		if err := upsertDailyStat(tx, victim, victimUpdates); err != nil {
+			return err
+		}
+
+		if kill.Suicide || kill.AttackerGUID == "" {
+			return nil
+		}
+
+		attacker := &PlayerDailyStat{GUID: kill.AttackerGUID, ServerID: serverID, Day: day, Name: kill.AttackerName}
+		attackerUpdates := map[string]interface{}{
+			"name":       kill.AttackerName,
+			"updated_at": time.Now(),
+		}
+		if kill.TeamKill {
+			// Team kills don't count towards kills, headshots or streaks
+			attacker.TeamKills = 1
+			attackerUpdates["team_kills"] = gorm.Expr("team_kills + 1")
+		} else {
+			attacker.Kills = 1
+			attacker.BestStreak = attackerStreak
+			attackerUpdates["kills"] = gorm.Expr("kills + 1")
+			attackerUpdates["best_streak"] = gorm.Expr("MAX(best_streak, ?)", attackerStreak)
+			if kill.Headshot {
+				attacker.Headshots = 1
+				attackerUpdates["headshots"] = gorm.Expr("headshots + 1")
+			}
+		}
+		return upsertDailyStat(tx, attacker, attackerUpdates)
+	})
+}
+
+// AddPlaytime adds seconds of playtime to a player's stats for today
+func AddPlaytime(guid, name string, serverID uint, seconds int) error {
+	if guid == "" || seconds <= 0 {
+		return nil
+	}
+	stat := &PlayerDailyStat{
+		GUID:            guid,
+		ServerID:        serverID,
+		Day:             time.Now().Format(statDayFormat),
+		Name:            name,
+		PlaytimeSeconds: seconds,
+	}
+	return upsertDailyStat(database.DB, stat, map[string]interface{}{
+		"name":             name,
+		"playtime_seconds": gorm.Expr("playtime_seconds + ?", seconds),
+		"updated_at":       time.Now(),
+	})
+}
+
+func upsertDailyStat(tx *gorm.DB, stat *PlayerDailyStat, updates map[string]interface{}) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "guid"}, {Name: "server_id"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(updates),
+	}).Create(stat).Error
+}
+
+// CountKillsSince returns the kills (excluding suicides) and deaths logged since a time
+func CountKillsSince(serverID *uint, since time.Time) (kills, deaths int, err error) {
+	var counts struct {
+		Kills  int
+		Deaths int
+	}
+	query := database.DB.Model(&Kill{}).
+		Select("COALESCE(SUM(CASE WHEN suicide THEN 0 ELSE 1 END), 0) AS kills, COUNT(*) AS deaths").
+		Where("timestamp >= ?", since)
+	if serverID != nil {
+		query = query.Where("server_id = ?", *serverID)
+	}
+	err = query.Scan(&counts).Error
+	return counts.Kills, counts.Deaths, err
+}
+
+// GetLeaderboard returns the top players for a sort order. Players with
+// fewer than minKills kills are left out, which keeps one lucky kill off
+// the top of the K/D board.
+func GetLeaderboard(filter StatsFilter, sort string, minKills, limit int) ([]PlayerStatSummary, error) {
+	order, ok := leaderboardOrder[sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown leaderboard sort: %s", sort)
+	}
+
+	var summaries []PlayerStatSummary
+	query := dailyStatQuery(filter).
+		Select(summaryColumns).
+		Group("guid").
+		Order(order).
+		Limit(limit)
+	if minKills > 0 {
+		query = query.Having("SUM(kills) >= ?", minKills)
+	}
+	if err := query.Scan(&summaries).Error; err != nil {
+		return nil, err
+	}
+
+	guids := make([]string, len(summaries))
+	for i, s := range summaries {
+		guids[i] = s.GUID
+	}
+	names, err := latestStatNames(guids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range summaries {
+		summaries[i].Name = names[summaries[i].GUID]
+	}
+	return summaries, nil
+}
+
+// GetPlayerStatSummary returns a player's totals. A player with no stats
+// gets a zero summary rather than an error.
+func GetPlayerStatSummary(guid string, filter StatsFilter) (*PlayerStatSummary, error) {
+	var summaries []PlayerStatSummary
+	err := dailyStatQuery(filter).
+		Select(summaryColumns).
+		Where("guid = ?", guid).
+		Group("guid").
+		Scan(&summaries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &PlayerStatSummary{GUID: guid}
+	if len(summaries) > 0 {
+		summary = &summaries[0]
+	}
+
+	names, err := latestStatNames([]string{guid})
+	if err != nil {
+		return nil, err
+	}
+	summary.Name = names[guid]
+	return summary, nil
+}
+
+// GetPlayerRank returns a player's 1-based position on the kills
+// leaderboard, or 0 if they have no kills
+func GetPlayerRank(guid string, filter StatsFilter) (int, error) {
+	summary, err := GetPlayerStatSummary(guid, filter)
+	if err != nil || summary.Kills == 0 {
+		return 0, err
+	}
+
+	var ahead int64
+	sub := dailyStatQuery(filter).
+		Select("guid").
+		Group("guid").
+		Having("SUM(kills) > ?", summary.Kills)
+	if err := database.DB.Table("(?) AS ranked", sub).Count(&ahead).Error; err != nil {
+		return 0, err
+	}
+	return int(ahead) + 1, nil
+}
+
+// GetMapBreakdown returns kills and deaths per map. With an empty guid it
+// covers all players.
+func GetMapBreakdown(guid string, filter StatsFilter) ([]KillBreakdown, error) {
+	return killBreakdown("map_name", guid, filter)
+}
+
+// GetWeaponBreakdown returns kills and deaths per weapon. With an empty
+// guid it covers all players, and deaths are kills taken by that weapon.
+func GetWeaponBreakdown(guid string, filter StatsFilter) ([]KillBreakdown, error) {
+	return killBreakdown("weapon", guid, filter)
+}
+
+func killBreakdown(column, guid string, filter StatsFilter) ([]KillBreakdown, error) {
+	var kills []KillBreakdown
+	kq := killQuery(filter).
+		Select(column+" AS name, COUNT(*) AS kills, SUM(CASE WHEN headshot THEN 1 ELSE 0 END) AS headshots").
+		Where("suicide = ? AND team_kill = ?", false, false).
+		Group(column).
+		Order("kills DESC")
+	if guid != "" {
+		kq = kq.Where("attacker_guid = ?", guid)
+	}
+	if err := kq.Scan(&kills).Error; err != nil {
+		return nil, err
+	}
+
+	var deaths []KillBreakdown
+	dq := killQuery(filter).
+		Select(column + " AS name, COUNT(*) AS deaths").
+		Group(column)
+	if guid != "" {
+		dq = dq.Where("victim_guid = ?", guid)
+	}
+	if err := dq.Scan(&deaths).Error; err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int, len(kills))
+	for i, k := range kills {
+		index[k.Name] = i
+	}
+	for _, d := range deaths {
+		if i, ok := index[d.Name]; ok {
+			kills[i].Deaths = d.Deaths
+		} else {
+			kills = append(kills, KillBreakdown{Name: d.Name, Deaths: d.Deaths})
+		}
+	}
+
+	for i := range kills {
+		kills[i].KDRatio = kdRatio(kills[i].Kills, kills[i].Deaths)
+	}
+	return kills, nil
+}
+
+func kdRatio(kills, deaths int) float64 {
+	if deaths == 0 {
+		return float64(kills)
+	}
+	return float64(kills) / float64(deaths)
+}
+
+func dailyStatQuery(filter StatsFilter) *gorm.DB {
+	query := database.DB.Model(&PlayerDailyStat{})
+	if filter.ServerID != nil {
+		query = query.Where("server_id = ?", *filter.ServerID)
+	}
+	if filter.Start != nil {
+		query = query.Where("day >= ?", filter.Start.Format(statDayFormat))
+	}
+	if filter.End != nil {
+		query = query.Where("day <= ?", filter.End.Format(statDayFormat))
+	}
+	return query
+}
+
+func killQuery(filter StatsFilter) *gorm.DB {
+	query := database.DB.Model(&Kill{})
+	if filter.ServerID != nil {
+		query = query.Where("server_id = ?", *filter.ServerID)
+	}
+	if filter.Start != nil {
+		query = query.Where("timestamp >= ?", *filter.Start)
+	}
+	if filter.End != nil {
+		query = query.Where("timestamp <= ?", *filter.End)
+	}
+	return query
+}
+
+// latestStatNames maps GUIDs to the name they most recently played under
+func latestStatNames(guids []string) (map[string]string, error) {
+	names := make(map[string]string, len(guids))
+	if len(guids) == 0 {
+		return names, nil
+	}
+
+	var rows []PlayerDailyStat
+	err := database.DB.Select("guid", "name").
+		Where("guid IN ? AND name <> ''", guids).
+		Order("day ASC, updated_at ASC").
+		Find(&rows).Error
+	for _, row := range rows {
+		names[row.GUID] = row.Name
+	}
+	return names, err
+}
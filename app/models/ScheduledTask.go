@@ -0,0 +1,203 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/database"
+)
+
+// What a scheduled task does when it runs
+const (
+	TaskActionRcon    = "rcon"    // Payload is a raw rcon command
+	TaskActionSay     = "say"     // Payload is a message for all players
+	TaskActionCommand = "command" // Payload is a custom command and its arguments, e.g. "announce Restart in 5"
+	TaskActionMap     = "map"     // Payload is a map name
+)
+
+// Outcomes of a task's last run
+const (
+	TaskStatusSuccess = "success"
+	TaskStatusFailed  = "failed"
+	TaskStatusPartial = "partial" // Failed on some servers
+)
+
+// taskRunsKept is how many runs of each task are kept for history
+const taskRunsKept = 100
+
+// ScheduledTask is an rcon action run on a cron schedule
+type ScheduledTask struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	Name          string     `gorm:"not null" json:"name"`
+	Cron          string     `gorm:"not null" json:"cron"`
+	ServerID      *uint      `gorm:"index" json:"serverId,omitempty"` // nil runs on every running server
+	Action        string     `gorm:"not null" json:"action"`
+	Payload       string     `gorm:"type:text;not null" json:"payload"`
+	Enabled       bool       `gorm:"index" json:"enabled"`
+	NextRunAt     *time.Time `gorm:"index" json:"nextRunAt"`
+	LastRunAt     *time.Time `json:"lastRunAt"`
+	LastStatus    string     `json:"lastStatus,omitempty"`
+	LastResult    string     `gorm:"type:text" json:"lastResult,omitempty"`
+	FailureCount  int        `json:"failureCount"` // Consecutive failed runs
+	CreatedByName string     `json:"createdByName"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// ScheduledTaskRun is the result of running a task on one server
+type ScheduledTaskRun struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	TaskID    uint      `gorm:"not null;index" json:"taskId"`
+	ServerID  *uint     `gorm:"index" json:"serverId,omitempty"`
+	Manual    bool      `json:"manual"` // Run from the panel rather than on schedule
+	Command   string    `gorm:"type:text" json:"command"`
+	Success   bool      `gorm:"index" json:"success"`
+	Output    string    `gorm:"type:text" json:"output,omitempty"`
+	Error     string    `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt time.Time `gorm:"index" json:"createdAt"`
+}
+
+// Validate checks a task's fields other than the cron expression, which
+// the scheduler parses
+func (t *ScheduledTask) Validate() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	switch t.Action {
+	case TaskActionRcon, TaskActionSay, TaskActionCommand, TaskActionMap:
+	default:
+		return fmt.Errorf("unknown action: %s", t.Action)
+	}
+	if strings.TrimSpace(t.Payload) == "" {
+		return fmt.Errorf("%s tasks need a payload", t.Action)
+	}
+	if t.Action == TaskActionMap && strings.ContainsAny(strings.TrimSpace(t.Payload), " \t") {
+		return fmt.Errorf("map tasks take a single map name")
+	}
+	return nil
+}
+
+// GetScheduledTasks lists the scheduled tasks by name
+func GetScheduledTasks() ([]ScheduledTask, error) {
+	var tasks []ScheduledTask
+	err := database.DB.Order("name ASC").Find(&tasks).Error
+	return tasks, err
+}
+
+// GetScheduledTask gets a scheduled task by ID
+func GetScheduledTask(id uint) (*ScheduledTask, error) {
+	var task ScheduledTask
+	if err := database.DB.First(&task, id).Error; err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// GetDueScheduledTasks lists the enabled tasks due to run at now
+func GetDueScheduledTasks(now time.Time) ([]ScheduledTask, error) {
+	var tasks []ScheduledTask
+	err := database.DB.Where("enabled = ? AND next_run_at IS NOT NULL AND next_run_at <= ?", true, now).
+		Order("next_run_at ASC").Find(&tasks).Error
+	return tasks, err
+}
+
+// GetUnscheduledTasks lists the enabled tasks with no next run, such as
+// tasks created before the scheduler last started
+func GetUnscheduledTasks() ([]ScheduledTask, error) {
+	var tasks []ScheduledTask
+	err := database.DB.Where("enabled = ? AND next_run_at IS NULL", true).Find(&tasks).Error
+	return tasks, err
+}
+
+// SaveScheduledTask creates or updates a task
+func SaveScheduledTask(task *ScheduledTask) error {
+	if err := task.Validate(); err != nil {
+		return err
+	}
+	return database.DB.Save(task).Error
+}
+
+// SetTaskNextRun records when a task runs next; nil leaves it unscheduled
+func SetTaskNextRun(id uint, next *time.Time) error {
+	return database.DB.Model(&ScheduledTask{}).Where("id = ?", id).Update("next_run_at", next).Error
+}
+
+// DeleteScheduledTask removes a task and its run history
+func DeleteScheduledTask(id uint) error {
+	if err := database.DB.Where("task_id = ?", id).Delete(&ScheduledTaskRun{}).Error; err != nil {
+		return err
+	}
+	return database.DB.Delete(&ScheduledTask{}, id).Error
+}
+
+// RecordTaskRuns stores the results of one run of a task across its servers,
+// updates the task's last-run summary and trims old history
+func RecordTaskRuns(task *ScheduledTask, runs []ScheduledTaskRun, ranAt time.Time) error {
+	failed := 0
+	var failures []string
+	for i := range runs {
+		runs[i].TaskID = task.ID
+		if !runs[i].Success {
+			failed++
+			failures = append(failures, runs[i].Error)
+		}
+	}
+
+	status := TaskStatusSuccess
+	result := fmt.Sprintf("Ran on %d server(s)", len(runs))
+	switch {
+	case len(runs) == 0:
+		status = TaskStatusFailed
+		result = "No servers to run on"
+	case failed == len(runs):
+		status = TaskStatusFailed
+		result = strings.Join(failures, "; ")
+	case failed > 0:
+		status = TaskStatusPartial
+		result = fmt.Sprintf("Failed on %d of %d server(s): %s", failed, len(runs), strings.Join(failures, "; "))
+	}
+
+	failureCount := 0
+	if status != TaskStatusSuccess {
+		failureCount = task.FailureCount + 1
+	}
+
+	if len(runs) > 0 {
+		if err := database.DB.Create(&runs).Error; err != nil {
+			return err
+		}
+	}
+
+	task.LastRunAt = &ranAt
+	task.LastStatus = status
+	task.LastResult = result
+	task.FailureCount = failureCount
+	err := database.DB.Model(&ScheduledTask{}).Where("id = ?", task.ID).Updates(map[string]interface{}{
+		"last_run_at":   ranAt,
+		"last_status":   status,
+		"last_result":   result,
+		"failure_count": failureCount,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	// Keep the newest runs only
+	return database.DB.Where("task_id = ? AND id NOT IN (?)", task.ID,
+		database.DB.Model(&ScheduledTaskRun{}).Select("id").Where("task_id = ?", task.ID).
+			Order("id DESC").Limit(taskRunsKept)).
+		Delete(&ScheduledTaskRun{}).Error
+}
+
+// GetTaskRuns lists a task's runs, newest first. failedOnly limits it to the
+// failure history.
+func GetTaskRuns(taskID uint, failedOnly bool, limit int) ([]ScheduledTaskRun, error) {
+	var runs []ScheduledTaskRun
+	query := database.DB.Where("task_id = ?", taskID)
+	if failedOnly {
+		query = query.Where("success = ?", false)
+	}
+	err := query.Order("id DESC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
@@ -16,6 +16,10 @@ type Server struct {
 	RconPort     int            `gorm:"not null" json:"rconPort"`         // RCON port
 	RconPassword string         `gorm:"not null" json:"-"`                // RCON password (excluded from JSON)
 	GamesMpPath  string         `json:"gamesMpPath"`                      // Path to games_mp.log file
+	Game         string         `gorm:"default:'cod4'" json:"game"`       // Game adapter name (e.g., "cod4", "cod2", "codwaw")
+	LogSource    string         `gorm:"default:'file'" json:"logSource"`  // Where log lines come from: file, udp, http or poll
+	LogAddress   string         `json:"logAddress"`                       // UDP listen address or polled file path
+	LogToken     string         `gorm:"index" json:"-"`                   // Token for pushing logs over HTTP (excluded from JSON)
 	IsActive     bool           `gorm:"default:true" json:"isActive"`     // Whether server is active
 	IsDefault    bool           `gorm:"default:false" json:"isDefault"`   // Default server for operations
 	Description  string         `json:"description"`                      // Server description
@@ -34,7 +38,7 @@ type Server struct {
 }
 
 // CreateServer creates a new server instance
-func CreateServer(name, host, rconPassword, gamesMpPath, description, region string, port, rconPort, maxPlayers int, isDefault bool) (*Server, error) {
+func CreateServer(name, host, rconPassword, gamesMpPath, game, logSource, logAddress, description, region string, port, rconPort, maxPlayers int, isDefault bool) (*Server, error) {
 	db := database.DB
 
 	// If this is being set as default, unset any existing default
@@ -49,6 +53,9 @@ func CreateServer(name, host, rconPassword, gamesMpPath, description, region str
 		RconPort:     rconPort,
 		RconPassword: rconPassword,
 		GamesMpPath:  gamesMpPath,
+		Game:         game,
+		LogSource:    logSource,
+		LogAddress:   logAddress,
 		IsActive:     true,
 		IsDefault:    isDefault,
 		Description:  description,
@@ -147,3 +154,19 @@ func (s *Server) Deactivate() error {
 func (Server) TableName() string {
 	return "servers"
 }
+
+// RotateLogToken generates a new token for pushing logs over HTTP,
+// invalidating the previous one
+func (s *Server) RotateLogToken() (string, error) {
+	token, err := generateToken()
+	if err != nil {
+		return "", err
+	}
+
+	db := database.DB
+	if err := db.Model(s).Update("log_token", token).Error; err != nil {
+		return "", err
+	}
+	s.LogToken = token
+	return token, nil
+}
@@ -26,6 +26,7 @@ type ServerStats struct {
 // SystemStats stores system metrics over time
 type SystemStats struct {
 	ID          uint      `gorm:"primaryKey" json:"id"`
+	ServerID    *uint     `gorm:"index" json:"serverId,omitempty"` // Server these stats belong to
 	Timestamp   time.Time `gorm:"index;not null" json:"timestamp"`
 	CPUUsage    float64   `json:"cpuUsage"`
 	MemoryUsed  int64     `json:"memoryUsed"`  // in bytes
@@ -36,6 +37,7 @@ type SystemStats struct {
 // PlayerStats stores individual player metrics over time
 type PlayerStats struct {
 	ID          uint      `gorm:"primaryKey" json:"id"`
+	ServerID    *uint     `gorm:"index" json:"serverId,omitempty"` // Server these stats belong to
 	Timestamp   time.Time `gorm:"index;not null" json:"timestamp"`
 	TotalKills  int       `json:"totalKills"`
 	TotalDeaths int       `json:"totalDeaths"`
@@ -45,9 +47,10 @@ type PlayerStats struct {
 }
 
 // CreateServerStats creates a new server stats entry
-func CreateServerStats(playerCount, maxPlayers int, mapName, gametype, hostname string, fps, uptime int) error {
+func CreateServerStats(playerCount, maxPlayers int, mapName, gametype, hostname string, fps, uptime int, serverID *uint) error {
 	db := database.DB
 	stat := &ServerStats{
+		ServerID:    serverID,
 		Timestamp:   time.Now(),
 		PlayerCount: playerCount,
 		MaxPlayers:  maxPlayers,
@@ -61,9 +64,10 @@ func CreateServerStats(playerCount, maxPlayers int, mapName, gametype, hostname
 }
 
 // CreateSystemStats creates a new system stats entry
-func CreateSystemStats(cpuUsage float64, memoryUsed, memoryTotal int64) error {
+func CreateSystemStats(cpuUsage float64, memoryUsed, memoryTotal int64, serverID *uint) error {
 	db := database.DB
 	stat := &SystemStats{
+		ServerID:    serverID,
 		Timestamp:   time.Now(),
 		CPUUsage:    cpuUsage,
 		MemoryUsed:  memoryUsed,
@@ -73,9 +77,10 @@ func CreateSystemStats(cpuUsage float64, memoryUsed, memoryTotal int64) error {
 }
 
 // CreatePlayerStats creates a new player stats entry
-func CreatePlayerStats(totalKills, totalDeaths int, avgPing, avgScore float64) error {
+func CreatePlayerStats(totalKills, totalDeaths int, avgPing, avgScore float64, serverID *uint) error {
 	db := database.DB
 	stat := &PlayerStats{
+		ServerID:    serverID,
 		Timestamp:   time.Now(),
 		TotalKills:  totalKills,
 		TotalDeaths: totalDeaths,
@@ -99,23 +104,31 @@ func GetServerStatsRange(start, end time.Time, serverID *uint) ([]ServerStats, e
 	return stats, err
 }
 
-// GetSystemStatsRange retrieves system stats within a time range
-func GetSystemStatsRange(start, end time.Time) ([]SystemStats, error) {
+// GetSystemStatsRange retrieves system stats within a time range, optionally filtered by server ID
+func GetSystemStatsRange(start, end time.Time, serverID *uint) ([]SystemStats, error) {
 	db := database.DB
 	var stats []SystemStats
-	err := db.Where("timestamp BETWEEN ? AND ?", start, end).
-		Order("timestamp ASC").
-		Find(&stats).Error
+	query := db.Where("timestamp BETWEEN ? AND ?", start, end)
+
+	if serverID != nil {
+		query = query.Where("server_id = ?", *serverID)
+	}
+
+	err := query.Order("timestamp ASC").Find(&stats).Error
 	return stats, err
 }
 
-// GetPlayerStatsRange retrieves player stats within a time range
-func GetPlayerStatsRange(start, end time.Time) ([]PlayerStats, error) {
+// GetPlayerStatsRange retrieves player stats within a time range, optionally filtered by server ID
+func GetPlayerStatsRange(start, end time.Time, serverID *uint) ([]PlayerStats, error) {
 	db := database.DB
 	var stats []PlayerStats
-	err := db.Where("timestamp BETWEEN ? AND ?", start, end).
-		Order("timestamp ASC").
-		Find(&stats).Error
+	query := db.Where("timestamp BETWEEN ? AND ?", start, end)
+
+	if serverID != nil {
+		query = query.Where("server_id = ?", *serverID)
+	}
+
+	err := query.Order("timestamp ASC").Find(&stats).Error
 	return stats, err
 }
 
@@ -0,0 +1,312 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/database"
+	"gorm.io/gorm"
+)
+
+// DefaultWarningExpiry is how long a warning counts towards escalation when
+// its preset doesn't say otherwise
+const DefaultWarningExpiry = 7 * 24 * time.Hour
+
+// What an escalation rule does once a player reaches its threshold
+const (
+	EscalationKick    = "kick"
+	EscalationTempBan = "tempban"
+	EscalationBan     = "ban"
+)
+
+var (
+	ErrWarningNotFound = errors.New("warning not found")
+	ErrPresetNotFound  = errors.New("warning preset not found")
+)
+
+// Warning is a recorded warning against a player. Warnings stop counting
+// once they expire or are cleared, but are kept for history.
+type Warning struct {
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	PlayerName     string         `json:"playerName"`
+	PlayerGUID     string         `gorm:"not null;index" json:"playerGuid"`
+	Reason         string         `gorm:"type:text;not null" json:"reason"`
+	PresetID       *uint          `gorm:"index" json:"presetId"`
+	Preset         *WarningPreset `gorm:"foreignKey:PresetID;constraint:OnDelete:SET NULL" json:"preset,omitempty"`
+	IssuedByUserID *uint          `gorm:"index" json:"issuedByUserId"` // Dashboard user, nil for in-game warnings
+	IssuedByName   string         `json:"issuedByName"`
+	ServerID       *uint          `gorm:"index" json:"serverId,omitempty"`
+	ExpiresAt      time.Time      `gorm:"not null;index" json:"expiresAt"`
+	Escalation     string         `json:"escalation,omitempty"` // What the warning triggered, e.g. "kick" or "tempban 1h"
+	Cleared        bool           `gorm:"default:false;index" json:"cleared"`
+	ClearedAt      *time.Time     `json:"clearedAt"`
+	ClearedByName  string         `json:"clearedByName,omitempty"`
+	CreatedAt      time.Time      `json:"createdAt"`
+	UpdatedAt      time.Time      `json:"updatedAt"`
+}
+
+// WarningPreset is a stock warning admins pick by key, e.g. "!warn bob lang"
+type WarningPreset struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Key         string    `gorm:"uniqueIndex;not null" json:"key"`
+	Reason      string    `gorm:"not null" json:"reason"`   // Recorded on the warning
+	Message     string    `gorm:"type:text" json:"message"` // Told to the player, defaults to the reason
+	ExpireHours int       `json:"expireHours"`              // 0 uses DefaultWarningExpiry
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// WarningRule is a step of the escalation ladder: reaching Threshold active
+// warnings within WindowHours triggers Action
+type WarningRule struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	Threshold       int       `gorm:"not null" json:"threshold"`
+	WindowHours     int       `gorm:"not null;default:24" json:"windowHours"`
+	Action          string    `gorm:"not null" json:"action"`   // kick, tempban or ban
+	DurationMinutes int       `json:"durationMinutes"`          // Tempban length
+	Message         string    `gorm:"type:text" json:"message"` // Kick or ban reason shown to the player
+	Enabled         bool      `json:"enabled"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// Validate checks a rule's fields
+func (r *WarningRule) Validate() error {
+	if r.Threshold < 1 {
+		return fmt.Errorf("threshold must be at least 1")
+	}
+	if r.WindowHours < 1 {
+		return fmt.Errorf("window must be at least 1 hour")
+	}
+	switch r.Action {
+	case EscalationKick, EscalationBan:
+	case EscalationTempBan:
+		if r.DurationMinutes < 1 {
+			return fmt.Errorf("tempban rules need a duration")
+		}
+	default:
+		return fmt.Errorf("unknown action: %s", r.Action)
+	}
+	return nil
+}
+
+// Duration is the tempban length of the rule
+func (r *WarningRule) Duration() time.Duration {
+	return time.Duration(r.DurationMinutes) * time.Minute
+}
+
+// Describe summarizes the rule's action, e.g. "tempban 90m" or "tempban 2h"
+func (r *WarningRule) Describe() string {
+	if r.Action != EscalationTempBan {
+		return r.Action
+	}
+	if r.DurationMinutes%60 == 0 {
+		return fmt.Sprintf("%s %dh", r.Action, r.DurationMinutes/60)
+	}
+	return fmt.Sprintf("%s %dm", r.Action, r.DurationMinutes)
+}
+
+// Expiry is how long a warning from this preset counts
+func (p *WarningPreset) Expiry() time.Duration {
+	if p.ExpireHours > 0 {
+		return time.Duration(p.ExpireHours) * time.Hour
+	}
+	return DefaultWarningExpiry
+}
+
+// PlayerMessage is what the warned player is told
+func (p *WarningPreset) PlayerMessage() string {
+	if p.Message != "" {
+		return p.Message
+	}
+	return p.Reason
+}
+
+// activeWarnings scopes a query to warnings still counting
+func activeWarnings(query *gorm.DB) *gorm.DB {
+	return query.Where("cleared = ? AND expires_at > ?", false, time.Now())
+}
+
+// CreateWarning records a warning, defaulting its expiry from the preset
+func CreateWarning(warning *Warning) error {
+	warning.PlayerGUID = strings.ToLower(warning.PlayerGUID)
+	if warning.ExpiresAt.IsZero() {
+		expiry := DefaultWarningExpiry
+		if warning.Preset != nil {
+			expiry = warning.Preset.Expiry()
+		}
+		warning.ExpiresAt = time.Now().Add(expiry)
+	}
+	return database.DB.Omit("Preset").Create(warning).Error
+}
+
+// SetWarningEscalation records what a warning triggered
+func SetWarningEscalation(id uint, escalation string) error {
+	return database.DB.Model(&Warning{}).Where("id = ?", id).Update("escalation", escalation).Error
+}
+
+// GetWarningByID gets a warning by ID
+func GetWarningByID(id uint) (*Warning, error) {
+	var warning Warning
+	if err := database.DB.Preload("Preset").First(&warning, id).Error; err != nil {
+		return nil, ErrWarningNotFound
+	}
+	return &warning, nil
+}
+
+// GetPlayerWarnings lists a player's warnings, newest first
+func GetPlayerWarnings(guid string, activeOnly bool) ([]Warning, error) {
+	var warnings []Warning
+	query := database.DB.Preload("Preset").Where("player_guid = ?", strings.ToLower(guid))
+	if activeOnly {
+		query = activeWarnings(query)
+	}
+	err := query.Order("created_at DESC").Find(&warnings).Error
+	return warnings, err
+}
+
+// CountActiveWarnings counts a player's warnings still counting that were
+// issued within window, or at any time when window is 0
+func CountActiveWarnings(guid string, window time.Duration) (int64, error) {
+	var count int64
+	query := activeWarnings(database.DB.Model(&Warning{})).Where("player_guid = ?", strings.ToLower(guid))
+	if window > 0 {
+		query = query.Where("created_at > ?", time.Now().Add(-window))
+	}
+	err := query.Count(&count).Error
+	return count, err
+}
+
+// ClearWarning clears one warning
+func ClearWarning(id uint, clearedBy string) error {
+	result := database.DB.Model(&Warning{}).
+		Where("id = ? AND cleared = ?", id, false).
+		Updates(map[string]interface{}{
+			"cleared":         true,
+			"cleared_at":      time.Now(),
+			"cleared_by_name": clearedBy,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrWarningNotFound
+	}
+	return nil
+}
+
+// ClearPlayerWarnings clears all of a player's active warnings and returns
+// how many were cleared
+func ClearPlayerWarnings(guid, clearedBy string) (int64, error) {
+	result := activeWarnings(database.DB.Model(&Warning{})).
+		Where("player_guid = ?", strings.ToLower(guid)).
+		Updates(map[string]interface{}{
+			"cleared":         true,
+			"cleared_at":      time.Now(),
+			"cleared_by_name": clearedBy,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// MatchEscalation returns the strictest enabled rule the player has reached,
+// or nil. Each rule counts warnings within its own window.
+func MatchEscalation(guid string) (*WarningRule, int64, error) {
+	rules, err := GetWarningRules()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var matched *WarningRule
+	var matchedCount int64
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.Enabled {
+			continue
+		}
+		count, err := CountActiveWarnings(guid, time.Duration(rule.WindowHours)*time.Hour)
+		if err != nil {
+			return nil, 0, err
+		}
+		if count >= int64(rule.Threshold) && (matched == nil || rule.Threshold > matched.Threshold) {
+			matched = rule
+			matchedCount = count
+		}
+	}
+	return matched, matchedCount, nil
+}
+
+// GetWarningRules lists the escalation ladder, lowest threshold first
+func GetWarningRules() ([]WarningRule, error) {
+	var rules []WarningRule
+	err := database.DB.Order("threshold ASC").Find(&rules).Error
+	return rules, err
+}
+
+// GetWarningRule gets an escalation rule by ID
+func GetWarningRule(id uint) (*WarningRule, error) {
+	var rule WarningRule
+	if err := database.DB.First(&rule, id).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// SaveWarningRule creates or updates an escalation rule
+func SaveWarningRule(rule *WarningRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	return database.DB.Save(rule).Error
+}
+
+// DeleteWarningRule removes an escalation rule
+func DeleteWarningRule(id uint) error {
+	return database.DB.Delete(&WarningRule{}, id).Error
+}
+
+// GetWarningPresets lists the warning presets by key
+func GetWarningPresets() ([]WarningPreset, error) {
+	var presets []WarningPreset
+	err := database.DB.Order("key ASC").Find(&presets).Error
+	return presets, err
+}
+
+// GetWarningPreset gets a preset by ID
+func GetWarningPreset(id uint) (*WarningPreset, error) {
+	var preset WarningPreset
+	if err := database.DB.First(&preset, id).Error; err != nil {
+		return nil, ErrPresetNotFound
+	}
+	return &preset, nil
+}
+
+// GetWarningPresetByKey gets a preset by its key, ignoring case. Most
+// in-game reasons aren't preset keys, so a miss returns nil without error.
+func GetWarningPresetByKey(key string) (*WarningPreset, error) {
+	var presets []WarningPreset
+	err := database.DB.Where("key = ?", strings.ToLower(key)).Limit(1).Find(&presets).Error
+	if err != nil || len(presets) == 0 {
+		return nil, err
+	}
+	return &presets[0], nil
+}
+
+// SaveWarningPreset creates or updates a preset
+func SaveWarningPreset(preset *WarningPreset) error {
+	preset.Key = strings.ToLower(strings.TrimSpace(preset.Key))
+	if preset.Key == "" || strings.ContainsAny(preset.Key, " \t") {
+		return fmt.Errorf("preset key must be a single word")
+	}
+	if strings.TrimSpace(preset.Reason) == "" {
+		return fmt.Errorf("preset reason is required")
+	}
+	return database.DB.Save(preset).Error
+}
+
+// DeleteWarningPreset removes a preset. Warnings issued from it keep their
+// reason.
+func DeleteWarningPreset(id uint) error {
+	return database.DB.Delete(&WarningPreset{}, id).Error
+}
@@ -14,6 +14,7 @@ const (
 	WebhookEventPlayerBanned   WebhookEvent = "player.banned"
 	WebhookEventPlayerUnbanned WebhookEvent = "player.unbanned"
 	WebhookEventPlayerKicked   WebhookEvent = "player.kicked"
+	WebhookEventPlayerFlagged  WebhookEvent = "player.flagged"
 	WebhookEventReportCreated  WebhookEvent = "report.created"
 	WebhookEventReportActioned WebhookEvent = "report.actioned"
 	WebhookEventUserApproved   WebhookEvent = "user.approved"
@@ -21,6 +22,19 @@ const (
 	WebhookEventServerOnline   WebhookEvent = "server.online"
 	WebhookEventServerOffline  WebhookEvent = "server.offline"
 	WebhookEventSecurityAlert  WebhookEvent = "security.alert"
+	WebhookEventAutomation     WebhookEvent = "automation.triggered"
+
+	// Game events from the log watcher
+	WebhookEventPlayerConnect    WebhookEvent = "player.connect"
+	WebhookEventPlayerDisconnect WebhookEvent = "player.disconnect"
+	WebhookEventPlayerChat       WebhookEvent = "player.chat"
+	WebhookEventPlayerTeamChat   WebhookEvent = "player.team_chat"
+	WebhookEventPlayerKill       WebhookEvent = "player.kill"
+	WebhookEventPlayerAction     WebhookEvent = "player.action"
+	WebhookEventPlayerNameChange WebhookEvent = "player.name_change"
+	WebhookEventMapStart         WebhookEvent = "map.start"
+	WebhookEventMapEnd           WebhookEvent = "map.end"
+	WebhookEventRoundEnd         WebhookEvent = "round.end"
 )
 
 // Webhook represents a webhook configuration
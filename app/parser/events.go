@@ -0,0 +1,231 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Event is the typed payload of a log line. Use a type switch on
+// LogEntry.Event to get at the fields.
+type Event interface {
+	Type() CommandType
+}
+
+// Combatant identifies a player as logged on kill, damage and action lines
+type Combatant struct {
+	GUID string `json:"guid"`
+	Slot int    `json:"slot"`
+	Team string `json:"team,omitempty"`
+	Name string `json:"name"`
+}
+
+// IsWorld reports whether this is the world entity (falling, triggers, etc.),
+// which is logged with slot -1
+func (c Combatant) IsWorld() bool {
+	return c.Slot < 0
+}
+
+// ChatEvent is a say or sayteam line
+type ChatEvent struct {
+	Player  Combatant `json:"player"`
+	Message string    `json:"message"`
+	Team    bool      `json:"team"`
+}
+
+func (e *ChatEvent) Type() CommandType {
+	if e.Team {
+		return SAYTEAM
+	}
+	return SAY
+}
+
+// JoinEvent is logged when a player connects
+type JoinEvent struct {
+	Player Combatant `json:"player"`
+}
+
+func (e *JoinEvent) Type() CommandType { return JOIN }
+
+// QuitEvent is logged when a player disconnects
+type QuitEvent struct {
+	Player Combatant `json:"player"`
+}
+
+func (e *QuitEvent) Type() CommandType { return LEAVE }
+
+// DamageEvent is logged for every hit that doesn't kill
+type DamageEvent struct {
+	Victim       Combatant `json:"victim"`
+	Attacker     Combatant `json:"attacker"`
+	Weapon       string    `json:"weapon"`
+	Damage       int       `json:"damage"`
+	MeansOfDeath string    `json:"meansOfDeath"`
+	HitLocation  string    `json:"hitLocation"`
+}
+
+func (e *DamageEvent) Type() CommandType { return DAMAGE }
+
+// IsHeadshot reports whether the hit landed on the head
+func (e *DamageEvent) IsHeadshot() bool {
+	return e.HitLocation == "head" || e.MeansOfDeath == "MOD_HEAD_SHOT"
+}
+
+// KillEvent is logged when a player dies. The fields match DamageEvent;
+// Damage is the final hit.
+type KillEvent struct {
+	DamageEvent
+}
+
+func (e *KillEvent) Type() CommandType { return KILL }
+
+// IsSuicide reports whether the victim killed themselves or was killed by the world
+func (e *KillEvent) IsSuicide() bool {
+	return e.Attacker.IsWorld() || e.Attacker.Slot == e.Victim.Slot
+}
+
+// IsTeamKill reports whether both players were on the same team. Always false
+// in free-for-all modes, where no team is logged.
+func (e *KillEvent) IsTeamKill() bool {
+	return !e.IsSuicide() && e.Victim.Team != "" && e.Victim.Team == e.Attacker.Team
+}
+
+// WeaponEvent is logged when a player picks up a weapon
+type WeaponEvent struct {
+	Player Combatant `json:"player"`
+	Weapon string    `json:"weapon"`
+}
+
+func (e *WeaponEvent) Type() CommandType { return WEAPON }
+
+// ActionEvent is a gametype action such as a bomb plant or flag capture
+type ActionEvent struct {
+	Player Combatant `json:"player"`
+	Action string    `json:"action"`
+}
+
+func (e *ActionEvent) Type() CommandType { return ACTION }
+
+// TeamMember is a player listed on a W or L line, which carries no slot
+type TeamMember struct {
+	GUID string `json:"guid"`
+	Name string `json:"name"`
+}
+
+// TeamResultEvent is a W (win) or L (loss) line at the end of a round or map
+type TeamResultEvent struct {
+	Win     bool         `json:"win"`
+	Team    string       `json:"team"`
+	Players []TeamMember `json:"players"`
+}
+
+func (e *TeamResultEvent) Type() CommandType {
+	if e.Win {
+		return WIN
+	}
+	return LOSS
+}
+
+// InitGameEvent is logged when a map starts, with the server settings
+type InitGameEvent struct {
+	Settings map[string]string `json:"settings"`
+}
+
+func (e *InitGameEvent) Type() CommandType { return INITGAME }
+
+// Map returns the map being loaded
+func (e *InitGameEvent) Map() string {
+	return e.Settings["mapname"]
+}
+
+// GameType returns the gametype being played
+func (e *InitGameEvent) GameType() string {
+	return e.Settings["g_gametype"]
+}
+
+// ExitLevelEvent is logged when a map ends normally
+type ExitLevelEvent struct{}
+
+func (e *ExitLevelEvent) Type() CommandType { return EXITLEVEL }
+
+// ShutdownGameEvent is logged when the game shuts down, including before a map change
+type ShutdownGameEvent struct{}
+
+func (e *ShutdownGameEvent) Type() CommandType { return SHUTDOWNGAME }
+
+// parseInfoString parses a \key\value\key\value string
+func parseInfoString(info string) map[string]string {
+	settings := make(map[string]string)
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(info), "\\"), "\\")
+	for i := 0; i+1 < len(parts); i += 2 {
+		settings[parts[i]] = parts[i+1]
+	}
+	return settings
+}
+
+// parseCombatant reads guid;slot;team;name, or guid;slot;name if withTeam is false
+func parseCombatant(parts []string, withTeam bool) (Combatant, bool) {
+	need := 3
+	if withTeam {
+		need = 4
+	}
+	if len(parts) < need {
+		return Combatant{}, false
+	}
+
+	slot, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return Combatant{}, false
+	}
+
+	c := Combatant{GUID: parts[0], Slot: slot}
+	if withTeam {
+		c.Team = parts[2]
+		c.Name = parts[3]
+	} else {
+		c.Name = parts[2]
+	}
+	return c, true
+}
+
+// parseHit reads the shared K/D layout:
+// victim guid;slot;team;name;attacker guid;slot;team;name;weapon;damage;mod;location
+func parseHit(parts []string) (*DamageEvent, bool) {
+	if len(parts) < 12 {
+		return nil, false
+	}
+
+	victim, ok := parseCombatant(parts[0:4], true)
+	if !ok {
+		return nil, false
+	}
+	attacker, ok := parseCombatant(parts[4:8], true)
+	if !ok {
+		return nil, false
+	}
+	damage, err := strconv.Atoi(strings.TrimSpace(parts[9]))
+	if err != nil {
+		return nil, false
+	}
+
+	return &DamageEvent{
+		Victim:       victim,
+		Attacker:     attacker,
+		Weapon:       parts[8],
+		Damage:       damage,
+		MeansOfDeath: parts[10],
+		HitLocation:  parts[11],
+	}, true
+}
+
+// parseTeamResult reads team;guid;name[;guid;name...]
+func parseTeamResult(parts []string, win bool) (*TeamResultEvent, bool) {
+	if len(parts) < 1 {
+		return nil, false
+	}
+
+	event := &TeamResultEvent{Win: win, Team: parts[0]}
+	for i := 1; i+1 < len(parts); i += 2 {
+		event.Players = append(event.Players, TeamMember{GUID: parts[i], Name: parts[i+1]})
+	}
+	return event, true
+}
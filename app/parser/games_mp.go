@@ -2,7 +2,9 @@ package parser
 
 import (
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type CommandType int
@@ -12,6 +14,15 @@ const (
 	SAYTEAM
 	JOIN
 	LEAVE
+	KILL
+	DAMAGE
+	WEAPON
+	ACTION
+	WIN
+	LOSS
+	INITGAME
+	EXITLEVEL
+	SHUTDOWNGAME
 )
 
 var Commands = map[string]CommandType{
@@ -19,8 +30,51 @@ var Commands = map[string]CommandType{
 	"sayteam": SAYTEAM,
 	"J":       JOIN,
 	"Q":       LEAVE,
+	"K":       KILL,
+	"D":       DAMAGE,
+	"Weapon":  WEAPON,
+	"A":       ACTION,
+	"W":       WIN,
+	"L":       LOSS,
 }
 
+// String returns the log name of the command type
+func (t CommandType) String() string {
+	switch t {
+	case SAY:
+		return "say"
+	case SAYTEAM:
+		return "sayteam"
+	case JOIN:
+		return "join"
+	case LEAVE:
+		return "quit"
+	case KILL:
+		return "kill"
+	case DAMAGE:
+		return "damage"
+	case WEAPON:
+		return "weapon"
+	case ACTION:
+		return "action"
+	case WIN:
+		return "win"
+	case LOSS:
+		return "loss"
+	case INITGAME:
+		return "init_game"
+	case EXITLEVEL:
+		return "exit_level"
+	case SHUTDOWNGAME:
+		return "shutdown_game"
+	default:
+		return "unknown"
+	}
+}
+
+// LogEntry is one parsed games_mp.log line. The Player fields are set for
+// lines about a single player (chat, join, quit, weapon, action); Event
+// always holds the typed payload.
 type LogEntry struct {
 	Command     string
 	PlayerGUID  string
@@ -28,50 +82,129 @@ type LogEntry struct {
 	PlayerName  string
 	Message     string
 	CommandType CommandType
+	GameTime    time.Duration // Time since the server started, from the line prefix
+	Event       Event
 }
 
-var timestampRegex = regexp.MustCompile(`^\s*\d+:\d+\s+`)
+var timestampRegex = regexp.MustCompile(`^\s*(\d+):(\d+)\s+`)
 
 func ParseGamesMpLine(line string) (*LogEntry, bool) {
 	if line == "" {
 		return nil, false
 	}
 
-	line = timestampRegex.ReplaceAllString(line, "")
+	var gameTime time.Duration
+	if m := timestampRegex.FindStringSubmatch(line); m != nil {
+		minutes, _ := strconv.Atoi(m[1])
+		seconds, _ := strconv.Atoi(m[2])
+		gameTime = time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
+		line = line[len(m[0]):]
+	}
 	line = strings.TrimSpace(line)
 
-	if !strings.Contains(line, ";") {
-		return nil, false
+	entry, ok := parseLine(line)
+	if ok {
+		entry.GameTime = gameTime
+	}
+	return entry, ok
+}
+
+func parseLine(line string) (*LogEntry, bool) {
+	// Round events use "Name: args" rather than semicolons
+	switch {
+	case strings.HasPrefix(line, "InitGame:"):
+		event := &InitGameEvent{Settings: parseInfoString(strings.TrimPrefix(line, "InitGame:"))}
+		return &LogEntry{Command: "InitGame", CommandType: INITGAME, Event: event}, true
+	case strings.HasPrefix(line, "ExitLevel:"):
+		return &LogEntry{Command: "ExitLevel", CommandType: EXITLEVEL, Event: &ExitLevelEvent{}}, true
+	case strings.HasPrefix(line, "ShutdownGame:"):
+		return &LogEntry{Command: "ShutdownGame", CommandType: SHUTDOWNGAME, Event: &ShutdownGameEvent{}}, true
 	}
 
-	parts := strings.Split(line, ";")
-	if len(parts) < 4 {
+	if !strings.Contains(line, ";") {
 		return nil, false
 	}
 
+	parts := strings.Split(line, ";")
 	command := parts[0]
-	playerGUID := parts[1]
-	playerID := parts[2]
-	playerName := parts[3]
+	args := parts[1:]
 
 	cmdType, exists := Commands[command]
 	if !exists {
 		return nil, false
 	}
 
-	entry := &LogEntry{
-		Command:     command,
-		PlayerGUID:  playerGUID,
-		PlayerID:    playerID,
-		PlayerName:  playerName,
-		CommandType: cmdType,
-	}
+	entry := &LogEntry{Command: command, CommandType: cmdType}
+
+	switch cmdType {
+	case SAY, SAYTEAM:
+		player, ok := parseCombatant(args, false)
+		if !ok {
+			return nil, false
+		}
+		// Messages may contain semicolons themselves
+		message := ""
+		if len(args) > 3 {
+			message = strings.Join(args[3:], ";")
+		}
+		entry.setPlayer(player)
+		entry.Message = message
+		entry.Event = &ChatEvent{Player: player, Message: message, Team: cmdType == SAYTEAM}
+
+	case JOIN, LEAVE:
+		player, ok := parseCombatant(args, false)
+		if !ok {
+			return nil, false
+		}
+		entry.setPlayer(player)
+		if cmdType == JOIN {
+			entry.Event = &JoinEvent{Player: player}
+		} else {
+			entry.Event = &QuitEvent{Player: player}
+		}
+
+	case KILL, DAMAGE:
+		hit, ok := parseHit(args)
+		if !ok {
+			return nil, false
+		}
+		if cmdType == KILL {
+			entry.Event = &KillEvent{DamageEvent: *hit}
+		} else {
+			entry.Event = hit
+		}
+
+	case WEAPON:
+		player, ok := parseCombatant(args, false)
+		if !ok || len(args) < 4 {
+			return nil, false
+		}
+		entry.setPlayer(player)
+		entry.Event = &WeaponEvent{Player: player, Weapon: args[3]}
 
-	if cmdType == SAY || cmdType == SAYTEAM {
-		if len(parts) >= 5 {
-			entry.Message = parts[4]
+	case ACTION:
+		// A;guid;slot;team;name;action, or without the team on older mods
+		withTeam := len(args) >= 5
+		player, ok := parseCombatant(args, withTeam)
+		if !ok {
+			return nil, false
 		}
+		entry.setPlayer(player)
+		entry.Event = &ActionEvent{Player: player, Action: args[len(args)-1]}
+
+	case WIN, LOSS:
+		result, ok := parseTeamResult(args, cmdType == WIN)
+		if !ok {
+			return nil, false
+		}
+		entry.Event = result
 	}
 
 	return entry, true
 }
+
+func (e *LogEntry) setPlayer(player Combatant) {
+	e.PlayerGUID = player.GUID
+	e.PlayerID = strconv.Itoa(player.Slot)
+	e.PlayerName = player.Name
+}
@@ -92,8 +92,9 @@ func (c *CommandAPIImpl) ExecuteCommand(playerName, playerGUID, command string,
 	return handler(playerName, playerGUID, args)
 }
 
-// ProcessPluginCommand processes a command from chat
-func (c *CommandAPIImpl) ProcessPluginCommand(playerName, playerGUID, commandName string, args []string) error {
+// ProcessPluginCommand processes a command from chat on the given server.
+// A nil serverID replies through the default server.
+func (c *CommandAPIImpl) ProcessPluginCommand(serverID *uint, playerName, playerGUID, commandName string, args []string) error {
 	c.mu.RLock()
 	pluginCmd, exists := c.pluginCommands[commandName]
 	handler := c.commandCallbacks[commandName]
@@ -107,11 +108,11 @@ func (c *CommandAPIImpl) ProcessPluginCommand(playerName, playerGUID, commandNam
 
 	// Validate argument count
 	if len(args) < cmd.MinArgs {
-		c.sendPlayerMessage(playerName, fmt.Sprintf("Usage: !%s", cmd.Usage))
+		c.sendPlayerMessage(serverID, playerName, fmt.Sprintf("Usage: !%s", cmd.Usage))
 		return nil
 	}
 	if cmd.MaxArgs >= 0 && len(args) > cmd.MaxArgs {
-		c.sendPlayerMessage(playerName, fmt.Sprintf("Usage: !%s", cmd.Usage))
+		c.sendPlayerMessage(serverID, playerName, fmt.Sprintf("Usage: !%s", cmd.Usage))
 		return nil
 	}
 
@@ -122,7 +123,7 @@ func (c *CommandAPIImpl) ProcessPluginCommand(playerName, playerGUID, commandNam
 
 		// Check power level
 		if cmd.MinPower > 0 && playerPower < cmd.MinPower {
-			c.sendPlayerMessage(playerName, "You don't have permission to use this command")
+			c.sendPlayerMessage(serverID, playerName, "You don't have permission to use this command")
 			return nil
 		}
 
@@ -131,7 +132,7 @@ func (c *CommandAPIImpl) ProcessPluginCommand(playerName, playerGUID, commandNam
 			// Get player's group to check permissions
 			player, err := models.GetInGamePlayerByGUID(playerGUID)
 			if err != nil || player.Group == nil {
-				c.sendPlayerMessage(playerName, "You don't have permission to use this command")
+				c.sendPlayerMessage(serverID, playerName, "You don't have permission to use this command")
 				return nil
 			}
 
@@ -147,7 +148,7 @@ func (c *CommandAPIImpl) ProcessPluginCommand(playerName, playerGUID, commandNam
 				}
 			}
 			if !hasPermission {
-				c.sendPlayerMessage(playerName, "You don't have permission to use this command")
+				c.sendPlayerMessage(serverID, playerName, "You don't have permission to use this command")
 				return nil
 			}
 		}
@@ -159,7 +160,7 @@ func (c *CommandAPIImpl) ProcessPluginCommand(playerName, playerGUID, commandNam
 			zap.String("command", commandName),
 			zap.String("player", playerName),
 			zap.Error(err))
-		c.sendPlayerMessage(playerName, "An error occurred while executing the command")
+		c.sendPlayerMessage(serverID, playerName, "An error occurred while executing the command")
 		return err
 	}
 
@@ -179,14 +180,17 @@ func (c *CommandAPIImpl) GetRegisteredCommands() map[string]CommandDefinition {
 }
 
 // sendPlayerMessage sends a message to a specific player
-func (c *CommandAPIImpl) sendPlayerMessage(playerName, message string) {
+func (c *CommandAPIImpl) sendPlayerMessage(serverID *uint, playerName, message string) {
 	if c.rconAPI == nil {
 		return
 	}
 
-	// Use tell command to send private message
-	cmd := fmt.Sprintf(`tell %s "^2%s"`, playerName, message)
-	if _, err := c.rconAPI.SendCommand(cmd); err != nil {
+	api := c.rconAPI
+	if serverID != nil {
+		api = api.ForServer(*serverID)
+	}
+
+	if err := api.Tell(playerName, "^2"+message); err != nil {
 		logger.Error("Failed to send player message", zap.Error(err))
 	}
 }
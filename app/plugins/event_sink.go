@@ -0,0 +1,20 @@
+package plugins
+
+import (
+	"github.com/ethanburkett/goadmin/app/events"
+)
+
+// PublishEvent forwards an event from the event stream to plugin
+// subscribers. The payload fields are flattened into the data map alongside
+// eventId, version, serverId and timestamp.
+func PublishEvent(event events.Event) {
+	data := event.DataMap()
+	data["eventId"] = event.ID
+	data["version"] = event.Version
+	data["timestamp"] = event.Timestamp
+	if event.ServerID != nil {
+		data["serverId"] = *event.ServerID
+	}
+
+	GlobalEventBus.Publish(string(event.Type), data)
+}
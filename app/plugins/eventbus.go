@@ -3,6 +3,8 @@ package plugins
 import (
 	"fmt"
 	"sync"
+
+	"github.com/ethanburkett/goadmin/app/logger"
 )
 
 // EventBus provides pub/sub event handling for plugins
@@ -46,7 +48,9 @@ func (eb *EventBus) Publish(eventType string, data map[string]interface{}) error
 	subscriberCount := len(handlers)
 	eb.mu.RUnlock()
 
-	fmt.Printf("[EventBus] Publishing event: %s (subscribers: %d)\n", eventType, subscriberCount)
+	if subscriberCount > 0 {
+		logger.Debug(fmt.Sprintf("[EventBus] Publishing event: %s (subscribers: %d)", eventType, subscriberCount))
+	}
 
 	// Call handlers in goroutines to prevent blocking
 	for _, handler := range handlers {
@@ -3,6 +3,9 @@ package plugins
 import (
 	"context"
 	"time"
+
+	"github.com/ethanburkett/goadmin/app/rcon"
+	"github.com/ethanburkett/goadmin/app/rcon/commands"
 )
 
 // Plugin represents the interface all plugins must implement
@@ -121,8 +124,53 @@ type RCONAPI interface {
 	// SendCommandWithTimeout sends a command with a custom timeout
 	SendCommandWithTimeout(command string, timeout time.Duration) (string, error)
 
-	// GetStatus gets server status
-	GetStatus() (map[string]interface{}, error)
+	// GetStatus gets the parsed server status
+	GetStatus() (*rcon.StatusResponse, error)
+
+	// GetPlayers returns the players currently on the server
+	GetPlayers() ([]rcon.StatusPlayer, error)
+
+	// FindPlayer looks up an online player by GUID, slot number or name
+	FindPlayer(nameOrGUID string) (*rcon.StatusPlayer, error)
+
+	// GetCvar returns the current value of a cvar
+	GetCvar(name string) (string, error)
+
+	// GetServerInfo returns the parsed serverinfo reply
+	GetServerInfo() (*commands.ServerInfo, error)
+
+	// Kick removes a player by slot number, GUID or exact name. Names and
+	// GUIDs are resolved to the player's slot from status.
+	Kick(target, reason string) error
+
+	// BanClient permanently bans a player by slot number, GUID or exact
+	// name, resolved to a slot like Kick
+	BanClient(target, reason string) error
+
+	// TempBanUser bans a player for the given duration
+	TempBanUser(target string, duration time.Duration, reason string) error
+
+	// UnbanUser lifts a ban by player name or GUID
+	UnbanUser(target string) error
+
+	// Tell sends a private message to a player
+	Tell(target, message string) error
+
+	// Say broadcasts a message to all players
+	Say(message string) error
+
+	// Map loads the given map
+	Map(mapName string) error
+
+	// MapRotate switches to the next map in the rotation
+	MapRotate() error
+
+	// SetCvar sets a server cvar
+	SetCvar(name, value string) error
+
+	// ForServer returns an RCONAPI that targets a specific server.
+	// Calls on the original API go to the default server.
+	ForServer(serverID uint) RCONAPI
 }
 
 // DatabaseAPI provides access to database operations
@@ -2,51 +2,314 @@ package plugins
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethanburkett/goadmin/app/rcon"
+	"github.com/ethanburkett/goadmin/app/rcon/commands"
 )
 
+// ClientResolver returns the RCON client for a server. A nil serverID
+// selects the default server.
+type ClientResolver func(serverID *uint) (*rcon.Client, error)
+
 // RCONAPIImpl implements the RCONAPI interface for plugins
 type RCONAPIImpl struct {
-	client *rcon.Client
+	client   *rcon.Client
+	resolve  ClientResolver
+	serverID *uint
+	cache    *rconCache
+
+	// bound holds the per-server views handed out by ForServer so they
+	// share a cache between plugins. Only used on the root instance.
+	root    *RCONAPIImpl
+	boundMu sync.Mutex
+	bound   map[uint]*RCONAPIImpl
 }
 
-// NewRCONAPI creates a new RCON API instance
+// NewRCONAPI creates a new RCON API instance bound to a single client
 func NewRCONAPI(client *rcon.Client) *RCONAPIImpl {
 	return &RCONAPIImpl{
 		client: client,
+		cache:  newRCONCache(),
+		bound:  make(map[uint]*RCONAPIImpl),
+	}
+}
+
+// NewRCONAPIWithResolver creates an RCON API that looks up the client for
+// each call, so it follows servers as they are started and stopped
+func NewRCONAPIWithResolver(resolve ClientResolver) *RCONAPIImpl {
+	return &RCONAPIImpl{
+		resolve: resolve,
+		cache:   newRCONCache(),
+		bound:   make(map[uint]*RCONAPIImpl),
+	}
+}
+
+// ForServer returns a view of the API that sends commands to a specific server
+func (r *RCONAPIImpl) ForServer(serverID uint) RCONAPI {
+	root := r
+	if r.root != nil {
+		root = r.root
+	}
+
+	root.boundMu.Lock()
+	defer root.boundMu.Unlock()
+
+	if api, ok := root.bound[serverID]; ok {
+		return api
+	}
+
+	id := serverID
+	api := &RCONAPIImpl{
+		client:   root.client,
+		resolve:  root.resolve,
+		serverID: &id,
+		cache:    newRCONCache(),
+		root:     root,
 	}
+	root.bound[serverID] = api
+	return api
+}
+
+// getClient returns the client this view sends commands to
+func (r *RCONAPIImpl) getClient() (*rcon.Client, error) {
+	if r.resolve != nil {
+		return r.resolve(r.serverID)
+	}
+	if r.client == nil {
+		return nil, fmt.Errorf("RCON client not initialized")
+	}
+	return r.client, nil
 }
 
 // SendCommand sends a raw RCON command
 func (r *RCONAPIImpl) SendCommand(command string) (string, error) {
-	if r.client == nil {
-		return "", fmt.Errorf("RCON client not initialized")
+	client, err := r.getClient()
+	if err != nil {
+		return "", err
 	}
-	return r.client.SendCommand(command)
+	return client.SendCommand(command)
 }
 
 // SendCommandWithTimeout sends a command with a custom timeout
 func (r *RCONAPIImpl) SendCommandWithTimeout(command string, timeout time.Duration) (string, error) {
-	if r.client == nil {
-		return "", fmt.Errorf("RCON client not initialized")
+	client, err := r.getClient()
+	if err != nil {
+		return "", err
 	}
-	return r.client.SendCommandWithTimeout(command, timeout)
+	return client.SendCommandWithTimeout(command, timeout)
 }
 
-// GetStatus gets server status
-func (r *RCONAPIImpl) GetStatus() (map[string]interface{}, error) {
-	if r.client == nil {
-		return nil, fmt.Errorf("RCON client not initialized")
+// GetStatus gets the parsed server status. Replies are shared between
+// plugins for a couple of seconds, so callers must not modify the result.
+func (r *RCONAPIImpl) GetStatus() (*rcon.StatusResponse, error) {
+	client, err := r.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := r.cache.do("status", statusCacheTTL, func() (interface{}, error) {
+		return client.Status()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*rcon.StatusResponse), nil
+}
+
+// GetPlayers returns the players currently on the server
+func (r *RCONAPIImpl) GetPlayers() ([]rcon.StatusPlayer, error) {
+	status, err := r.GetStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	players := make([]rcon.StatusPlayer, len(status.Players))
+	copy(players, status.Players)
+	return players, nil
+}
+
+// FindPlayer looks up an online player by GUID, slot number or name.
+// Exact matches win over partial name matches.
+func (r *RCONAPIImpl) FindPlayer(nameOrGUID string) (*rcon.StatusPlayer, error) {
+	players, err := r.GetPlayers()
+	if err != nil {
+		return nil, err
+	}
+
+	search := strings.ToLower(strings.TrimSpace(nameOrGUID))
+	if search == "" {
+		return nil, rcon.ErrPlayerNotFound
+	}
+
+	for i := range players {
+		p := &players[i]
+		if strings.ToLower(p.Uuid) == search || strings.ToLower(p.SteamID) == search ||
+			strconv.Itoa(p.ID) == search || strings.ToLower(p.StrippedName) == search {
+			return p, nil
+		}
+	}
+
+	for i := range players {
+		if strings.Contains(strings.ToLower(players[i].StrippedName), search) {
+			return &players[i], nil
+		}
+	}
+
+	return nil, rcon.ErrPlayerNotFound
+}
+
+// GetCvar returns the current value of a cvar
+func (r *RCONAPIImpl) GetCvar(name string) (string, error) {
+	client, err := r.getClient()
+	if err != nil {
+		return "", err
 	}
 
-	response, err := r.client.SendCommand("status")
+	value, err := r.cache.do("cvar:"+strings.ToLower(name), infoCacheTTL, func() (interface{}, error) {
+		return client.GetCvar(name)
+	})
+	if err != nil {
+		return "", err
+	}
+	return value.(*commands.Cvar).Value, nil
+}
+
+// GetServerInfo returns the parsed serverinfo reply
+func (r *RCONAPIImpl) GetServerInfo() (*commands.ServerInfo, error) {
+	client, err := r.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := r.cache.do("serverinfo", infoCacheTTL, func() (interface{}, error) {
+		return client.ServerInfo()
+	})
 	if err != nil {
 		return nil, err
 	}
+	return value.(*commands.ServerInfo), nil
+}
+
+// slotOf resolves a slot number, GUID or exact name to a slot, since
+// clientkick and banclient only take slots. Partial names aren't accepted
+// so a typo can't hit the wrong player.
+func (r *RCONAPIImpl) slotOf(target string) (string, error) {
+	target = strings.TrimSpace(target)
+	if _, err := strconv.Atoi(target); err == nil {
+		return target, nil
+	}
+
+	players, err := r.GetPlayers()
+	if err != nil {
+		return "", err
+	}
+	for _, p := range players {
+		if strings.EqualFold(p.Uuid, target) || (p.SteamID != "" && strings.EqualFold(p.SteamID, target)) ||
+			strings.EqualFold(p.StrippedName, target) {
+			return strconv.Itoa(p.ID), nil
+		}
+	}
+	return "", rcon.ErrPlayerNotFound
+}
+
+// Kick removes a player by slot number, GUID or exact name
+func (r *RCONAPIImpl) Kick(target, reason string) error {
+	client, err := r.getClient()
+	if err != nil {
+		return err
+	}
+	slot, err := r.slotOf(target)
+	if err != nil {
+		return err
+	}
+	_, err = client.Kick(slot, reason)
+	return err
+}
+
+// BanClient permanently bans a player by slot number, GUID or exact name
+func (r *RCONAPIImpl) BanClient(target, reason string) error {
+	client, err := r.getClient()
+	if err != nil {
+		return err
+	}
+	slot, err := r.slotOf(target)
+	if err != nil {
+		return err
+	}
+	_, err = client.BanClient(slot, reason)
+	return err
+}
+
+// TempBanUser bans a player for the given duration
+func (r *RCONAPIImpl) TempBanUser(target string, duration time.Duration, reason string) error {
+	client, err := r.getClient()
+	if err != nil {
+		return err
+	}
+	_, err = client.TempBanUser(target, duration, reason)
+	return err
+}
+
+// UnbanUser lifts a ban by player name or GUID
+func (r *RCONAPIImpl) UnbanUser(target string) error {
+	client, err := r.getClient()
+	if err != nil {
+		return err
+	}
+	_, err = client.UnbanUser(target)
+	return err
+}
 
-	return map[string]interface{}{
-		"raw": response,
-	}, nil
+// Tell sends a private message to a player
+func (r *RCONAPIImpl) Tell(target, message string) error {
+	client, err := r.getClient()
+	if err != nil {
+		return err
+	}
+	_, err = client.Tell(target, message)
+	return err
+}
+
+// Say broadcasts a message to all players
+func (r *RCONAPIImpl) Say(message string) error {
+	client, err := r.getClient()
+	if err != nil {
+		return err
+	}
+	_, err = client.Say(message)
+	return err
+}
+
+// Map loads the given map
+func (r *RCONAPIImpl) Map(mapName string) error {
+	client, err := r.getClient()
+	if err != nil {
+		return err
+	}
+	_, err = client.Map(mapName)
+	return err
+}
+
+// MapRotate switches to the next map in the rotation
+func (r *RCONAPIImpl) MapRotate() error {
+	client, err := r.getClient()
+	if err != nil {
+		return err
+	}
+	_, err = client.MapRotate()
+	return err
+}
+
+// SetCvar sets a server cvar
+func (r *RCONAPIImpl) SetCvar(name, value string) error {
+	client, err := r.getClient()
+	if err != nil {
+		return err
+	}
+	_, err = client.SetCvar(name, value)
+	return err
 }
@@ -0,0 +1,72 @@
+package plugins
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// statusCacheTTL is how long a status reply is shared between plugins
+	statusCacheTTL = 2 * time.Second
+
+	// infoCacheTTL applies to serverinfo and cvar reads, which change rarely
+	infoCacheTTL = 5 * time.Second
+)
+
+type cachedResult struct {
+	value   interface{}
+	err     error
+	expires time.Time
+}
+
+type inflightCall struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// rconCache shares recent RCON reads between plugins and collapses
+// concurrent identical reads into a single round-trip
+type rconCache struct {
+	mu       sync.Mutex
+	results  map[string]cachedResult
+	inflight map[string]*inflightCall
+}
+
+func newRCONCache() *rconCache {
+	return &rconCache{
+		results:  make(map[string]cachedResult),
+		inflight: make(map[string]*inflightCall),
+	}
+}
+
+// do returns the cached value for key, or runs fetch once and caches its
+// result for ttl. Errors are not cached so the next caller retries.
+func (c *rconCache) do(key string, ttl time.Duration, fetch func() (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	if res, ok := c.results[key]; ok && time.Now().Before(res.expires) {
+		c.mu.Unlock()
+		return res.value, res.err
+	}
+	if call, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+		<-call.done
+		return call.value, call.err
+	}
+
+	call := &inflightCall{done: make(chan struct{})}
+	c.inflight[key] = call
+	c.mu.Unlock()
+
+	call.value, call.err = fetch()
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	if call.err == nil {
+		c.results[key] = cachedResult{value: call.value, expires: time.Now().Add(ttl)}
+	}
+	c.mu.Unlock()
+	close(call.done)
+
+	return call.value, call.err
+}
@@ -0,0 +1,193 @@
+package rcon
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxAuthFailures is how many rejected commands in a row trigger a lockout
+	DefaultMaxAuthFailures = 3
+
+	// DefaultLockoutBackoff is the first lockout duration; it doubles on each
+	// further failure up to MaxLockoutBackoff
+	DefaultLockoutBackoff = 30 * time.Second
+
+	// MaxLockoutBackoff caps the lockout duration
+	MaxLockoutBackoff = 10 * time.Minute
+)
+
+var (
+	// ErrBadPassword is returned when the server rejects the rcon password
+	ErrBadPassword = errors.New("rcon password rejected by server")
+
+	// ErrRconBanned is returned when the server has banned this address from rcon
+	ErrRconBanned = errors.New("banned from rcon by server")
+
+	// ErrLockedOut matches any LockoutError via errors.Is
+	ErrLockedOut = errors.New("rcon locked out")
+)
+
+// LockoutError is returned while the client refuses to contact the server
+// after repeated authentication failures
+type LockoutError struct {
+	Until    time.Time
+	Failures int
+	Reason   error
+}
+
+func (e *LockoutError) Error() string {
+	return fmt.Sprintf("rcon locked out until %s after %d failures: %v",
+		e.Until.Format(time.RFC3339), e.Failures, e.Reason)
+}
+
+func (e *LockoutError) Unwrap() error {
+	return e.Reason
+}
+
+func (e *LockoutError) Is(target error) bool {
+	return target == ErrLockedOut
+}
+
+// AuthState describes the authentication health of a client
+type AuthState struct {
+	Failures    int        `json:"failures"`
+	Locked      bool       `json:"locked"`
+	LockedUntil *time.Time `json:"lockedUntil,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+	Reason      string     `json:"reason,omitempty"`
+}
+
+type authTracker struct {
+	mu          sync.Mutex
+	failures    int
+	lockedUntil time.Time
+	lastErr     error
+}
+
+// detectAuthError inspects the first line of a reply for rejection messages.
+// Only the first line is checked so that ban lists and chat containing these
+// words are not mistaken for errors.
+func detectAuthError(response string) error {
+	line := strings.TrimSpace(response)
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = strings.TrimSpace(line[:i])
+	}
+	line = strings.ToLower(line)
+
+	switch {
+	case strings.HasPrefix(line, "invalid password"),
+		strings.HasPrefix(line, "bad rcon"),
+		strings.HasPrefix(line, "bad rconpassword"),
+		strings.HasPrefix(line, "no rconpassword set"):
+		return ErrBadPassword
+	case strings.Contains(line, "rcon") && strings.Contains(line, "banned"):
+		return ErrRconBanned
+	}
+	return nil
+}
+
+// checkLockout returns a LockoutError if the client is currently locked out
+func (c *Client) checkLockout() error {
+	c.auth.mu.Lock()
+	defer c.auth.mu.Unlock()
+
+	if time.Now().Before(c.auth.lockedUntil) {
+		return &LockoutError{
+			Until:    c.auth.lockedUntil,
+			Failures: c.auth.failures,
+			Reason:   c.auth.lastErr,
+		}
+	}
+	return nil
+}
+
+// recordAuthResult updates the failure counter after an exchange.
+// A nil err clears the counter.
+func (c *Client) recordAuthResult(err error) {
+	c.auth.mu.Lock()
+	defer c.auth.mu.Unlock()
+
+	if err == nil {
+		c.auth.failures = 0
+		c.auth.lastErr = nil
+		c.auth.lockedUntil = time.Time{}
+		return
+	}
+
+	c.auth.failures++
+	c.auth.lastErr = err
+
+	maxFailures := c.MaxAuthFailures
+	if maxFailures <= 0 {
+		maxFailures = DefaultMaxAuthFailures
+	}
+
+	// A ban means every further attempt is wasted, so back off fully right away
+	if errors.Is(err, ErrRconBanned) {
+		c.auth.lockedUntil = time.Now().Add(MaxLockoutBackoff)
+		return
+	}
+
+	if c.auth.failures < maxFailures {
+		return
+	}
+
+	backoff := DefaultLockoutBackoff
+	for i := maxFailures; i < c.auth.failures && backoff < MaxLockoutBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > MaxLockoutBackoff {
+		backoff = MaxLockoutBackoff
+	}
+	c.auth.lockedUntil = time.Now().Add(backoff)
+}
+
+// AuthState returns the current authentication failure and lockout state
+func (c *Client) AuthState() AuthState {
+	c.auth.mu.Lock()
+	defer c.auth.mu.Unlock()
+
+	state := AuthState{Failures: c.auth.failures}
+	if c.auth.lastErr != nil {
+		state.LastError = c.auth.lastErr.Error()
+		state.Reason = ErrorReason(c.auth.lastErr)
+	}
+	if time.Now().Before(c.auth.lockedUntil) {
+		until := c.auth.lockedUntil
+		state.Locked = true
+		state.LockedUntil = &until
+	}
+	return state
+}
+
+// ResetAuth clears the failure counter and any lockout, e.g. after the
+// password has been changed
+func (c *Client) ResetAuth() {
+	c.recordAuthResult(nil)
+}
+
+// ErrorReason maps an rcon error to a short machine-readable reason
+func ErrorReason(err error) string {
+	var netErr interface{ Timeout() bool }
+
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrLockedOut):
+		return "locked_out"
+	case errors.Is(err, ErrBadPassword):
+		return "bad_password"
+	case errors.Is(err, ErrRconBanned):
+		return "rcon_banned"
+	case errors.Is(err, ErrClientClosed):
+		return "closed"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	default:
+		return "error"
+	}
+}
@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"strings"
+)
+
+type BanListEntry struct {
+	Name    string `json:"name"`
+	GUID    string `json:"guid"`
+	IP      string `json:"ip"`
+	Expires string `json:"expires"`
+	Reason  string `json:"reason"`
+	Admin   string `json:"admin"`
+	Raw     string `json:"raw"`
+}
+
+// ParseBanList parses dumpbanlist output. Each ban is a single line of
+// "Key: value" pairs; keys that aren't recognised are ignored and the full
+// line is kept in Raw.
+func ParseBanList(response string) ([]BanListEntry, error) {
+	entries := []BanListEntry{}
+
+	lines := strings.Split(response, "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "---") {
+			continue
+		}
+
+		fields := parseKeyValues(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		entry := BanListEntry{Raw: line}
+		for key, value := range fields {
+			switch key {
+			case "name", "nick", "playername":
+				entry.Name = value
+			case "guid", "playerid", "steamid", "pbguid":
+				if entry.GUID == "" {
+					entry.GUID = value
+				}
+			case "ip", "address":
+				entry.IP = value
+			case "expire", "expires", "until":
+				entry.Expires = value
+			case "reason":
+				entry.Reason = value
+			case "admin", "by", "bannedby":
+				entry.Admin = value
+			}
+		}
+
+		if entry.GUID == "" && entry.Name == "" && entry.IP == "" {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// parseKeyValues splits "Key: value Other: value" into lowercase keys.
+// A key is a single word immediately followed by a colon.
+func parseKeyValues(line string) map[string]string {
+	result := make(map[string]string)
+
+	words := strings.Fields(line)
+	var key string
+	var value []string
+	flush := func() {
+		if key != "" {
+			result[key] = strings.Join(value, " ")
+		}
+	}
+
+	for _, word := range words {
+		if strings.HasSuffix(word, ":") && len(word) > 1 && !strings.ContainsAny(word[:len(word)-1], ":.") {
+			flush()
+			key = strings.ToLower(strings.TrimSuffix(word, ":"))
+			value = nil
+			continue
+		}
+		if key != "" {
+			value = append(value, word)
+		}
+	}
+	flush()
+
+	return result
+}
@@ -0,0 +1,33 @@
+package commands
+
+import (
+	"regexp"
+	"strings"
+)
+
+type Cvar struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	Default string `json:"default"`
+}
+
+// Matches replies like: "sv_hostname" is:"My Server^7" default:"CoD4Host^7"
+var cvarPattern = regexp.MustCompile(`"([^"]+)"\s+is:\s*"([^"]*)"(?:\s+default:\s*"([^"]*)")?`)
+
+func ParseCvar(response string) (*Cvar, error) {
+	match := cvarPattern.FindStringSubmatch(response)
+	if match == nil {
+		return nil, ErrUnknownCvar
+	}
+
+	return &Cvar{
+		Name:    match[1],
+		Value:   trimColorReset(match[2]),
+		Default: trimColorReset(match[3]),
+	}, nil
+}
+
+// The console appends ^7 to cvar values so colors don't bleed into the next field
+func trimColorReset(value string) string {
+	return strings.TrimSuffix(value, "^7")
+}
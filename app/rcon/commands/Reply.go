@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrPlayerNotFound is returned when the server can't find the target player
+	ErrPlayerNotFound = errors.New("player not found")
+
+	// ErrBadSlot is returned when a client slot number is out of range or empty
+	ErrBadSlot = errors.New("bad client slot")
+
+	// ErrUnknownCvar is returned when a cvar doesn't exist on the server
+	ErrUnknownCvar = errors.New("unknown cvar")
+)
+
+// CheckReply maps the server's error replies to typed errors.
+// Replies that don't look like errors return nil.
+func CheckReply(response string) error {
+	lower := strings.ToLower(strings.TrimSpace(response))
+
+	switch {
+	case strings.Contains(lower, "bad slot"),
+		strings.Contains(lower, "bad client slot"),
+		strings.Contains(lower, "is not active"):
+		return ErrBadSlot
+	case strings.Contains(lower, "is not on the server"),
+		strings.Contains(lower, "player not found"),
+		strings.Contains(lower, "no player"),
+		strings.Contains(lower, "no such player"),
+		strings.Contains(lower, "couldn't find player"),
+		strings.Contains(lower, "could not find player"):
+		return ErrPlayerNotFound
+	}
+	return nil
+}
+
+// Quote wraps an argument in double quotes so it is passed as a single token.
+// The console has no escape sequences, so embedded quotes and line breaks are
+// removed rather than escaped.
+func Quote(arg string) string {
+	return `"` + Sanitize(arg) + `"`
+}
+
+// Sanitize strips characters that would end the argument or start a new command
+func Sanitize(arg string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '"', '\n', '\r', 0:
+			return -1
+		}
+		return r
+	}, arg)
+}
+
+// IsIdentifier reports whether s is a safe map, gametype or cvar name
+func IsIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r == '_' || r == '-' || r == '.' ||
+			(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"strconv"
+	"strings"
+)
+
+type ServerInfo struct {
+	Hostname   string            `json:"hostname"`
+	MapName    string            `json:"mapName"`
+	Gametype   string            `json:"gametype"`
+	MaxClients int               `json:"maxClients"`
+	Version    string            `json:"version"`
+	Values     map[string]string `json:"values"`
+	Raw        string            `json:"-"`
+}
+
+func ParseServerInfo(response string) (*ServerInfo, error) {
+	info := &ServerInfo{
+		Values: make(map[string]string),
+		Raw:    response,
+	}
+
+	lines := strings.Split(response, "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasSuffix(line, "settings:") {
+			continue
+		}
+
+		parts := strings.Fields(line)
+		if len(parts) < 2 {
+			continue
+		}
+
+		key := parts[0]
+		value := strings.Join(parts[1:], " ")
+		info.Values[key] = value
+
+		switch key {
+		case "sv_hostname":
+			info.Hostname = value
+		case "mapname":
+			info.MapName = value
+		case "g_gametype":
+			info.Gametype = value
+		case "sv_maxclients":
+			if v, err := strconv.Atoi(value); err == nil {
+				info.MaxClients = v
+			}
+		case "version", "shortversion":
+			if info.Version == "" {
+				info.Version = value
+			}
+		}
+	}
+
+	return info, nil
+}
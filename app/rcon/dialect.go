@@ -0,0 +1,68 @@
+package rcon
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/rcon/commands"
+)
+
+// ErrUnsupported is returned when the server's game has no equivalent command
+var ErrUnsupported = errors.New("command not supported by this game")
+
+// Dialect describes the parts of the rcon protocol that differ between games:
+// the layout of the status table and the moderation command syntax.
+// All supported games share the same packet format.
+type Dialect interface {
+	ParseStatus(response string) (*StatusResponse, error)
+	KickCommand(target, reason string) string
+	BanCommand(target, reason string) string
+	TempBanCommand(target string, duration time.Duration, reason string) (string, error)
+	UnbanCommand(target string) string
+}
+
+// CoD4Dialect is the default dialect, covering stock CoD4 1.7/1.8 and CoD4x
+type CoD4Dialect struct{}
+
+func (CoD4Dialect) ParseStatus(response string) (*StatusResponse, error) {
+	return ParseStatus(response)
+}
+
+func (CoD4Dialect) KickCommand(target, reason string) string {
+	return withReason("clientkick "+commands.Quote(target), reason)
+}
+
+func (CoD4Dialect) BanCommand(target, reason string) string {
+	return withReason("banclient "+commands.Quote(target), reason)
+}
+
+// TempBanCommand rounds the duration up to whole minutes
+func (CoD4Dialect) TempBanCommand(target string, duration time.Duration, reason string) (string, error) {
+	minutes := int((duration + time.Minute - 1) / time.Minute)
+	if minutes < 1 {
+		minutes = 1
+	}
+
+	command := fmt.Sprintf("tempban %s %dm", commands.Quote(target), minutes)
+	return withReason(command, reason), nil
+}
+
+func (CoD4Dialect) UnbanCommand(target string) string {
+	return "unbanUser " + commands.Quote(target)
+}
+
+// withReason appends a quoted reason if one was given
+func withReason(command, reason string) string {
+	if reason == "" {
+		return command
+	}
+	return command + " " + commands.Quote(reason)
+}
+
+func (c *Client) dialect() Dialect {
+	if c.Dialect == nil {
+		return CoD4Dialect{}
+	}
+	return c.Dialect
+}
@@ -2,32 +2,85 @@ package rcon
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethanburkett/goadmin/app/config"
-	"github.com/ethanburkett/goadmin/app/parser"
 	"github.com/ethanburkett/goadmin/app/rcon/commands"
 )
 
+const (
+	// DefaultMaxResponseSize caps how many bytes of a multi-packet reply are kept
+	DefaultMaxResponseSize = 64 * 1024
+
+	// DefaultQuietInterval is how long to wait for a continuation packet before
+	// treating the reply as complete
+	DefaultQuietInterval = 250 * time.Millisecond
+
+	responseHeader = "\xFF\xFF\xFF\xFFprint\n"
+)
+
 type Client struct {
 	Host     string
 	Port     int
 	Password string
 	Timeout  time.Duration
-	conn     *net.UDPConn
+
+	// MaxResponseSize is the maximum number of bytes read for a single reply
+	MaxResponseSize int
+
+	// QuietInterval is the silence after the last packet that ends a reply
+	QuietInterval time.Duration
+
+	// CommandInterval is the minimum spacing between queued commands
+	CommandInterval time.Duration
+
+	// MaxAuthFailures is how many rejected commands in a row lock the client out
+	MaxAuthFailures int
+
+	// Dialect adapts status parsing and moderation commands to the server's game.
+	// Nil means CoD4.
+	Dialect Dialect
+
+	conn      *net.UDPConn
+	auth      authTracker
+	queue     *commandQueue
+	queueOnce sync.Once
+	queueMu   sync.Mutex // Guards queue, which startQueue sets while others read it
 }
 
 func NewClient(config *config.Config) *Client {
-	return &Client{
-		Host:     config.Server.Host,
-		Port:     config.Server.Port,
-		Password: config.Server.RconPassword,
-		Timeout:  5 * time.Second,
+	return NewClientFromServerConfig(config.Server)
+}
+
+// NewClientFromServerConfig creates a client for a single server's settings
+func NewClientFromServerConfig(server config.ServerConfig) *Client {
+	client := &Client{
+		Host:            server.Host,
+		Port:            server.Port,
+		Password:        server.RconPassword,
+		Timeout:         5 * time.Second,
+		MaxResponseSize: DefaultMaxResponseSize,
+		QuietInterval:   DefaultQuietInterval,
+		CommandInterval: DefaultCommandInterval,
+		MaxAuthFailures: DefaultMaxAuthFailures,
 	}
+
+	if server.RconMaxResponseSize > 0 {
+		client.MaxResponseSize = server.RconMaxResponseSize
+	}
+	if server.RconQuietIntervalMs > 0 {
+		client.QuietInterval = time.Duration(server.RconQuietIntervalMs) * time.Millisecond
+	}
+	if server.RconCommandIntervalMs > 0 {
+		client.CommandInterval = time.Duration(server.RconCommandIntervalMs) * time.Millisecond
+	}
+
+	return client
 }
 
 func (c *Client) Connect() error {
@@ -46,11 +99,27 @@ func (c *Client) Connect() error {
 }
 
 func (c *Client) SendCommand(command string) (string, error) {
-	return c.SendCommandWithTimeout(command, c.Timeout)
+	return c.SendCommandWithPriority(command, PriorityNormal)
+}
+
+// SendCommandWithPriority queues a command at the given priority and waits for its reply
+func (c *Client) SendCommandWithPriority(command string, priority Priority) (string, error) {
+	return c.enqueue(context.Background(), command, c.Timeout, priority)
 }
 
-// SendCommandWithTimeout sends a command with a custom timeout
+// SendCommandWithTimeout sends a command with a custom timeout.
+// The timeout covers the exchange with the server, not time spent in the queue.
 func (c *Client) SendCommandWithTimeout(command string, timeout time.Duration) (string, error) {
+	return c.enqueue(context.Background(), command, timeout, PriorityNormal)
+}
+
+// exchange performs a single request/response round-trip with the server.
+// Only the queue dispatcher calls this, so commands never overlap.
+func (c *Client) exchange(command string, timeout time.Duration) (string, error) {
+	if err := c.checkLockout(); err != nil {
+		return "", err
+	}
+
 	// Create a fresh connection for each command to avoid stale data
 	udpAddr, err := net.ResolveUDPAddr("udp", fmt.Sprintf("%s:%d", c.Host, c.Port))
 	if err != nil {
@@ -70,207 +139,86 @@ func (c *Client) SendCommandWithTimeout(command string, timeout time.Duration) (
 		return "", err
 	}
 
-	_ = conn.SetReadDeadline(time.Now().Add(timeout))
-
-	buf := make([]byte, 4096)
-	n, _, err := conn.ReadFromUDP(buf)
+	response, err := c.readResponse(conn, timeout)
 	if err != nil {
 		return "", err
 	}
 
-	response := string(buf[:n])
-
-	response = strings.TrimPrefix(response, "\xFF\xFF\xFF\xFFprint\n")
-
-	return response, nil
-}
-
-// SendCommandWithContext sends a command with context cancellation support
-func (c *Client) SendCommandWithContext(ctx context.Context, command string) (string, error) {
-	if c.conn == nil {
-		return "", fmt.Errorf("not connected")
-	}
-
-	type result struct {
-		response string
-		err      error
+	// Rejections arrive as ordinary print replies, so turn them into errors
+	authErr := detectAuthError(response)
+	c.recordAuthResult(authErr)
+	if authErr != nil {
+		return "", authErr
 	}
 
-	resultChan := make(chan result, 1)
-
-	go func() {
-		resp, err := c.SendCommand(command)
-		resultChan <- result{response: resp, err: err}
-	}()
-
-	select {
-	case <-ctx.Done():
-		return "", fmt.Errorf("command cancelled: %w", ctx.Err())
-	case res := <-resultChan:
-		return res.response, res.err
-	}
-}
-
-func (c *Client) Close() error {
-	if c.conn != nil {
-		return c.conn.Close()
-	}
-	return nil
-}
-
-type StatusPlayer struct {
-	ID           int    `json:"id"`
-	Score        int    `json:"score"`
-	Ping         int    `json:"ping"`
-	Uuid         string `json:"uuid"`
-	SteamID      string `json:"steamId"`
-	Name         string `json:"name"`
-	StrippedName string `json:"strippedName"`
-	Address      string `json:"address"`
-	QPort        int    `json:"qPort"`
-	Rate         int    `json:"rate"`
-}
-
-type StatusResponse struct {
-	Hostname string         `json:"hostname"`
-	Version  string         `json:"version"`
-	Address  string         `json:"address"`
-	OS       string         `json:"os"`
-	Type     string         `json:"type"`
-	Map      string         `json:"map"`
-	Players  []StatusPlayer `json:"players"`
+	return response, nil
 }
 
-func (c *Client) Status() (*StatusResponse, error) {
-	response, err := c.SendCommand("status")
-	if err != nil {
-		return nil, err
-	}
-
-	status := &StatusResponse{
-		Players: []StatusPlayer{},
+// readResponse reads a reply that may be split across several print packets.
+// The first packet must arrive within timeout; after that, packets are read
+// until QuietInterval passes without new data or MaxResponseSize is reached.
+func (c *Client) readResponse(conn *net.UDPConn, timeout time.Duration) (string, error) {
+	maxSize := c.MaxResponseSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxResponseSize
 	}
-
-	lines := strings.Split(response, "\n")
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-
-		if strings.HasPrefix(line, "hostname:") {
-			status.Hostname = strings.TrimSpace(strings.TrimPrefix(line, "hostname:"))
-		} else if strings.HasPrefix(line, "version :") {
-			status.Version = strings.TrimSpace(strings.TrimPrefix(line, "version :"))
-		} else if strings.HasPrefix(line, "udp/ip  :") {
-			status.Address = strings.TrimSpace(strings.TrimPrefix(line, "udp/ip  :"))
-		} else if strings.HasPrefix(line, "os      :") {
-			status.OS = strings.TrimSpace(strings.TrimPrefix(line, "os      :"))
-		} else if strings.HasPrefix(line, "type    :") {
-			status.Type = strings.TrimSpace(strings.TrimPrefix(line, "type    :"))
-		} else if strings.HasPrefix(line, "map     :") {
-			status.Map = strings.TrimSpace(strings.TrimPrefix(line, "map     :"))
-		}
+	quiet := c.QuietInterval
+	if quiet <= 0 {
+		quiet = DefaultQuietInterval
 	}
 
-	playerDataStarted := false
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-
-		if line == "" {
-			continue
-		}
+	var response strings.Builder
+	buf := make([]byte, 65535)
 
-		if strings.HasPrefix(line, "num score ping") {
-			playerDataStarted = true
-			continue
+	_ = conn.SetReadDeadline(time.Now().Add(timeout))
+	for packets := 0; ; packets++ {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			// A timeout after at least one packet just means the reply is complete
+			var netErr net.Error
+			if packets > 0 && errors.As(err, &netErr) && netErr.Timeout() {
+				break
+			}
+			return "", err
 		}
 
-		if strings.HasPrefix(line, "---") {
-			continue
+		chunk := strings.TrimPrefix(string(buf[:n]), responseHeader)
+		if response.Len()+len(chunk) > maxSize {
+			response.WriteString(chunk[:maxSize-response.Len()])
+			break
 		}
+		response.WriteString(chunk)
 
-		if playerDataStarted {
-			player := parsePlayerLine(line)
-			if player != nil {
-				status.Players = append(status.Players, *player)
-			}
-		}
+		_ = conn.SetReadDeadline(time.Now().Add(quiet))
 	}
 
-	return status, nil
+	return response.String(), nil
 }
 
-func parsePlayerLine(line string) *StatusPlayer {
-	fields := strings.Fields(line)
-
-	if len(fields) < 9 {
-		return nil
-	}
-
-	player := &StatusPlayer{}
-
-	if id, err := strconv.Atoi(fields[0]); err == nil {
-		player.ID = id
-	}
-
-	if score, err := strconv.Atoi(fields[1]); err == nil {
-		player.Score = score
-	}
-
-	if ping, err := strconv.Atoi(fields[2]); err == nil {
-		player.Ping = ping
+// SendCommandWithContext sends a command with context cancellation support
+func (c *Client) SendCommandWithContext(ctx context.Context, command string) (string, error) {
+	if c.conn == nil {
+		return "", fmt.Errorf("not connected")
 	}
 
-	player.Uuid = fields[3]
-
-	player.SteamID = fields[4]
+	return c.enqueue(ctx, command, c.Timeout, PriorityNormal)
+}
 
-	nameStart := 5
-	addressIndex := -1
+func (c *Client) Close() error {
+	if q := c.currentQueue(); q != nil {
+		q.stop()
 
-	for i := 5; i < len(fields); i++ {
-		if strings.Contains(fields[i], ":") && strings.Contains(fields[i], ".") {
-			addressIndex = i
-			break
-		}
-	}
-	if addressIndex > 6 {
-		player.Name = strings.Join(fields[nameStart:addressIndex-1], " ")
-	} else if addressIndex == 6 {
-		player.Name = fields[5]
+		activeClientsMu.Lock()
+		delete(activeClients, c)
+		activeClientsMu.Unlock()
 	}
 
-	if addressIndex != -1 && addressIndex < len(fields) {
-		// Strip port from IP address
-		address := fields[addressIndex]
-		if colonIdx := strings.LastIndex(address, ":"); colonIdx != -1 {
-			player.Address = address[:colonIdx]
-		} else {
-			player.Address = address
-		}
-
-		if addressIndex+1 < len(fields) {
-			if qport, err := strconv.Atoi(fields[addressIndex+1]); err == nil {
-				player.QPort = qport
-			}
-		}
-
-		if addressIndex+2 < len(fields) {
-			if rate, err := strconv.Atoi(fields[addressIndex+2]); err == nil {
-				player.Rate = rate
-			}
-		}
+	if c.conn != nil {
+		return c.conn.Close()
 	}
-
-	player.StrippedName = parser.StripColorCodes(player.Name)
-
-	return player
+	return nil
 }
 
 func (c *Client) GetPlayer(playerID string) (*commands.DumpUserInfo, error) {
-	response, err := c.SendCommand(fmt.Sprintf("dumpuser %s", playerID))
-	if err != nil {
-		return nil, err
-	}
-
-	return commands.ParseDumpUser(response)
+	return c.DumpUser(playerID)
 }
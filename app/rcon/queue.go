@@ -0,0 +1,290 @@
+package rcon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Priority controls the order in which queued commands are sent to the server
+type Priority int
+
+const (
+	PriorityModeration Priority = iota // kicks, bans
+	PriorityChat                       // say, tell
+	PriorityNormal                     // everything else
+	PriorityStats                      // background status polling
+	priorityCount
+)
+
+// DefaultCommandInterval is the minimum spacing between two commands sent to
+// the same server. CoD4 throttles rcon at roughly one command per 500ms.
+const DefaultCommandInterval = 500 * time.Millisecond
+
+// ErrClientClosed is returned for commands sent after Close
+var ErrClientClosed = errors.New("rcon client closed")
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityModeration:
+		return "moderation"
+	case PriorityChat:
+		return "chat"
+	case PriorityNormal:
+		return "normal"
+	case PriorityStats:
+		return "stats"
+	default:
+		return fmt.Sprintf("priority(%d)", int(p))
+	}
+}
+
+type commandResult struct {
+	response string
+	err      error
+}
+
+type queuedCommand struct {
+	ctx      context.Context
+	command  string
+	timeout  time.Duration
+	priority Priority
+	enqueued time.Time
+	result   chan commandResult
+}
+
+// commandQueue serializes all commands for one server through a single
+// dispatcher goroutine
+type commandQueue struct {
+	mu      sync.Mutex
+	pending [priorityCount][]*queuedCommand
+	notify  chan struct{}
+	done    chan struct{}
+	closed  bool
+
+	processed uint64
+	totalWait time.Duration
+	maxWait   time.Duration
+	lastWait  time.Duration
+}
+
+// QueueStats describes the state of a client's command queue
+type QueueStats struct {
+	Server          string         `json:"server"`
+	Depth           int            `json:"depth"`
+	DepthByPriority map[string]int `json:"depthByPriority"`
+	Processed       uint64         `json:"processed"`
+	AvgWaitMs       float64        `json:"avgWaitMs"`
+	MaxWaitMs       float64        `json:"maxWaitMs"`
+	LastWaitMs      float64        `json:"lastWaitMs"`
+}
+
+var (
+	activeClientsMu sync.RWMutex
+	activeClients   = make(map[*Client]struct{})
+)
+
+// AllQueueStats returns queue statistics for every client with a running dispatcher
+func AllQueueStats() []QueueStats {
+	activeClientsMu.RLock()
+	defer activeClientsMu.RUnlock()
+
+	stats := make([]QueueStats, 0, len(activeClients))
+	for client := range activeClients {
+		stats = append(stats, client.QueueStats())
+	}
+	return stats
+}
+
+// startQueue lazily creates the dispatcher so clients built as struct literals still work
+func (c *Client) startQueue() *commandQueue {
+	c.queueOnce.Do(func() {
+		q := &commandQueue{
+			notify: make(chan struct{}, 1),
+			done:   make(chan struct{}),
+		}
+		c.queueMu.Lock()
+		c.queue = q
+		c.queueMu.Unlock()
+
+		activeClientsMu.Lock()
+		activeClients[c] = struct{}{}
+		activeClientsMu.Unlock()
+
+		go c.dispatch(q)
+	})
+	return c.currentQueue()
+}
+
+// currentQueue returns the queue, or nil if no command has been sent yet
+func (c *Client) currentQueue() *commandQueue {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+	return c.queue
+}
+
+// enqueue adds a command to the queue and blocks until its reply is available
+func (c *Client) enqueue(ctx context.Context, command string, timeout time.Duration, priority Priority) (string, error) {
+	if priority < 0 || priority >= priorityCount {
+		priority = PriorityNormal
+	}
+
+	q := c.startQueue()
+	item := &queuedCommand{
+		ctx:      ctx,
+		command:  command,
+		timeout:  timeout,
+		priority: priority,
+		enqueued: time.Now(),
+		result:   make(chan commandResult, 1),
+	}
+
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return "", ErrClientClosed
+	}
+	q.pending[priority] = append(q.pending[priority], item)
+	q.mu.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+
+	select {
+	case res := <-item.result:
+		return res.response, res.err
+	case <-ctx.Done():
+		return "", fmt.Errorf("command cancelled: %w", ctx.Err())
+	}
+}
+
+// next pops the highest priority command, or nil if the queue is empty
+func (q *commandQueue) next() *queuedCommand {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for p := range q.pending {
+		if len(q.pending[p]) > 0 {
+			item := q.pending[p][0]
+			q.pending[p][0] = nil
+			q.pending[p] = q.pending[p][1:]
+			return item
+		}
+	}
+	return nil
+}
+
+// dispatch sends queued commands one at a time, spaced by CommandInterval
+func (c *Client) dispatch(q *commandQueue) {
+	var lastSent time.Time
+
+	for {
+		item := q.next()
+		if item == nil {
+			select {
+			case <-q.notify:
+				continue
+			case <-q.done:
+				return
+			}
+		}
+
+		// Skip commands whose caller already gave up
+		if err := item.ctx.Err(); err != nil {
+			item.result <- commandResult{err: fmt.Errorf("command cancelled: %w", err)}
+			continue
+		}
+
+		// Fail fast while locked out instead of waiting for a send slot
+		if err := c.checkLockout(); err != nil {
+			item.result <- commandResult{err: err}
+			continue
+		}
+
+		interval := c.CommandInterval
+		if interval <= 0 {
+			interval = DefaultCommandInterval
+		}
+		if wait := time.Until(lastSent.Add(interval)); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-q.done:
+				item.result <- commandResult{err: ErrClientClosed}
+				return
+			}
+		}
+
+		q.recordWait(time.Since(item.enqueued))
+
+		response, err := c.exchange(item.command, item.timeout)
+		lastSent = time.Now()
+		item.result <- commandResult{response: response, err: err}
+	}
+}
+
+func (q *commandQueue) recordWait(wait time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.processed++
+	q.totalWait += wait
+	q.lastWait = wait
+	if wait > q.maxWait {
+		q.maxWait = wait
+	}
+}
+
+// stop shuts down the dispatcher and fails any commands still waiting
+func (q *commandQueue) stop() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	pending := q.pending
+	q.pending = [priorityCount][]*queuedCommand{}
+	q.mu.Unlock()
+
+	close(q.done)
+
+	for _, items := range pending {
+		for _, item := range items {
+			item.result <- commandResult{err: ErrClientClosed}
+		}
+	}
+}
+
+// QueueStats returns the current depth and wait times of the command queue
+func (c *Client) QueueStats() QueueStats {
+	stats := QueueStats{
+		Server:          fmt.Sprintf("%s:%d", c.Host, c.Port),
+		DepthByPriority: make(map[string]int),
+	}
+
+	q := c.currentQueue()
+	if q == nil {
+		return stats
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for p, items := range q.pending {
+		stats.DepthByPriority[Priority(p).String()] = len(items)
+		stats.Depth += len(items)
+	}
+
+	stats.Processed = q.processed
+	if q.processed > 0 {
+		stats.AvgWaitMs = float64(q.totalWait.Milliseconds()) / float64(q.processed)
+	}
+	stats.MaxWaitMs = float64(q.maxWait.Milliseconds())
+	stats.LastWaitMs = float64(q.lastWait.Milliseconds())
+
+	return stats
+}
@@ -0,0 +1,175 @@
+package rcon
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/rcon/commands"
+)
+
+var (
+	// ErrPlayerNotFound is returned when the target player isn't on the server
+	ErrPlayerNotFound = commands.ErrPlayerNotFound
+
+	// ErrBadSlot is returned when a client slot is out of range or empty
+	ErrBadSlot = commands.ErrBadSlot
+
+	// ErrUnknownCvar is returned when a cvar doesn't exist on the server
+	ErrUnknownCvar = commands.ErrUnknownCvar
+)
+
+// CommandResult is the command that was sent and the server's raw reply.
+// Command is always set, even when an error is returned, so callers can log it.
+type CommandResult struct {
+	Command  string `json:"command"`
+	Response string `json:"response"`
+}
+
+// run sends a command and converts error replies into typed errors. Chat
+// replies echo the message back, so they aren't checked: a player's text
+// could otherwise read as an error.
+func (c *Client) run(command string, priority Priority) (CommandResult, error) {
+	result := CommandResult{Command: command}
+
+	response, err := c.SendCommandWithPriority(command, priority)
+	if err != nil {
+		return result, err
+	}
+	result.Response = response
+
+	if priority == PriorityChat {
+		return result, nil
+	}
+	if err := commands.CheckReply(response); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// Kick removes a player by slot number
+func (c *Client) Kick(target, reason string) (CommandResult, error) {
+	return c.run(c.dialect().KickCommand(target, reason), PriorityModeration)
+}
+
+// BanClient permanently bans a player by slot number
+func (c *Client) BanClient(target, reason string) (CommandResult, error) {
+	return c.run(c.dialect().BanCommand(target, reason), PriorityModeration)
+}
+
+// TempBanUser bans a player for the given duration
+func (c *Client) TempBanUser(target string, duration time.Duration, reason string) (CommandResult, error) {
+	command, err := c.dialect().TempBanCommand(target, duration, reason)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	return c.run(command, PriorityModeration)
+}
+
+// UnbanUser lifts a ban by player name or GUID
+func (c *Client) UnbanUser(target string) (CommandResult, error) {
+	return c.run(c.dialect().UnbanCommand(target), PriorityModeration)
+}
+
+// Tell sends a private message to a player by slot number or name
+func (c *Client) Tell(target, message string) (CommandResult, error) {
+	return c.run("tell "+commands.Quote(target)+" "+commands.Quote(message), PriorityChat)
+}
+
+// Say broadcasts a message to all players
+func (c *Client) Say(message string) (CommandResult, error) {
+	return c.run("say "+commands.Quote(message), PriorityChat)
+}
+
+// MapRotate switches to the next map in sv_mapRotation
+func (c *Client) MapRotate() (CommandResult, error) {
+	return c.run("map_rotate", PriorityNormal)
+}
+
+// Map loads the given map
+func (c *Client) Map(mapName string) (CommandResult, error) {
+	if !commands.IsIdentifier(mapName) {
+		return CommandResult{}, fmt.Errorf("invalid map name: %q", mapName)
+	}
+	return c.run("map "+mapName, PriorityNormal)
+}
+
+// SetCvar sets a server cvar
+func (c *Client) SetCvar(name, value string) (CommandResult, error) {
+	if !commands.IsIdentifier(name) {
+		return CommandResult{}, fmt.Errorf("invalid cvar name: %q", name)
+	}
+	return c.run("set "+name+" "+commands.Quote(value), PriorityNormal)
+}
+
+// ExecConfig runs a config file from the server's game folder
+func (c *Client) ExecConfig(filename string) (CommandResult, error) {
+	if !commands.IsIdentifier(filename) {
+		return CommandResult{}, fmt.Errorf("invalid config file name: %q", filename)
+	}
+	return c.run("exec "+filename, PriorityNormal)
+}
+
+// WriteConfig saves the server's cvars to a config file
+func (c *Client) WriteConfig(filename string) (CommandResult, error) {
+	if !commands.IsIdentifier(filename) {
+		return CommandResult{}, fmt.Errorf("invalid config file name: %q", filename)
+	}
+	return c.run("writeconfig "+filename, PriorityNormal)
+}
+
+// GetCvar reads the current and default value of a cvar
+func (c *Client) GetCvar(name string) (*commands.Cvar, error) {
+	if !commands.IsIdentifier(name) {
+		return nil, fmt.Errorf("invalid cvar name: %q", name)
+	}
+
+	response, err := c.SendCommand(name)
+	if err != nil {
+		return nil, err
+	}
+
+	cvar, err := commands.ParseCvar(response)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, name)
+	}
+	return cvar, nil
+}
+
+// ServerInfo fetches and parses the serverinfo command
+func (c *Client) ServerInfo() (*commands.ServerInfo, error) {
+	response, err := c.SendCommand("serverinfo")
+	if err != nil {
+		return nil, err
+	}
+	return commands.ParseServerInfo(response)
+}
+
+// DumpUser fetches the userinfo of a player by slot number or name
+func (c *Client) DumpUser(target string) (*commands.DumpUserInfo, error) {
+	response, err := c.SendCommand("dumpuser " + commands.Quote(target))
+	if err != nil {
+		return nil, err
+	}
+	if err := commands.CheckReply(response); err != nil {
+		return nil, err
+	}
+
+	info, err := commands.ParseDumpUser(response)
+	if err != nil {
+		return nil, err
+	}
+	// An empty userinfo means the server had nobody to dump
+	if info.Name == "" && info.IP == "" {
+		return nil, ErrPlayerNotFound
+	}
+	return info, nil
+}
+
+// DumpBanList fetches the server's ban list
+func (c *Client) DumpBanList() ([]commands.BanListEntry, error) {
+	response, err := c.SendCommand("dumpbanlist")
+	if err != nil {
+		return nil, err
+	}
+	return commands.ParseBanList(response)
+}
@@ -0,0 +1,264 @@
+package rcon
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ethanburkett/goadmin/app/parser"
+)
+
+// Player connection states reported in the ping column
+const (
+	PlayerStateActive     = "active"
+	PlayerStateConnecting = "connecting"
+	PlayerStateZombie     = "zombie"
+)
+
+type StatusPlayer struct {
+	ID           int    `json:"id"`
+	Score        int    `json:"score"`
+	Ping         int    `json:"ping"`
+	Uuid         string `json:"uuid"`
+	SteamID      string `json:"steamId"`
+	Name         string `json:"name"`
+	StrippedName string `json:"strippedName"`
+	LastMsg      int    `json:"lastMsg"`
+	Address      string `json:"address"`
+	QPort        int    `json:"qPort"`
+	Rate         int    `json:"rate"`
+	State        string `json:"state"`
+	IsBot        bool   `json:"isBot"`
+}
+
+type StatusResponse struct {
+	Hostname string         `json:"hostname"`
+	Version  string         `json:"version"`
+	Address  string         `json:"address"`
+	OS       string         `json:"os"`
+	Type     string         `json:"type"`
+	Map      string         `json:"map"`
+	Players  []StatusPlayer `json:"players"`
+}
+
+// ActivePlayers returns the number of fully connected players, bots included
+func (s *StatusResponse) ActivePlayers() int {
+	count := 0
+	for _, p := range s.Players {
+		if p.State == PlayerStateActive {
+			count++
+		}
+	}
+	return count
+}
+
+func (c *Client) Status() (*StatusResponse, error) {
+	return c.StatusWithPriority(PriorityNormal)
+}
+
+// StatusWithPriority queues the status command at the given priority and
+// parses the reply with the server's dialect
+func (c *Client) StatusWithPriority(priority Priority) (*StatusResponse, error) {
+	response, err := c.SendCommandWithPriority("status", priority)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.dialect().ParseStatus(response)
+}
+
+// Column layouts used when a reply has no header row. 1.7 servers print a
+// single guid column, CoD4x 1.8 prints playerid and steamid.
+var (
+	legacyColumns = []string{"num", "score", "ping", "guid", "name", "lastmsg", "address", "qport", "rate"}
+	cod4xColumns  = []string{"num", "score", "ping", "playerid", "steamid", "name", "lastmsg", "address", "qport", "rate"}
+)
+
+// ParseStatus parses the reply to the status command.
+//
+// The player table is read using the header row: columns before "name" are
+// taken from the left of each line and columns after it from the right, so
+// whatever is left in between is the name, spaces and all.
+func ParseStatus(response string) (*StatusResponse, error) {
+	status := &StatusResponse{
+		Players: []StatusPlayer{},
+	}
+
+	var columns []string
+	inTable := false
+
+	lines := strings.Split(strings.ReplaceAll(response, "\r\n", "\n"), "\n")
+	for _, raw := range lines {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+
+		if !inTable {
+			if strings.HasPrefix(line, "num ") && strings.Contains(line, " name") {
+				columns = strings.Fields(line)
+				inTable = true
+				continue
+			}
+			parseStatusHeader(status, line)
+			continue
+		}
+
+		if strings.HasPrefix(line, "---") {
+			continue
+		}
+
+		if player := parseStatusRow(line, columns); player != nil {
+			status.Players = append(status.Players, *player)
+		}
+	}
+
+	return status, nil
+}
+
+func parseStatusHeader(status *StatusResponse, line string) {
+	key, value, found := strings.Cut(line, ":")
+	if !found {
+		return
+	}
+	value = strings.TrimSpace(value)
+
+	switch strings.TrimSpace(key) {
+	case "hostname":
+		status.Hostname = value
+	case "version":
+		status.Version = value
+	case "udp/ip":
+		status.Address = value
+	case "os":
+		status.OS = value
+	case "type":
+		status.Type = value
+	case "map":
+		status.Map = value
+	}
+}
+
+// parseStatusRow parses one player line. Rows that don't start with a slot
+// number or have too few fields are ignored.
+func parseStatusRow(line string, columns []string) *StatusPlayer {
+	spans := fieldSpans(line)
+	if len(spans) == 0 {
+		return nil
+	}
+	if _, err := strconv.Atoi(line[spans[0][0]:spans[0][1]]); err != nil {
+		return nil
+	}
+
+	if len(columns) == 0 {
+		columns = legacyColumns
+		if len(spans) >= len(cod4xColumns) {
+			columns = cod4xColumns
+		}
+	}
+
+	nameIndex := -1
+	for i, col := range columns {
+		if col == "name" {
+			nameIndex = i
+			break
+		}
+	}
+	if nameIndex < 0 {
+		return nil
+	}
+
+	leading := columns[:nameIndex]
+	trailing := columns[nameIndex+1:]
+	if len(spans) < len(leading)+len(trailing) {
+		return nil
+	}
+
+	values := make(map[string]string, len(columns))
+	for i, col := range leading {
+		values[col] = line[spans[i][0]:spans[i][1]]
+	}
+	for i, col := range trailing {
+		span := spans[len(spans)-len(trailing)+i]
+		values[col] = line[span[0]:span[1]]
+	}
+
+	nameStart := len(line)
+	if len(spans) > len(leading) {
+		nameStart = spans[len(leading)][0]
+	}
+	nameEnd := nameStart
+	if n := len(spans) - len(trailing); n > len(leading) {
+		nameEnd = spans[n-1][1]
+	}
+
+	player := &StatusPlayer{
+		Name:  line[nameStart:nameEnd],
+		State: PlayerStateActive,
+	}
+
+	player.ID, _ = strconv.Atoi(values["num"])
+	player.Score, _ = strconv.Atoi(values["score"])
+
+	switch ping := values["ping"]; strings.ToUpper(ping) {
+	case "CNCT":
+		player.State = PlayerStateConnecting
+	case "ZMBI":
+		player.State = PlayerStateZombie
+	default:
+		player.Ping, _ = strconv.Atoi(ping)
+	}
+
+	if guid, ok := values["guid"]; ok {
+		player.Uuid = guid
+	} else {
+		player.Uuid = values["playerid"]
+	}
+	player.SteamID = values["steamid"]
+
+	player.LastMsg, _ = strconv.Atoi(values["lastmsg"])
+	player.QPort, _ = strconv.Atoi(values["qport"])
+	player.Rate, _ = strconv.Atoi(values["rate"])
+
+	address := values["address"]
+	player.IsBot = strings.EqualFold(address, "bot")
+	player.Address = stripPort(address)
+
+	player.StrippedName = parser.StripColorCodes(player.Name)
+
+	return player
+}
+
+// fieldSpans returns the start and end offsets of each whitespace separated field
+func fieldSpans(line string) [][2]int {
+	var spans [][2]int
+	start := -1
+	for i := 0; i < len(line); i++ {
+		isSpace := line[i] == ' ' || line[i] == '\t'
+		if !isSpace && start < 0 {
+			start = i
+		} else if isSpace && start >= 0 {
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(line)})
+	}
+	return spans
+}
+
+// stripPort removes the port from an address. Bracketed IPv6 addresses lose
+// their brackets; bare IPv6 and non-network addresses such as "bot" and
+// "loopback" are returned unchanged.
+func stripPort(address string) string {
+	if strings.HasPrefix(address, "[") {
+		if end := strings.Index(address, "]"); end > 0 {
+			return address[1:end]
+		}
+		return address
+	}
+	if strings.Count(address, ":") == 1 {
+		return address[:strings.Index(address, ":")]
+	}
+	return address
+}
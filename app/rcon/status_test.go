@@ -0,0 +1,66 @@
+package rcon
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite status golden files with the current parser output")
+
+// TestParseStatusFixtures checks ParseStatus against the dumps in
+// testdata/status. Each <name>.txt has a <name>.json golden file holding the
+// expected result; run with -update after an intentional parser change and
+// review the diff.
+func TestParseStatusFixtures(t *testing.T) {
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "status", "*.txt"))
+	if err != nil {
+		t.Fatalf("list fixtures: %v", err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatal("no fixtures found in testdata/status")
+	}
+
+	for _, fixture := range fixtures {
+		name := strings.TrimSuffix(filepath.Base(fixture), ".txt")
+		golden := strings.TrimSuffix(fixture, ".txt") + ".json"
+
+		t.Run(name, func(t *testing.T) {
+			input, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("read fixture: %v", err)
+			}
+
+			status, err := ParseStatus(string(input))
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+
+			actual, err := json.MarshalIndent(status, "", "  ")
+			if err != nil {
+				t.Fatalf("encode result: %v", err)
+			}
+			actual = append(actual, '\n')
+
+			if *update {
+				if err := os.WriteFile(golden, actual, 0644); err != nil {
+					t.Fatalf("write golden file: %v", err)
+				}
+				return
+			}
+
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("read golden file (run with -update): %v", err)
+			}
+			expected = bytes.ReplaceAll(expected, []byte("\r\n"), []byte("\n"))
+			if !bytes.Equal(expected, actual) {
+				t.Errorf("output differs from %s\n--- expected\n%s\n--- actual\n%s", filepath.Base(golden), expected, actual)
+			}
+		})
+	}
+}
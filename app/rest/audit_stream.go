@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ethanburkett/goadmin/app/events"
 	"github.com/ethanburkett/goadmin/app/logger"
 	"github.com/ethanburkett/goadmin/app/models"
 	"github.com/gin-gonic/gin"
@@ -25,7 +26,7 @@ var upgrader = websocket.Upgrader{
 // AuditStreamManager manages WebSocket connections for real-time audit log streaming
 type AuditStreamManager struct {
 	clients    map[*websocket.Conn]bool
-	broadcast  chan *models.AuditLog
+	broadcast  chan interface{} // *models.AuditLog or eventMessage
 	register   chan *websocket.Conn
 	unregister chan *websocket.Conn
 	mu         sync.RWMutex
@@ -38,7 +39,7 @@ var GlobalAuditStreamManager *AuditStreamManager
 func InitAuditStreamManager() {
 	GlobalAuditStreamManager = &AuditStreamManager{
 		clients:    make(map[*websocket.Conn]bool),
-		broadcast:  make(chan *models.AuditLog, 100), // Buffer for 100 messages
+		broadcast:  make(chan interface{}, 100), // Buffer for 100 messages
 		register:   make(chan *websocket.Conn),
 		unregister: make(chan *websocket.Conn),
 	}
@@ -66,11 +67,11 @@ func (asm *AuditStreamManager) run() {
 			}
 			asm.mu.Unlock()
 
-		case log := <-asm.broadcast:
+		case msg := <-asm.broadcast:
 			asm.mu.RLock()
 			for conn := range asm.clients {
 				// Send with timeout to prevent blocking
-				go func(c *websocket.Conn, l *models.AuditLog) {
+				go func(c *websocket.Conn, l interface{}) {
 					if err := c.SetWriteDeadline(time.Now().Add(10 * time.Second)); err != nil {
 						logger.Error("Failed to set write deadline", zap.Error(err))
 						asm.unregister <- c
@@ -83,7 +84,7 @@ func (asm *AuditStreamManager) run() {
 							zap.String("remote_addr", c.RemoteAddr().String()))
 						asm.unregister <- c
 					}
-				}(conn, log)
+				}(conn, msg)
 			}
 			asm.mu.RUnlock()
 		}
@@ -102,6 +103,28 @@ func (asm *AuditStreamManager) BroadcastAuditLog(log *models.AuditLog) {
 	}
 }
 
+// eventMessage wraps an event on the audit stream. Clients tell it apart
+// from audit logs by the "type" field.
+type eventMessage struct {
+	Type  string       `json:"type"`
+	Event events.Event `json:"event"`
+}
+
+// BroadcastEvent sends an event from the event stream to all connected
+// clients. Damage and weapon events are skipped as too noisy.
+func (asm *AuditStreamManager) BroadcastEvent(event events.Event) {
+	if event.Type.IsHighVolume() {
+		return
+	}
+
+	select {
+	case asm.broadcast <- eventMessage{Type: "event", Event: event}:
+	default:
+		logger.Warn("Audit stream broadcast channel is full, dropping event",
+			zap.String("event", string(event.Type)))
+	}
+}
+
 // handleAuditStream handles WebSocket connections for real-time audit log streaming
 func handleAuditStream(api *Api) gin.HandlerFunc {
 	return func(c *gin.Context) {
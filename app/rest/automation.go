@@ -0,0 +1,305 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ethanburkett/goadmin/app/automation"
+	"github.com/ethanburkett/goadmin/app/logger"
+	"github.com/ethanburkett/goadmin/app/models"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type AutomationRuleRequest struct {
+	Name            string                       `json:"name" binding:"required"`
+	Trigger         string                       `json:"trigger" binding:"required"` // Event type, or "snapshot"
+	ServerID        *uint                        `json:"serverId"`                   // Omit to match every server
+	Conditions      []models.AutomationCondition `json:"conditions"`
+	Actions         []models.AutomationAction    `json:"actions" binding:"required"`
+	CooldownSeconds int                          `json:"cooldownSeconds"`
+	DryRun          bool                         `json:"dryRun"`
+	Enabled         *bool                        `json:"enabled"`
+}
+
+func RegisterAutomationRoutes(r *gin.Engine, api *Api) {
+	rules := r.Group("/automation")
+	rules.Use(AuthMiddleware())
+	{
+		rules.GET("/triggers", RequirePermission("automation.view"), getAutomationTriggers(api))
+		rules.GET("/rules", RequirePermission("automation.view"), getAutomationRules(api))
+		rules.GET("/rules/:id", RequirePermission("automation.view"), getAutomationRule(api))
+		rules.GET("/rules/:id/runs", RequirePermission("automation.view"), getAutomationRuns(api))
+
+		rules.POST("/rules", RequirePermission("automation.manage"), saveAutomationRule(api))
+		rules.PUT("/rules/:id", RequirePermission("automation.manage"), saveAutomationRule(api))
+		rules.DELETE("/rules/:id", RequirePermission("automation.manage"), deleteAutomationRule(api))
+	}
+}
+
+// reloadAutomation applies rule changes to the running engine
+func reloadAutomation() {
+	if automation.Global == nil {
+		return
+	}
+	if err := automation.Global.Reload(); err != nil {
+		logger.Error("Failed to reload automation rules", zap.Error(err))
+	}
+}
+
+// getAutomationTriggers lists what rules can fire on
+func getAutomationTriggers(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("data", gin.H{"triggers": automation.Triggers})
+		c.Status(http.StatusOK)
+	}
+}
+
+func getAutomationRules(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rules, err := models.GetAutomationRules()
+		if err != nil {
+			c.Set("error", "Failed to retrieve automation rules")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{"rules": rules})
+		c.Status(http.StatusOK)
+	}
+}
+
+func getAutomationRule(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid rule ID")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		rule, err := models.GetAutomationRule(uint(id))
+		if err != nil {
+			c.Set("error", "Rule not found")
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		runs, err := models.GetAutomationRuns(rule.ID, 10)
+		if err != nil {
+			c.Set("error", "Failed to retrieve rule runs")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{
+			"rule": rule,
+			"runs": runs,
+		})
+		c.Status(http.StatusOK)
+	}
+}
+
+func getAutomationRuns(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid rule ID")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit <= 0 || limit > 100 {
+			limit = 50
+		}
+
+		runs, err := models.GetAutomationRuns(uint(id), limit)
+		if err != nil {
+			c.Set("error", "Failed to retrieve rule runs")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{"runs": runs})
+		c.Status(http.StatusOK)
+	}
+}
+
+// saveAutomationRule creates a rule, or replaces one when called with an ID
+func saveAutomationRule(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req AutomationRuleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		userVal, exists := c.Get("user")
+		if !exists {
+			c.Set("error", "User not found")
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+		user := userVal.(*models.User)
+
+		rule := &models.AutomationRule{Enabled: true, CreatedByName: user.Username}
+		status := http.StatusCreated
+		if c.Param("id") != "" {
+			id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+			if err != nil {
+				c.Set("error", "Invalid rule ID")
+				c.Status(http.StatusBadRequest)
+				return
+			}
+			rule, err = models.GetAutomationRule(uint(id))
+			if err != nil {
+				c.Set("error", "Rule not found")
+				c.Status(http.StatusNotFound)
+				return
+			}
+			status = http.StatusOK
+		}
+
+		rule.Name = req.Name
+		rule.Trigger = req.Trigger
+		rule.ServerID = req.ServerID
+		rule.Conditions = req.Conditions
+		rule.Actions = req.Actions
+		rule.CooldownSeconds = req.CooldownSeconds
+		rule.DryRun = req.DryRun
+		if req.Enabled != nil {
+			rule.Enabled = *req.Enabled
+		}
+
+		if !automation.IsTrigger(rule.Trigger) {
+			c.Set("error", fmt.Sprintf("Unknown trigger: %s", rule.Trigger))
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		if rule.ServerID != nil {
+			if _, err := models.GetServerByID(*rule.ServerID); err != nil {
+				c.Set("error", "Server not found")
+				c.Status(http.StatusBadRequest)
+				return
+			}
+		}
+
+		// Group actions can only grant what the author could assign by
+		// hand, never an admin group, and never to an admin
+		for i, action := range rule.Actions {
+			if action.Type != models.AutomationGroup {
+				continue
+			}
+			if !user.HasPermission("groups.manage") {
+				c.Set("error", fmt.Sprintf("Action %d: assigning groups requires the groups.manage permission", i+1))
+				c.Status(http.StatusForbidden)
+				return
+			}
+			group, err := automation.AssignableGroup(action.Value)
+			if err != nil {
+				c.Set("error", fmt.Sprintf("Action %d: %v", i+1, err))
+				c.Status(http.StatusBadRequest)
+				return
+			}
+			if target := strings.TrimSpace(action.Target); target != "" && !strings.ContainsAny(target, "{}") {
+				if err := automation.AssignableTarget(target, group); err != nil {
+					c.Set("error", fmt.Sprintf("Action %d: %v", i+1, err))
+					c.Status(http.StatusBadRequest)
+					return
+				}
+			}
+			rule.Actions[i].Value = group.Name
+		}
+
+		// Raw commands get the same checks as the RCON console
+		for i, action := range rule.Actions {
+			if action.Type != models.AutomationRcon {
+				continue
+			}
+			sanitized, err := ValidateRconCommand(action.Value)
+			if err != nil {
+				if CommandValidatorInstance.IsRestrictedCommand(action.Value) {
+					Audit.LogSecurityViolation(c, "restricted_command_attempt", action.Value, err.Error())
+				}
+				c.Set("error", fmt.Sprintf("Action %d: invalid command: %v", i+1, err))
+				c.Status(http.StatusBadRequest)
+				return
+			}
+			rule.Actions[i].Value = sanitized
+		}
+
+		if err := rule.Validate(); err != nil {
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		err := models.SaveAutomationRule(rule)
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		Audit.LogAction(c, models.ActionAutomationConfig, models.SourceWebUI, err == nil, errMsg,
+			"automation_rule", fmt.Sprintf("%d", rule.ID), rule.Name,
+			map[string]interface{}{
+				"trigger":    rule.Trigger,
+				"server_id":  rule.ServerID,
+				"conditions": rule.Conditions,
+				"actions":    rule.Actions,
+				"dry_run":    rule.DryRun,
+				"enabled":    rule.Enabled,
+			}, fmt.Sprintf("Saved automation rule '%s' on %s", rule.Name, rule.Trigger))
+
+		if err != nil {
+			c.Set("error", "Failed to save rule")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		reloadAutomation()
+
+		c.Set("data", gin.H{"rule": rule})
+		c.Status(status)
+	}
+}
+
+func deleteAutomationRule(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid rule ID")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		rule, err := models.GetAutomationRule(uint(id))
+		if err != nil {
+			c.Set("error", "Rule not found")
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		err = models.DeleteAutomationRule(rule.ID)
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		Audit.LogAction(c, models.ActionAutomationConfig, models.SourceWebUI, err == nil, errMsg,
+			"automation_rule", fmt.Sprintf("%d", rule.ID), rule.Name, nil,
+			fmt.Sprintf("Deleted automation rule '%s'", rule.Name))
+
+		if err != nil {
+			c.Set("error", "Failed to delete rule")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		reloadAutomation()
+
+		c.Set("data", gin.H{"message": "Rule deleted"})
+		c.Status(http.StatusOK)
+	}
+}
@@ -0,0 +1,336 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/banfeed"
+	"github.com/ethanburkett/goadmin/app/models"
+	"github.com/gin-gonic/gin"
+)
+
+// BanFeedPeerRequest represents the request to create/update a ban feed peer
+type BanFeedPeerRequest struct {
+	Name                string   `json:"name" binding:"required"`
+	URL                 string   `json:"url" binding:"omitempty,url"`
+	Secret              string   `json:"secret"` // Required on create; left unchanged on update when empty
+	Enabled             *bool    `json:"enabled"`
+	Subscribe           *bool    `json:"subscribe"`
+	Publish             *bool    `json:"publish"`
+	Policy              string   `json:"policy"` // ban or flag, defaults to flag
+	BanCategories       []string `json:"banCategories"`
+	PullIntervalMinutes *int     `json:"pullIntervalMinutes"`
+}
+
+// RegisterBanFeedRoutes registers the ban feed served to peers and the
+// routes for managing peers
+func RegisterBanFeedRoutes(r *gin.Engine, api *Api) {
+	peers := r.Group("/bans/peers")
+	peers.Use(AuthMiddleware())
+	peers.Use(RequirePermission("bans.manage"))
+	{
+		peers.GET("", getBanFeedPeers(api))
+		peers.POST("", createBanFeedPeer(api))
+		peers.PUT("/:id", updateBanFeedPeer(api))
+		peers.DELETE("/:id", deleteBanFeedPeer(api))
+		peers.POST("/:id/sync", syncBanFeedPeer(api))
+	}
+
+	r.GET("/bans/flags", AuthMiddleware(), RequirePermission("bans.view"), getBanFlags(api))
+
+	// Peers sign pulls and verify our responses with their shared secret
+	r.GET("/feed/bans", RateLimitByIP(APIRateLimiter), serveBanFeed(api))
+}
+
+// serveBanFeed returns our ban feed signed with the requesting peer's secret
+func serveBanFeed(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !api.config.BanFeed.Enabled {
+			c.Set("error", "Ban feed is not enabled")
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		// Pulls are signed with the shared secret; knowing a peer's name
+		// isn't enough to read the feed
+		instance := c.GetHeader(banfeed.InstanceHeader)
+		peer, err := models.GetPublishingPeer(instance)
+		if err != nil {
+			c.Set("error", "Unknown peer or invalid signature")
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+		since, after, timestamp := c.Query("since"), c.Query("after"), c.GetHeader(banfeed.TimestampHeader)
+		if err := banfeed.VerifyRequest(instance, since, after, timestamp,
+			c.GetHeader(banfeed.SignatureHeader), peer.Secret, time.Now()); err != nil {
+			c.Set("error", "Unknown peer or invalid signature")
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+
+		var sinceTime *time.Time
+		var afterID uint
+		if since != "" {
+			t, err := time.Parse(time.RFC3339Nano, since)
+			if err != nil {
+				c.Set("error", "Invalid since timestamp")
+				c.Status(http.StatusBadRequest)
+				return
+			}
+			sinceTime = &t
+		}
+		if after != "" {
+			id, err := strconv.ParseUint(after, 10, 32)
+			if err != nil {
+				c.Set("error", "Invalid after ID")
+				c.Status(http.StatusBadRequest)
+				return
+			}
+			afterID = uint(id)
+		}
+
+		feed, err := banfeed.Build(api.config.BanFeed.InstanceName, sinceTime, afterID)
+		if err != nil {
+			c.Set("error", "Failed to build ban feed")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		feed.Since, feed.After, feed.Timestamp = since, after, timestamp
+		body, signature, err := banfeed.Sign(feed, peer.Secret)
+		if err != nil {
+			c.Set("error", "Failed to sign ban feed")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Header(banfeed.SignatureHeader, signature)
+		c.Data(http.StatusOK, "application/json", body)
+	}
+}
+
+func getBanFeedPeers(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		peers, err := models.GetBanFeedPeers()
+		if err != nil {
+			c.Set("error", "Failed to retrieve peers")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{
+			"instance": api.config.BanFeed.InstanceName,
+			"enabled":  api.config.BanFeed.Enabled,
+			"peers":    peers,
+		})
+		c.Status(http.StatusOK)
+	}
+}
+
+func createBanFeedPeer(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req BanFeedPeerRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		if req.Secret == "" {
+			c.Set("error", "Secret is required")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		peer := &models.BanFeedPeer{
+			Policy:              models.FeedPolicyFlag,
+			Enabled:             true,
+			Subscribe:           true,
+			Publish:             true,
+			PullIntervalMinutes: 15,
+		}
+		if msg := applyBanFeedPeerRequest(peer, &req); msg != "" {
+			c.Set("error", msg)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		if err := models.CreateBanFeedPeer(peer); err != nil {
+			c.Set("error", "Failed to create peer")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{"peer": peer})
+		c.Status(http.StatusCreated)
+	}
+}
+
+func updateBanFeedPeer(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid peer ID")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		var req BanFeedPeerRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		peer, err := models.GetBanFeedPeer(uint(id))
+		if err != nil {
+			c.Set("error", "Peer not found")
+			c.Status(http.StatusNotFound)
+			return
+		}
+		if msg := applyBanFeedPeerRequest(peer, &req); msg != "" {
+			c.Set("error", msg)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		if err := models.SaveBanFeedPeer(peer); err != nil {
+			c.Set("error", "Failed to update peer")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{"peer": peer})
+		c.Status(http.StatusOK)
+	}
+}
+
+// applyBanFeedPeerRequest copies a request onto a peer, returning a
+// validation message when it is invalid
+func applyBanFeedPeerRequest(peer *models.BanFeedPeer, req *BanFeedPeerRequest) string {
+	peer.Name = strings.TrimSpace(req.Name)
+	peer.URL = req.URL
+	if req.Secret != "" {
+		peer.Secret = req.Secret
+	}
+	if req.Enabled != nil {
+		peer.Enabled = *req.Enabled
+	}
+	if req.Subscribe != nil {
+		peer.Subscribe = *req.Subscribe
+	}
+	if req.Publish != nil {
+		peer.Publish = *req.Publish
+	}
+	if req.Policy != "" {
+		if req.Policy != models.FeedPolicyBan && req.Policy != models.FeedPolicyFlag {
+			return "Policy must be ban or flag"
+		}
+		peer.Policy = req.Policy
+	}
+	if req.BanCategories != nil {
+		for _, category := range req.BanCategories {
+			if !models.ValidBanCategory(category) {
+				return "Unknown ban category: " + category
+			}
+		}
+		peer.BanCategories = req.BanCategories
+	}
+	if req.PullIntervalMinutes != nil {
+		if *req.PullIntervalMinutes < 1 {
+			return "Pull interval must be at least 1 minute"
+		}
+		peer.PullIntervalMinutes = *req.PullIntervalMinutes
+	}
+
+	if peer.Name == "" {
+		return "Name is required"
+	}
+	if peer.Subscribe && peer.URL == "" {
+		return "URL is required to subscribe to a peer"
+	}
+	return ""
+}
+
+func deleteBanFeedPeer(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid peer ID")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		if err := models.DeleteBanFeedPeer(uint(id)); err != nil {
+			c.Set("error", "Failed to delete peer")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{"message": "Peer deleted successfully"})
+		c.Status(http.StatusOK)
+	}
+}
+
+// syncBanFeedPeer pulls a peer's feed now instead of waiting for its interval
+func syncBanFeedPeer(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !api.config.BanFeed.Enabled {
+			c.Set("error", "Ban feed is not enabled")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid peer ID")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		peer, err := models.GetBanFeedPeer(uint(id))
+		if err != nil {
+			c.Set("error", "Peer not found")
+			c.Status(http.StatusNotFound)
+			return
+		}
+		if peer.URL == "" {
+			c.Set("error", "Peer has no feed URL")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		client := &http.Client{Timeout: 15 * time.Second}
+		result, err := banfeed.Pull(client, api.config.BanFeed.InstanceName, peer)
+		if err != nil {
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadGateway)
+			return
+		}
+
+		// Remove anyone newly banned who is online now
+		if result.Banned > 0 {
+			go api.servers.SweepBans()
+		}
+
+		c.Set("data", gin.H{"peer": peer, "result": result})
+		c.Status(http.StatusOK)
+	}
+}
+
+func getBanFlags(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		guid := strings.ToLower(strings.TrimSpace(c.Query("guid")))
+		activeOnly := c.DefaultQuery("active", "true") == "true"
+
+		flags, err := models.GetBanFlags(guid, activeOnly)
+		if err != nil {
+			c.Set("error", "Failed to retrieve flags")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{"flags": flags})
+		c.Status(http.StatusOK)
+	}
+}
@@ -0,0 +1,609 @@
+package rest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/banio"
+	"github.com/ethanburkett/goadmin/app/events"
+	"github.com/ethanburkett/goadmin/app/models"
+	"github.com/gin-gonic/gin"
+)
+
+type CreateBanRequest struct {
+	Type       string   `json:"type" binding:"required"`  // guid, ip, ip_range or name
+	Value      string   `json:"value" binding:"required"` // GUID, IP, CIDR or name pattern
+	PlayerName string   `json:"playerName"`
+	PlayerGUID string   `json:"playerGuid"`
+	Reason     string   `json:"reason" binding:"required"`
+	Category   string   `json:"category"` // cheating, exploiting, griefing, abuse, evasion or other
+	Evidence   []string `json:"evidence"`
+	Duration   *int     `json:"duration"` // Hours; omit for a permanent ban
+	ServerID   *uint    `json:"serverId"`
+}
+
+type UpdateBanRequest struct {
+	Reason   *string  `json:"reason"`
+	Category *string  `json:"category"`
+	Evidence []string `json:"evidence"` // Replaces the list when set
+	Duration *int     `json:"duration"` // Hours from now; 0 makes the ban permanent
+}
+
+type RevokeBanRequest struct {
+	Reason string `json:"reason"`
+}
+
+type BanAppealRequest struct {
+	BanID      uint   `json:"banId" binding:"required"`
+	PlayerGUID string `json:"playerGuid" binding:"required"`
+	PlayerName string `json:"playerName"`
+	Contact    string `json:"contact"`
+	Message    string `json:"message" binding:"required"`
+}
+
+type ReviewAppealRequest struct {
+	Accept bool   `json:"accept"`
+	Note   string `json:"note"`
+}
+
+func RegisterBanRoutes(r *gin.Engine, api *Api) {
+	bans := r.Group("/bans")
+	bans.Use(AuthMiddleware())
+	{
+		bans.GET("", RequirePermission("bans.view"), getBans(api))
+		bans.GET("/check", RequirePermission("bans.view"), checkBan(api))
+		bans.GET("/export", RequirePermission("bans.view"), exportBans(api))
+		bans.POST("/import", RequirePermission("bans.manage"), importBans(api))
+		bans.GET("/appeals", RequirePermission("bans.view"), getBanAppeals(api))
+		bans.POST("/appeals/:id/review", RequirePermission("bans.manage"), reviewBanAppeal(api))
+		bans.GET("/:id", RequirePermission("bans.view"), getBan(api))
+		bans.POST("", RequirePermission("bans.manage"), createBan(api))
+		bans.PUT("/:id", RequirePermission("bans.manage"), updateBan(api))
+		bans.DELETE("/:id", RequirePermission("bans.manage"), revokeBan(api))
+	}
+
+	// Banned players have no dashboard account, so appeals are public and
+	// checked against the ban ID and GUID shown in the kick message
+	r.POST("/appeals", RateLimitByIP(AppealRateLimiter), submitBanAppeal(api))
+}
+
+func getBans(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit <= 0 {
+			limit = 50
+		}
+		if limit > 500 {
+			limit = 500
+		}
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			offset = 0
+		}
+
+		filter := models.BanFilter{
+			ActiveOnly: c.Query("active") == "true",
+			Type:       c.Query("type"),
+			Search:     c.Query("search"),
+		}
+
+		bans, total, err := models.GetBans(filter, limit, offset)
+		if err != nil {
+			c.Set("error", "Failed to retrieve bans")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{
+			"bans":   bans,
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+		})
+		c.Status(http.StatusOK)
+	}
+}
+
+func getBan(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid ban ID")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		ban, err := models.GetBanByID(uint(id))
+		if err != nil {
+			c.Set("error", "Ban not found")
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		c.Set("data", ban)
+		c.Status(http.StatusOK)
+	}
+}
+
+// checkBan reports the ban in effect for a player, if any, given any of the
+// guid, ip and name query parameters
+func checkBan(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		guid, ip, name := c.Query("guid"), c.Query("ip"), c.Query("name")
+		if guid == "" && ip == "" && name == "" {
+			c.Set("error", "Provide at least one of guid, ip or name")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		ban, err := models.FindMatchingBan(guid, ip, name)
+		if err != nil {
+			c.Set("error", "Failed to check bans")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{
+			"banned": ban != nil,
+			"ban":    ban,
+		})
+		c.Status(http.StatusOK)
+	}
+}
+
+func createBan(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req CreateBanRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		userVal, exists := c.Get("user")
+		if !exists {
+			c.Set("error", "User not found")
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+		user := userVal.(*models.User)
+
+		ban := &models.Ban{
+			Type:           req.Type,
+			Value:          req.Value,
+			PlayerName:     req.PlayerName,
+			PlayerGUID:     req.PlayerGUID,
+			Reason:         req.Reason,
+			Category:       req.Category,
+			Evidence:       req.Evidence,
+			BannedByUserID: &user.ID,
+			BannedByName:   user.Username,
+			ServerID:       req.ServerID,
+			Source:         "web",
+		}
+		if req.Duration != nil {
+			if *req.Duration <= 0 {
+				c.Set("error", "Duration must be a positive number of hours")
+				c.Status(http.StatusBadRequest)
+				return
+			}
+			expiresAt := time.Now().Add(time.Duration(*req.Duration) * time.Hour)
+			ban.ExpiresAt = &expiresAt
+		}
+
+		if err := models.CreateBan(ban); err != nil {
+			Audit.LogBan(c, req.PlayerName, req.Value, req.Reason, false, err.Error())
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		Audit.LogBan(c, ban.PlayerName, ban.Value, ban.Reason, true, "")
+
+		publishBanCreated(ban, user, "web")
+
+		// Remove anyone it covers who is online now rather than at the next sweep
+		go api.servers.SweepBans()
+
+		c.Set("data", ban)
+		c.Status(http.StatusCreated)
+	}
+}
+
+func updateBan(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid ban ID")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		var req UpdateBanRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		ban, err := models.GetBanByID(uint(id))
+		if err != nil {
+			c.Set("error", "Ban not found")
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		if req.Reason != nil {
+			if strings.TrimSpace(*req.Reason) == "" {
+				c.Set("error", "Reason cannot be empty")
+				c.Status(http.StatusBadRequest)
+				return
+			}
+			ban.Reason = *req.Reason
+		}
+		if req.Category != nil {
+			if !models.ValidBanCategory(*req.Category) {
+				c.Set("error", "Invalid ban category")
+				c.Status(http.StatusBadRequest)
+				return
+			}
+			ban.Category = *req.Category
+		}
+		if req.Evidence != nil {
+			ban.Evidence = req.Evidence
+		}
+		if req.Duration != nil {
+			switch {
+			case *req.Duration < 0:
+				c.Set("error", "Duration cannot be negative")
+				c.Status(http.StatusBadRequest)
+				return
+			case *req.Duration == 0:
+				ban.ExpiresAt = nil
+			default:
+				expiresAt := time.Now().Add(time.Duration(*req.Duration) * time.Hour)
+				ban.ExpiresAt = &expiresAt
+			}
+		}
+
+		err = models.UpdateBan(ban)
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		Audit.LogAction(c, models.ActionBanUpdate, models.SourceWebUI, err == nil, errMsg,
+			"ban", fmt.Sprintf("%d", ban.ID), ban.PlayerName,
+			map[string]interface{}{
+				"reason":     ban.Reason,
+				"category":   ban.Category,
+				"evidence":   ban.Evidence,
+				"expires_at": ban.ExpiresAt,
+			}, fmt.Sprintf("Updated ban #%d", ban.ID))
+
+		if err != nil {
+			c.Set("error", "Failed to update ban")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", ban)
+		c.Status(http.StatusOK)
+	}
+}
+
+func revokeBan(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid ban ID")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		// The body is optional
+		var req RevokeBanRequest
+		c.ShouldBindJSON(&req)
+
+		userVal, exists := c.Get("user")
+		if !exists {
+			c.Set("error", "User not found")
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+		user := userVal.(*models.User)
+
+		ban, err := models.GetBanByID(uint(id))
+		if err != nil {
+			c.Set("error", "Ban not found")
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		err = models.RevokeBan(ban.ID, user.Username, req.Reason)
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		Audit.LogAction(c, models.ActionUnbanPlayer, models.SourceWebUI, err == nil, errMsg,
+			"ban", fmt.Sprintf("%d", ban.ID), ban.PlayerName,
+			map[string]interface{}{
+				"type":   ban.Type,
+				"value":  ban.Value,
+				"reason": req.Reason,
+			}, fmt.Sprintf("Revoked ban #%d", ban.ID))
+
+		if errors.Is(err, models.ErrBanNotFound) {
+			c.Set("error", "Ban is not active")
+			c.Status(http.StatusConflict)
+			return
+		}
+		if err != nil {
+			c.Set("error", "Failed to revoke ban")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		events.Publish(events.PlayerUnbanned, ban.ServerID, events.BanPayload{
+			PlayerName: ban.PlayerName,
+			PlayerGUID: ban.PlayerGUID,
+			BannedBy:   user.Username,
+			BannedByID: &user.ID,
+			Reason:     req.Reason,
+			Source:     "web",
+			BanID:      &ban.ID,
+		})
+
+		c.Set("data", gin.H{"message": "Ban revoked"})
+		c.Status(http.StatusOK)
+	}
+}
+
+func getBanAppeals(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		appeals, err := models.GetBanAppeals(c.Query("status"))
+		if err != nil {
+			c.Set("error", "Failed to retrieve appeals")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", appeals)
+		c.Status(http.StatusOK)
+	}
+}
+
+func reviewBanAppeal(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid appeal ID")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		var req ReviewAppealRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		userVal, exists := c.Get("user")
+		if !exists {
+			c.Set("error", "User not found")
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+		user := userVal.(*models.User)
+
+		appeal, err := models.ReviewBanAppeal(uint(id), req.Accept, user, req.Note)
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		Audit.LogAction(c, models.ActionBanAppealReview, models.SourceWebUI, err == nil, errMsg,
+			"ban_appeal", fmt.Sprintf("%d", id), appeal.PlayerName,
+			map[string]interface{}{
+				"ban_id": appeal.BanID,
+				"accept": req.Accept,
+				"note":   req.Note,
+			}, fmt.Sprintf("Appeal %s", appeal.Status))
+
+		if err != nil {
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		if req.Accept {
+			events.Publish(events.PlayerUnbanned, nil, events.BanPayload{
+				PlayerName: appeal.PlayerName,
+				PlayerGUID: appeal.PlayerGUID,
+				BannedBy:   user.Username,
+				BannedByID: &user.ID,
+				Reason:     "Appeal accepted: " + req.Note,
+				Source:     "web",
+				BanID:      &appeal.BanID,
+			})
+		}
+
+		c.Set("data", appeal)
+		c.Status(http.StatusOK)
+	}
+}
+
+func submitBanAppeal(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req BanAppealRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		if len(req.Message) > 4000 {
+			c.Set("error", "Message is too long")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		appeal, err := models.CreateBanAppeal(req.BanID, req.PlayerName, req.PlayerGUID, req.Contact, req.Message)
+		switch {
+		case errors.Is(err, models.ErrBanNotFound):
+			c.Set("error", "No active ban matches that ban ID and GUID")
+			c.Status(http.StatusNotFound)
+			return
+		case errors.Is(err, models.ErrAppealPending):
+			c.Set("error", err.Error())
+			c.Status(http.StatusConflict)
+			return
+		case err != nil:
+			c.Set("error", "Failed to submit appeal")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{
+			"id":     appeal.ID,
+			"status": appeal.Status,
+		})
+		c.Status(http.StatusCreated)
+	}
+}
+
+// maxBanImportSize caps uploaded ban files
+const maxBanImportSize = 10 << 20
+
+// importBans reads a ban file given as a multipart "file" field or the raw
+// request body. With dry_run=true nothing is saved and the report shows what
+// would be imported.
+func importBans(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := c.Query("format")
+		if !banio.ValidFormat(format) {
+			c.Set("error", "Invalid format, expected one of: "+strings.Join(banio.Formats, ", "))
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		userVal, exists := c.Get("user")
+		if !exists {
+			c.Set("error", "User not found")
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+		user := userVal.(*models.User)
+
+		// Only a multipart upload is parsed as a form; any other body is
+		// the ban file itself
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBanImportSize)
+		var body io.Reader = c.Request.Body
+		if c.ContentType() == "multipart/form-data" {
+			file, err := c.FormFile("file")
+			if err != nil {
+				c.Set("error", "Missing \"file\" field in upload")
+				c.Status(http.StatusBadRequest)
+				return
+			}
+			f, err := file.Open()
+			if err != nil {
+				c.Set("error", "Failed to read uploaded file")
+				c.Status(http.StatusBadRequest)
+				return
+			}
+			defer f.Close()
+			body = f
+		}
+
+		dryRun := c.Query("dry_run") == "true"
+		report, err := banio.Import(format, body, banio.Options{
+			DryRun:        dryRun,
+			DefaultReason: c.Query("reason"),
+			ImportedBy:    user.Username,
+		})
+		if err != nil {
+			c.Set("error", "Failed to import bans: "+err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		if !dryRun {
+			Audit.LogAction(c, models.ActionBanPlayer, models.SourceWebUI, true, "",
+				"ban_import", format, "",
+				map[string]interface{}{
+					"format":     format,
+					"total":      report.Total,
+					"imported":   report.Imported,
+					"duplicates": report.Duplicates,
+					"skipped":    len(report.Skipped),
+				}, fmt.Sprintf("Imported %d bans from %s", report.Imported, format))
+
+			if report.Imported > 0 {
+				go api.servers.SweepBans()
+			}
+		}
+
+		c.Set("data", report)
+		c.Status(http.StatusOK)
+	}
+}
+
+// exportBans downloads every ban in effect in the given format
+func exportBans(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := c.DefaultQuery("format", banio.FormatJSON)
+		if !banio.ValidFormat(format) {
+			c.Set("error", "Invalid format, expected one of: "+strings.Join(banio.Formats, ", "))
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		bans, err := models.GetBansInEffect()
+		if err != nil {
+			c.Set("error", "Failed to retrieve bans")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		var buf bytes.Buffer
+		if _, err := banio.Export(format, &buf, bans); err != nil {
+			c.Set("error", "Failed to export bans")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		filename := map[string]string{
+			banio.FormatB3:    "penalties.tsv",
+			banio.FormatCoD4x: "banlist.dat",
+			banio.FormatGUIDs: "guids.txt",
+			banio.FormatJSON:  "bans.json",
+		}[format]
+		c.Header("Content-Disposition", "attachment; filename="+filename)
+		c.Data(http.StatusOK, "application/octet-stream", buf.Bytes())
+	}
+}
+
+// publishBanCreated announces a new ban list entry
+func publishBanCreated(ban *models.Ban, user *models.User, source string) {
+	payload := events.BanPayload{
+		PlayerName: ban.PlayerName,
+		PlayerGUID: ban.PlayerGUID,
+		BannedBy:   user.Username,
+		BannedByID: &user.ID,
+		Reason:     ban.Reason,
+		BanType:    "permanent",
+		Source:     source,
+		BanID:      &ban.ID,
+	}
+	if ban.ExpiresAt != nil {
+		payload.BanType = "temporary"
+		payload.ExpiresAt = ban.ExpiresAt.Format(time.RFC3339)
+		payload.Duration = time.Until(*ban.ExpiresAt).Round(time.Hour).String()
+	}
+	events.Publish(events.PlayerBanned, ban.ServerID, payload)
+}
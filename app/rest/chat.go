@@ -0,0 +1,225 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/models"
+	"github.com/gin-gonic/gin"
+)
+
+// maxChatExport caps the lines in one export
+const maxChatExport = 50000
+
+func RegisterChatRoutes(r *gin.Engine, api *Api) {
+	chat := r.Group("/chat")
+	chat.Use(AuthMiddleware())
+	chat.Use(RequirePermission("chat.view"))
+	{
+		chat.GET("", getChatMessages(api))
+		chat.GET("/export", exportChatMessages(api))
+	}
+
+	// What was said on the server before a report, for reviewing it
+	r.GET("/reports/:id/chat", AuthMiddleware(), RequirePermission("reports.view"), getReportChat(api))
+}
+
+// chatFilterFromQuery reads the shared filter parameters. On failure the
+// error response is already set and ok is false.
+func chatFilterFromQuery(c *gin.Context) (filter models.ChatFilter, ok bool) {
+	filter = models.ChatFilter{
+		Search:     c.Query("search"),
+		PlayerGUID: c.Query("guid"),
+		PlayerName: c.Query("name"),
+	}
+
+	if serverIDStr := c.Query("server_id"); serverIDStr != "" {
+		id, err := strconv.ParseUint(serverIDStr, 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid server ID")
+			c.Status(http.StatusBadRequest)
+			return filter, false
+		}
+		serverID := uint(id)
+		filter.ServerID = &serverID
+	}
+
+	for param, target := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.Set("error", "Invalid "+param+" timestamp, expected RFC 3339")
+			c.Status(http.StatusBadRequest)
+			return filter, false
+		}
+		t = models.ColumnTime(t)
+		*target = &t
+	}
+
+	return filter, true
+}
+
+func getChatMessages(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+		if err != nil || limit <= 0 {
+			limit = 100
+		}
+		if limit > 500 {
+			limit = 500
+		}
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			offset = 0
+		}
+
+		filter, ok := chatFilterFromQuery(c)
+		if !ok {
+			return
+		}
+
+		messages, total, err := models.GetChatMessages(filter, limit, offset)
+		if err != nil {
+			c.Set("error", "Failed to retrieve chat messages")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{
+			"messages": messages,
+			"total":    total,
+			"limit":    limit,
+			"offset":   offset,
+		})
+		c.Status(http.StatusOK)
+	}
+}
+
+// exportChatMessages downloads the filtered chat log as CSV or JSON
+func exportChatMessages(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := c.DefaultQuery("format", "csv")
+		if format != "csv" && format != "json" {
+			c.Set("error", "Invalid format, expected csv or json")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		filter, ok := chatFilterFromQuery(c)
+		if !ok {
+			return
+		}
+
+		messages, err := models.GetChatMessagesForExport(filter, maxChatExport)
+		if err != nil {
+			c.Set("error", "Failed to retrieve chat messages")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		var buf bytes.Buffer
+		if format == "json" {
+			if err := json.NewEncoder(&buf).Encode(messages); err != nil {
+				c.Set("error", "Failed to export chat messages")
+				c.Status(http.StatusInternalServerError)
+				return
+			}
+		} else {
+			w := csv.NewWriter(&buf)
+			w.Write([]string{"time", "server_id", "player_guid", "player_name", "team", "message"})
+			for _, m := range messages {
+				serverID := ""
+				if m.ServerID != nil {
+					serverID = strconv.FormatUint(uint64(*m.ServerID), 10)
+				}
+				w.Write([]string{
+					m.CreatedAt.Format(time.RFC3339),
+					serverID,
+					csvCell(m.PlayerGUID),
+					csvCell(m.PlayerName),
+					strconv.FormatBool(m.Team),
+					csvCell(m.Message),
+				})
+			}
+			w.Flush()
+			if err := w.Error(); err != nil {
+				c.Set("error", "Failed to export chat messages")
+				c.Status(http.StatusInternalServerError)
+				return
+			}
+		}
+
+		filename := "chat_" + time.Now().Format("20060102_150405") + "." + format
+		contentType := "text/csv"
+		if format == "json" {
+			contentType = "application/json"
+		}
+		c.Header("Content-Disposition", "attachment; filename="+filename)
+		c.Data(http.StatusOK, contentType, buf.Bytes())
+	}
+}
+
+// csvCell prefixes player-supplied text that a spreadsheet would read as a
+// formula with a quote, so an exported chat line can't run one
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// getReportChat returns the chat on the report's server in the minutes
+// leading up to it (15 by default, ?minutes= up to 120)
+func getReportChat(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid report ID")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		minutes, err := strconv.Atoi(c.DefaultQuery("minutes", "15"))
+		if err != nil || minutes <= 0 {
+			minutes = 15
+		}
+		if minutes > 120 {
+			minutes = 120
+		}
+
+		report, err := models.GetReportByID(uint(id))
+		if err != nil {
+			c.Set("error", "Report not found")
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		since := report.CreatedAt.Add(-time.Duration(minutes) * time.Minute)
+		// A little after, to include the !report line itself
+		until := report.CreatedAt.Add(time.Minute)
+		messages, err := models.GetChatMessagesForExport(models.ChatFilter{
+			ServerID: report.ServerID,
+			Since:    &since,
+			Until:    &until,
+		}, 500)
+		if err != nil {
+			c.Set("error", "Failed to retrieve chat messages")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{
+			"report":   report,
+			"messages": messages,
+		})
+		c.Status(http.StatusOK)
+	}
+}
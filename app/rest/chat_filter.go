@@ -0,0 +1,249 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/chatfilter"
+	"github.com/ethanburkett/goadmin/app/logger"
+	"github.com/ethanburkett/goadmin/app/models"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ChatFilterRuleRequest struct {
+	Name            string `json:"name" binding:"required"`
+	Type            string `json:"type" binding:"required"` // words, regex, advertising, repeat, flood or caps
+	Pattern         string `json:"pattern"`
+	Threshold       int    `json:"threshold"`
+	WindowSeconds   int    `json:"windowSeconds"`
+	MinLength       int    `json:"minLength"`
+	Action          string `json:"action" binding:"required"` // tell, mute, kick or tempban
+	DurationMinutes int    `json:"durationMinutes"`
+	Message         string `json:"message"`
+	ExemptPower     int    `json:"exemptPower"`
+	Enabled         *bool  `json:"enabled"`
+}
+
+type ChatFilterTestRequest struct {
+	Message string `json:"message" binding:"required"`
+}
+
+func RegisterChatFilterRoutes(r *gin.Engine, api *Api) {
+	filter := r.Group("/chatfilter")
+	filter.Use(AuthMiddleware())
+	{
+		filter.GET("/rules", RequirePermission("chat.view"), getChatFilterRules(api))
+		filter.POST("/rules", RequirePermission("chatfilter.manage"), saveChatFilterRule(api))
+		filter.PUT("/rules/:id", RequirePermission("chatfilter.manage"), saveChatFilterRule(api))
+		filter.DELETE("/rules/:id", RequirePermission("chatfilter.manage"), deleteChatFilterRule(api))
+		filter.POST("/test", RequirePermission("chatfilter.manage"), testChatFilter(api))
+
+		filter.GET("/mutes", RequirePermission("chat.view"), getChatMutes(api))
+		filter.DELETE("/mutes/:guid", RequirePermission("chatfilter.manage"), unmutePlayer(api))
+	}
+}
+
+// reloadChatFilter applies rule changes to the running filter
+func reloadChatFilter() {
+	if err := chatfilter.Default.Reload(); err != nil {
+		logger.Error("Failed to reload chat filter rules", zap.Error(err))
+	}
+}
+
+func getChatFilterRules(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rules, err := models.GetChatFilterRules()
+		if err != nil {
+			c.Set("error", "Failed to retrieve chat filter rules")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{"rules": rules})
+		c.Status(http.StatusOK)
+	}
+}
+
+// saveChatFilterRule creates a rule, or replaces one when called with an ID
+func saveChatFilterRule(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ChatFilterRuleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		rule := &models.ChatFilterRule{Enabled: true}
+		status := http.StatusCreated
+		if c.Param("id") != "" {
+			id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+			if err != nil {
+				c.Set("error", "Invalid rule ID")
+				c.Status(http.StatusBadRequest)
+				return
+			}
+			rule, err = models.GetChatFilterRule(uint(id))
+			if err != nil {
+				c.Set("error", "Rule not found")
+				c.Status(http.StatusNotFound)
+				return
+			}
+			status = http.StatusOK
+		}
+
+		rule.Name = req.Name
+		rule.Type = req.Type
+		rule.Pattern = req.Pattern
+		rule.Threshold = req.Threshold
+		rule.WindowSeconds = req.WindowSeconds
+		rule.MinLength = req.MinLength
+		rule.Action = req.Action
+		rule.DurationMinutes = req.DurationMinutes
+		rule.Message = req.Message
+		rule.ExemptPower = req.ExemptPower
+		if req.Enabled != nil {
+			rule.Enabled = *req.Enabled
+		}
+
+		if err := rule.Validate(); err != nil {
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		err := models.SaveChatFilterRule(rule)
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		Audit.LogAction(c, models.ActionChatFilterConfig, models.SourceWebUI, err == nil, errMsg,
+			"chat_filter_rule", fmt.Sprintf("%d", rule.ID), rule.Name,
+			map[string]interface{}{
+				"type":             rule.Type,
+				"pattern":          rule.Pattern,
+				"threshold":        rule.Threshold,
+				"window_seconds":   rule.WindowSeconds,
+				"action":           rule.Action,
+				"duration_minutes": rule.DurationMinutes,
+				"exempt_power":     rule.ExemptPower,
+				"enabled":          rule.Enabled,
+			}, fmt.Sprintf("Saved chat filter rule '%s' (%s -> %s)", rule.Name, rule.Type, rule.Action))
+
+		if err != nil {
+			c.Set("error", "Failed to save rule")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		reloadChatFilter()
+
+		c.Set("data", gin.H{"rule": rule})
+		c.Status(status)
+	}
+}
+
+func deleteChatFilterRule(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid rule ID")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		rule, err := models.GetChatFilterRule(uint(id))
+		if err != nil {
+			c.Set("error", "Rule not found")
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		err = models.DeleteChatFilterRule(rule.ID)
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		Audit.LogAction(c, models.ActionChatFilterConfig, models.SourceWebUI, err == nil, errMsg,
+			"chat_filter_rule", fmt.Sprintf("%d", rule.ID), rule.Name, nil,
+			fmt.Sprintf("Deleted chat filter rule '%s'", rule.Name))
+
+		if err != nil {
+			c.Set("error", "Failed to delete rule")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		reloadChatFilter()
+
+		c.Set("data", gin.H{"message": "Rule deleted"})
+		c.Status(http.StatusOK)
+	}
+}
+
+// testChatFilter reports which rule, if any, a message would break for a
+// player with no power. Repeat and flood rules need history, so only the
+// single line is considered.
+func testChatFilter(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ChatFilterTestRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		rules, err := models.GetChatFilterRules()
+		if err != nil {
+			c.Set("error", "Failed to retrieve chat filter rules")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		engine := chatfilter.NewEngine()
+		engine.SetRules(rules)
+		violation := engine.Check(chatfilter.Line{GUID: "test", Message: req.Message}, 0, time.Now())
+
+		if violation == nil {
+			c.Set("data", gin.H{"matched": false})
+		} else {
+			c.Set("data", gin.H{
+				"matched": true,
+				"rule":    violation.Rule,
+				"match":   violation.Match,
+			})
+		}
+		c.Status(http.StatusOK)
+	}
+}
+
+func getChatMutes(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("data", gin.H{"mutes": chatfilter.Default.Mutes()})
+		c.Status(http.StatusOK)
+	}
+}
+
+func unmutePlayer(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		guid := c.Param("guid")
+		mute := chatfilter.Default.Muted(guid)
+		if mute == nil {
+			c.Set("error", "Player is not muted")
+			c.Status(http.StatusNotFound)
+			return
+		}
+		chatfilter.Default.Unmute(guid)
+
+		Audit.LogAction(c, models.ActionUnmutePlayer, models.SourceWebUI, true, "",
+			"player", mute.PlayerGUID, mute.PlayerName,
+			map[string]interface{}{
+				"reason":      mute.Reason,
+				"muted_until": mute.ExpiresAt,
+			}, fmt.Sprintf("Unmuted %s", mute.PlayerName))
+
+		c.Set("data", gin.H{"message": "Player unmuted"})
+		c.Status(http.StatusOK)
+	}
+}
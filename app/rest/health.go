@@ -1,6 +1,8 @@
 package rest
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -44,12 +46,32 @@ func getHealth(api *Api) gin.HandlerFunc {
 		dbHealth := checkDatabase()
 		response.Checks["database"] = dbHealth
 
-		// Check RCON
-		rconHealth := checkRCON(api.rcon)
+		// Check RCON on every running server. The default server keeps
+		// the "rcon" key; others are reported as "rcon:<server id>".
+		allHealthy := dbHealth.Status == "healthy"
+
+		var defaultClient *rcon.Client
+		if inst, err := api.servers.Resolve(nil); err == nil {
+			defaultClient = inst.RCON
+		}
+		rconHealth := checkRCON(defaultClient)
 		response.Checks["rcon"] = rconHealth
+		allHealthy = allHealthy && rconHealth.Status == "healthy"
+
+		for _, inst := range api.servers.Instances() {
+			if inst.RCON == defaultClient {
+				continue
+			}
+			serverHealth := checkRCON(inst.RCON)
+			if serverHealth.Details == nil {
+				serverHealth.Details = make(map[string]interface{})
+			}
+			serverHealth.Details["server"] = inst.Server.Name
+			response.Checks[fmt.Sprintf("rcon:%d", inst.Server.ID)] = serverHealth
+			allHealthy = allHealthy && serverHealth.Status == "healthy"
+		}
 
 		// Determine overall status
-		allHealthy := dbHealth.Status == "healthy" && rconHealth.Status == "healthy"
 		if allHealthy {
 			response.Status = "healthy"
 		} else if dbHealth.Status == "unhealthy" {
@@ -148,12 +170,32 @@ func checkRCON(rconClient *rcon.Client) Health {
 		}
 	}
 
-	// Try a simple command
+	// Try a simple command. While locked out this fails without contacting the server.
 	_, err := rconClient.SendCommand("status")
 	if err != nil {
+		auth := rconClient.AuthState()
+		details := map[string]interface{}{
+			"reason":   rcon.ErrorReason(err),
+			"failures": auth.Failures,
+		}
+		if auth.LockedUntil != nil {
+			details["locked_until"] = auth.LockedUntil.Format(time.RFC3339)
+		}
+
+		message := err.Error()
+		switch {
+		case errors.Is(err, rcon.ErrLockedOut):
+			message = "RCON locked out after repeated authentication failures"
+		case errors.Is(err, rcon.ErrBadPassword):
+			message = "RCON password rejected by server"
+		case errors.Is(err, rcon.ErrRconBanned):
+			message = "panel address is banned from RCON"
+		}
+
 		return Health{
 			Status:  "unhealthy",
-			Message: err.Error(),
+			Message: message,
+			Details: details,
 		}
 	}
 
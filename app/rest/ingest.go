@@ -0,0 +1,78 @@
+package rest
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ethanburkett/goadmin/app/models"
+	"github.com/ethanburkett/goadmin/app/watcher"
+	"github.com/gin-gonic/gin"
+)
+
+// maxIngestChunk limits the size of one pushed log chunk
+const maxIngestChunk = 1 << 20
+
+// RegisterIngestRoutes registers the log shipper endpoint. It is
+// authenticated by the server's log token rather than a user session.
+func RegisterIngestRoutes(r *gin.Engine, api *Api) {
+	ingest := r.Group("/ingest")
+	{
+		ingest.POST("/logs/:serverId", ingestLogs(api))
+	}
+}
+
+// ingestLogs accepts appended games_mp.log data for a server using the http
+// log source. The token goes in an "Authorization: Bearer" or X-Log-Token header.
+func ingestLogs(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("serverId"), 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid server ID")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		token := c.GetHeader("X-Log-Token")
+		if token == "" {
+			token = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		}
+
+		server, err := models.GetServerByID(uint(id))
+		if err != nil || server.LogToken == "" || token == "" ||
+			subtle.ConstantTimeCompare([]byte(token), []byte(server.LogToken)) != 1 {
+			c.Set("error", "Invalid log token")
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+
+		if server.LogSource != watcher.SourceHTTP {
+			c.Set("error", "Server is not configured for HTTP log ingestion")
+			c.Status(http.StatusConflict)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIngestChunk))
+		if err != nil {
+			c.Set("error", "Log chunk too large or unreadable")
+			c.Status(http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		if err := watcher.Ingest.Push(server.ID, body); err != nil {
+			status := http.StatusTooManyRequests
+			if errors.Is(err, watcher.ErrNoIngestListener) {
+				status = http.StatusServiceUnavailable
+			}
+			c.Set("error", err.Error())
+			c.Status(status)
+			return
+		}
+
+		c.Set("data", gin.H{"accepted": len(body)})
+		c.Status(http.StatusOK)
+	}
+}
@@ -2,12 +2,15 @@ package rest
 
 import (
 	"io"
+	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/ethanburkett/goadmin/app/config"
 	"github.com/ethanburkett/goadmin/app/database"
 	"github.com/ethanburkett/goadmin/app/logger"
 	"github.com/ethanburkett/goadmin/app/rcon"
+	"github.com/ethanburkett/goadmin/app/supervisor"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -16,7 +19,7 @@ import (
 type Api struct {
 	engine     *gin.Engine
 	config     *config.Config
-	rcon       *rcon.Client
+	servers    *supervisor.Supervisor
 	DB         *gorm.DB
 	migrations []database.MigrationDefinition
 }
@@ -28,7 +31,7 @@ type ApiResponse struct {
 	Error    interface{} `json:"error,omitempty"`
 }
 
-func New(cfg *config.Config, rconClient *rcon.Client, migrations []database.MigrationDefinition) *Api {
+func New(cfg *config.Config, servers *supervisor.Supervisor, migrations []database.MigrationDefinition) *Api {
 	gin.DefaultWriter = logger.GinWriter{}
 	gin.DefaultErrorWriter = logger.GinWriter{}
 
@@ -60,7 +63,7 @@ func New(cfg *config.Config, rconClient *rcon.Client, migrations []database.Migr
 	api := &Api{
 		engine:     r,
 		config:     cfg,
-		rcon:       rconClient,
+		servers:    servers,
 		DB:         database.DB,
 		migrations: migrations,
 	}
@@ -82,8 +85,16 @@ func New(cfg *config.Config, rconClient *rcon.Client, migrations []database.Migr
 	RegisterPluginRoutes(r, api)
 	RegisterMetricsRoutes(r, api)
 	RegisterEmergencyRoutes(r, api)
-
-	rconClient.SendCommand("say ^5GoAdmin ^7now serving.")
+	RegisterIngestRoutes(r, api)
+	RegisterStatsRoutes(r, api)
+	RegisterBanRoutes(r, api)
+	RegisterBanFeedRoutes(r, api)
+	RegisterWarningRoutes(r, api)
+	RegisterChatRoutes(r, api)
+	RegisterChatFilterRoutes(r, api)
+	RegisterTaskRoutes(r, api)
+	RegisterAutomationRoutes(r, api)
+	RegisterMapRotationRoutes(r, api)
 
 	return api
 }
@@ -91,3 +102,27 @@ func New(cfg *config.Config, rconClient *rcon.Client, migrations []database.Migr
 func (api *Api) Engine() *gin.Engine {
 	return api.engine
 }
+
+// serverClient returns the RCON client for the server selected by the
+// optional server_id query parameter, falling back to the default server.
+// On failure the error response is already set and ok is false.
+func (api *Api) serverClient(c *gin.Context) (client *rcon.Client, serverID *uint, ok bool) {
+	if serverIDStr := c.Query("server_id"); serverIDStr != "" {
+		id, err := strconv.ParseUint(serverIDStr, 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid server ID")
+			c.Status(http.StatusBadRequest)
+			return nil, nil, false
+		}
+		sid := uint(id)
+		serverID = &sid
+	}
+
+	inst, err := api.servers.Resolve(serverID)
+	if err != nil {
+		c.Set("error", err.Error())
+		c.Status(http.StatusServiceUnavailable)
+		return nil, nil, false
+	}
+	return inst.RCON, inst.ServerID(), true
+}
@@ -0,0 +1,489 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/ethanburkett/goadmin/app/games"
+	"github.com/ethanburkett/goadmin/app/maprotation"
+	"github.com/ethanburkett/goadmin/app/models"
+	"github.com/ethanburkett/goadmin/app/rcon"
+	"github.com/ethanburkett/goadmin/app/rcon/commands"
+	"github.com/gin-gonic/gin"
+)
+
+type MapRotationRequest struct {
+	ServerID   uint                      `json:"serverId" binding:"required"`
+	Name       string                    `json:"name" binding:"required"`
+	Entries    []models.MapRotationEntry `json:"entries" binding:"required"`
+	AutoSwitch bool                      `json:"autoSwitch"`
+	MinPlayers int                       `json:"minPlayers"`
+	MaxPlayers int                       `json:"maxPlayers"` // 0 for no upper limit
+}
+
+type CustomMapRequest struct {
+	ServerID uint   `json:"serverId" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+}
+
+func RegisterMapRotationRoutes(r *gin.Engine, api *Api) {
+	maps := r.Group("/maps")
+	maps.Use(AuthMiddleware())
+	{
+		maps.GET("", RequirePermission("rotations.view"), getKnownMaps(api))
+		maps.POST("/custom", RequirePermission("rotations.manage"), addCustomMap(api))
+		maps.DELETE("/custom/:id", RequirePermission("rotations.manage"), deleteCustomMap(api))
+		maps.GET("/vote", RequirePermission("rotations.view"), getMapVoteSettings(api))
+		maps.PUT("/vote", RequirePermission("rotations.manage"), updateMapVoteSettings(api))
+
+		maps.GET("/rotations", RequirePermission("rotations.view"), getMapRotations(api))
+		maps.GET("/rotations/:id", RequirePermission("rotations.view"), getMapRotation(api))
+		maps.POST("/rotations", RequirePermission("rotations.manage"), saveMapRotation(api))
+		maps.PUT("/rotations/:id", RequirePermission("rotations.manage"), saveMapRotation(api))
+		maps.DELETE("/rotations/:id", RequirePermission("rotations.manage"), deleteMapRotation(api))
+		maps.POST("/rotations/:id/push", RequirePermission("rotations.manage"), pushMapRotation(api))
+	}
+}
+
+// serverAdapter loads a server and its game adapter
+func serverAdapter(serverID uint) (*models.Server, games.Adapter, error) {
+	server, err := models.GetServerByID(serverID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return server, games.ForServer(server), nil
+}
+
+// getKnownMaps lists the maps and gametypes a server's rotations can use
+func getKnownMaps(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Query("serverId"), 10, 32)
+		if err != nil {
+			c.Set("error", "serverId is required")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		server, adapter, err := serverAdapter(uint(id))
+		if err != nil {
+			c.Set("error", "Server not found")
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		custom, err := models.GetCustomMaps(server.ID)
+		if err != nil {
+			c.Set("error", "Failed to retrieve custom maps")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{
+			"game":       adapter.Name(),
+			"maps":       adapter.Maps(),
+			"customMaps": custom,
+			"gametypes":  adapter.Gametypes(),
+		})
+		c.Status(http.StatusOK)
+	}
+}
+
+func addCustomMap(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req CustomMapRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		if !commands.IsIdentifier(req.Name) {
+			c.Set("error", "Invalid map name")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		server, adapter, err := serverAdapter(req.ServerID)
+		if err != nil {
+			c.Set("error", "Server not found")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		if games.IsKnownMap(adapter, req.Name) {
+			c.Set("error", fmt.Sprintf("%s is a stock %s map", req.Name, adapter.DisplayName()))
+			c.Status(http.StatusConflict)
+			return
+		}
+
+		custom, err := models.CreateCustomMap(server.ID, req.Name)
+		if err != nil {
+			c.Set("error", "Map already added")
+			c.Status(http.StatusConflict)
+			return
+		}
+
+		Audit.LogAction(c, models.ActionMapRotationConfig, models.SourceWebUI, true, "",
+			"custom_map", fmt.Sprintf("%d", custom.ID), custom.Name,
+			map[string]interface{}{"server_id": server.ID},
+			fmt.Sprintf("Added custom map %s to %s", custom.Name, server.Name))
+
+		c.Set("data", gin.H{"map": custom})
+		c.Status(http.StatusCreated)
+	}
+}
+
+// deleteCustomMap removes a custom map. Rotations that use it keep it until
+// they're next saved.
+func deleteCustomMap(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid map ID")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		custom, err := models.GetCustomMap(uint(id))
+		if err != nil {
+			c.Set("error", "Map not found")
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		err = models.DeleteCustomMap(custom.ID)
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		Audit.LogAction(c, models.ActionMapRotationConfig, models.SourceWebUI, err == nil, errMsg,
+			"custom_map", fmt.Sprintf("%d", custom.ID), custom.Name,
+			map[string]interface{}{"server_id": custom.ServerID},
+			fmt.Sprintf("Removed custom map %s", custom.Name))
+
+		if err != nil {
+			c.Set("error", "Failed to remove map")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{"message": "Map removed"})
+		c.Status(http.StatusOK)
+	}
+}
+
+// getMapVoteSettings returns the settings for !votemap and !rtv
+func getMapVoteSettings(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("data", gin.H{"settings": models.GetMapVoteSettings()})
+		c.Status(http.StatusOK)
+	}
+}
+
+func updateMapVoteSettings(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var settings models.MapVoteSettings
+		if err := c.ShouldBindJSON(&settings); err != nil {
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		if err := settings.Validate(); err != nil {
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		err := models.SaveMapVoteSettings(&settings)
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		Audit.LogAction(c, models.ActionMapRotationConfig, models.SourceWebUI, err == nil, errMsg,
+			"map_vote", "", "Map voting",
+			map[string]interface{}{
+				"duration_seconds": settings.DurationSeconds,
+				"vote_quorum":      settings.VoteQuorum,
+				"rtv_quorum":       settings.RTVQuorum,
+				"cooldown_minutes": settings.CooldownMinutes,
+				"max_candidates":   settings.MaxCandidates,
+				"blacklist":        settings.Blacklist,
+			}, "Updated map vote settings")
+
+		if err != nil {
+			c.Set("error", "Failed to save settings")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{"settings": settings})
+		c.Status(http.StatusOK)
+	}
+}
+
+// getMapRotations lists rotations, ?serverId= for one server's
+func getMapRotations(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var serverID *uint
+		if raw := c.Query("serverId"); raw != "" {
+			id, err := strconv.ParseUint(raw, 10, 32)
+			if err != nil {
+				c.Set("error", "Invalid server ID")
+				c.Status(http.StatusBadRequest)
+				return
+			}
+			sid := uint(id)
+			serverID = &sid
+		}
+
+		rotations, err := models.GetMapRotations(serverID)
+		if err != nil {
+			c.Set("error", "Failed to retrieve map rotations")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{"rotations": rotations})
+		c.Status(http.StatusOK)
+	}
+}
+
+func getMapRotation(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid rotation ID")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		rotation, err := models.GetMapRotation(uint(id))
+		if err != nil {
+			c.Set("error", "Rotation not found")
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		c.Set("data", gin.H{
+			"rotation":    rotation,
+			"mapRotation": rotation.String(),
+		})
+		c.Status(http.StatusOK)
+	}
+}
+
+// saveMapRotation creates a rotation, or replaces one when called with an
+// ID. Editing the active rotation pushes it again.
+func saveMapRotation(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req MapRotationRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		userVal, exists := c.Get("user")
+		if !exists {
+			c.Set("error", "User not found")
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+		user := userVal.(*models.User)
+
+		rotation := &models.MapRotation{CreatedByName: user.Username}
+		status := http.StatusCreated
+		if c.Param("id") != "" {
+			id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+			if err != nil {
+				c.Set("error", "Invalid rotation ID")
+				c.Status(http.StatusBadRequest)
+				return
+			}
+			rotation, err = models.GetMapRotation(uint(id))
+			if err != nil {
+				c.Set("error", "Rotation not found")
+				c.Status(http.StatusNotFound)
+				return
+			}
+			if rotation.ServerID != req.ServerID {
+				c.Set("error", "A rotation can't be moved to another server")
+				c.Status(http.StatusBadRequest)
+				return
+			}
+			status = http.StatusOK
+		}
+
+		rotation.ServerID = req.ServerID
+		rotation.Name = req.Name
+		rotation.Entries = req.Entries
+		rotation.AutoSwitch = req.AutoSwitch
+		rotation.MinPlayers = req.MinPlayers
+		rotation.MaxPlayers = req.MaxPlayers
+
+		_, adapter, err := serverAdapter(rotation.ServerID)
+		if err != nil {
+			c.Set("error", "Server not found")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		if err := maprotation.Check(rotation, adapter); err != nil {
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		err = models.SaveMapRotation(rotation)
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		Audit.LogAction(c, models.ActionMapRotationConfig, models.SourceWebUI, err == nil, errMsg,
+			"map_rotation", fmt.Sprintf("%d", rotation.ID), rotation.Name,
+			map[string]interface{}{
+				"server_id":   rotation.ServerID,
+				"rotation":    rotation.String(),
+				"auto_switch": rotation.AutoSwitch,
+				"min_players": rotation.MinPlayers,
+				"max_players": rotation.MaxPlayers,
+			}, fmt.Sprintf("Saved map rotation '%s'", rotation.Name))
+
+		if err != nil {
+			c.Set("error", "Failed to save rotation (names must be unique per server)")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		data := gin.H{"rotation": rotation}
+		if rotation.Active {
+			if inst, ok := api.servers.Get(rotation.ServerID); ok {
+				results, err := maprotation.Push(inst.RCON, rotation)
+				recordRotationCommands(user.ID, inst.ServerID(), results, err)
+				if err != nil {
+					data["pushError"] = err.Error()
+				}
+			}
+		}
+
+		c.Set("data", data)
+		c.Status(status)
+	}
+}
+
+func deleteMapRotation(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid rotation ID")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		rotation, err := models.GetMapRotation(uint(id))
+		if err != nil {
+			c.Set("error", "Rotation not found")
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		err = models.DeleteMapRotation(rotation.ID)
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		Audit.LogAction(c, models.ActionMapRotationConfig, models.SourceWebUI, err == nil, errMsg,
+			"map_rotation", fmt.Sprintf("%d", rotation.ID), rotation.Name,
+			map[string]interface{}{"server_id": rotation.ServerID},
+			fmt.Sprintf("Deleted map rotation '%s'", rotation.Name))
+
+		if err != nil {
+			c.Set("error", "Failed to delete rotation")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{"message": "Rotation deleted"})
+		c.Status(http.StatusOK)
+	}
+}
+
+// pushMapRotation sends a rotation to its server. The current map plays out
+// unless called with ?rotate=true.
+func pushMapRotation(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid rotation ID")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		userVal, exists := c.Get("user")
+		if !exists {
+			c.Set("error", "User not found")
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+		user := userVal.(*models.User)
+
+		rotation, err := models.GetMapRotation(uint(id))
+		if err != nil {
+			c.Set("error", "Rotation not found")
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		inst, ok := api.servers.Get(rotation.ServerID)
+		if !ok {
+			c.Set("error", "Server is not running")
+			c.Status(http.StatusServiceUnavailable)
+			return
+		}
+
+		results, err := maprotation.Push(inst.RCON, rotation)
+		if err == nil && c.Query("rotate") == "true" {
+			var result rcon.CommandResult
+			result, err = inst.RCON.MapRotate()
+			results = append(results, result)
+		}
+		recordRotationCommands(user.ID, inst.ServerID(), results, err)
+
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		Audit.LogAction(c, models.ActionMapRotationPush, models.SourceWebUI, err == nil, errMsg,
+			"map_rotation", fmt.Sprintf("%d", rotation.ID), rotation.Name,
+			map[string]interface{}{
+				"server_id": rotation.ServerID,
+				"rotation":  rotation.String(),
+				"rotate":    c.Query("rotate") == "true",
+			}, fmt.Sprintf("Pushed map rotation '%s' to %s", rotation.Name, inst.Server.Name))
+
+		if err != nil {
+			c.Set("error", err.Error())
+			c.Status(rconErrorStatus(err))
+			return
+		}
+
+		c.Set("data", gin.H{
+			"rotation": rotation,
+			"results":  results,
+		})
+		c.Status(http.StatusOK)
+	}
+}
+
+// recordRotationCommands adds the commands a push sent to the command
+// history. err is the last command's.
+func recordRotationCommands(userID uint, serverID *uint, results []rcon.CommandResult, err error) {
+	for i, result := range results {
+		if i == len(results)-1 && err != nil {
+			models.CreateCommandHistory(userID, result.Command, err.Error(), false, serverID)
+			continue
+		}
+		models.CreateCommandHistory(userID, result.Command, result.Response, true, serverID)
+	}
+}
@@ -10,6 +10,11 @@ func ResponseMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Next()
 
+		// Handlers serving files or plain text write their own body
+		if c.Writer.Size() > 0 {
+			return
+		}
+
 		data, _ := c.Get("data")
 
 		messagesVal, _ := c.Get("messages")
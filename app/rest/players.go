@@ -16,6 +16,9 @@ func RegisterPlayerRoutes(r *gin.Engine, api *Api) {
 		players.GET("", getPlayers(api))
 		players.GET("/ingame", getInGamePlayers(api))
 		players.GET("/:playerId", getPlayer(api))
+		players.GET("/:playerId/sessions", getPlayerSessions(api))
+		players.GET("/:playerId/playtime", getPlayerPlaytime(api))
+		players.GET("/:playerId/aliases", getPlayerAliases(api))
 	}
 }
 
@@ -48,7 +51,12 @@ func getInGamePlayers(api *Api) gin.HandlerFunc {
 
 func getPlayers(api *Api) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		status, err := api.rcon.Status()
+		client, _, ok := api.serverClient(c)
+		if !ok {
+			return
+		}
+
+		status, err := client.Status()
 		if err != nil {
 			c.Set("error", err.Error())
 			c.Status(500)
@@ -81,7 +89,12 @@ func getPlayer(api *Api) gin.HandlerFunc {
 			return
 		}
 
-		player, err := api.rcon.GetPlayer(identifier)
+		client, _, ok := api.serverClient(c)
+		if !ok {
+			return
+		}
+
+		player, err := client.GetPlayer(identifier)
 		if err != nil || player.PlayerID == "" {
 			c.Set("error", "Player not found")
 			c.Status(404)
@@ -101,3 +114,105 @@ func getPlayer(api *Api) gin.HandlerFunc {
 		c.Status(200)
 	}
 }
+
+// getPlayerSessions returns a player's session timeline, newest first.
+// playerId is the player's GUID.
+func getPlayerSessions(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		guid := c.Param("playerId")
+
+		var serverID *uint
+		if serverIDStr := c.Query("server_id"); serverIDStr != "" {
+			id, err := strconv.ParseUint(serverIDStr, 10, 32)
+			if err != nil {
+				c.Set("error", "Invalid server ID")
+				c.Status(http.StatusBadRequest)
+				return
+			}
+			sid := uint(id)
+			serverID = &sid
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit <= 0 {
+			limit = 50
+		}
+		if limit > 500 {
+			limit = 500
+		}
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			offset = 0
+		}
+
+		sessions, total, err := models.GetPlayerSessions(guid, serverID, limit, offset)
+		if err != nil {
+			c.Set("error", "Failed to retrieve player sessions")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{
+			"sessions": sessions,
+			"total":    total,
+			"limit":    limit,
+			"offset":   offset,
+		})
+		c.Status(http.StatusOK)
+	}
+}
+
+// getPlayerPlaytime returns a player's total playtime and first/last seen
+// times, from their sessions
+func getPlayerPlaytime(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		guid := c.Param("playerId")
+
+		summary, err := models.GetPlayerPlaytime(guid)
+		if err != nil {
+			c.Set("error", "Failed to retrieve player playtime")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", summary)
+		c.Status(http.StatusOK)
+	}
+}
+
+// getPlayerAliases returns a player's name and IP history along with other
+// GUIDs that share an IP or name with them. playerId is the player's GUID.
+func getPlayerAliases(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		guid := c.Param("playerId")
+
+		aliases, err := models.GetPlayerAliases(guid)
+		if err != nil {
+			c.Set("error", "Failed to retrieve aliases")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		ips, err := models.GetPlayerIPs(guid)
+		if err != nil {
+			c.Set("error", "Failed to retrieve IP history")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		linked, err := models.FindLinkedPlayers(guid)
+		if err != nil {
+			c.Set("error", "Failed to find linked players")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{
+			"guid":    guid,
+			"aliases": aliases,
+			"ips":     ips,
+			"linked":  linked,
+		})
+		c.Status(http.StatusOK)
+	}
+}
@@ -123,6 +123,9 @@ var (
 
 	// Login rate limiter: 5 attempts per minute per IP
 	LoginRateLimiter = NewRateLimiter(5, time.Minute, 2)
+
+	// Ban appeal rate limiter: 3 appeals per hour per IP
+	AppealRateLimiter = NewRateLimiter(3, time.Hour, 1)
 )
 
 // RateLimitMiddleware creates a rate limiting middleware
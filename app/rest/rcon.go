@@ -1,12 +1,17 @@
 package rest
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/ethanburkett/goadmin/app/events"
 	"github.com/ethanburkett/goadmin/app/models"
+	"github.com/ethanburkett/goadmin/app/rcon"
+	"github.com/ethanburkett/goadmin/app/rcon/commands"
 	"github.com/gin-gonic/gin"
 )
 
@@ -47,6 +52,7 @@ type ExecRequest struct {
 
 type UnbanRequest struct {
 	PlayerName string `json:"playerName" binding:"required"`
+	BanID      uint   `json:"banId"` // GoAdmin ban to lift when the name matches more than one
 }
 
 type DumpUserRequest struct {
@@ -98,8 +104,27 @@ func RegisterRconRoutes(r *gin.Engine, api *Api) {
 	}
 }
 
+// rconErrorStatus maps typed rcon errors to an HTTP status
+func rconErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, rcon.ErrPlayerNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, rcon.ErrBadSlot), errors.Is(err, rcon.ErrUnknownCvar):
+		return http.StatusBadRequest
+	case errors.Is(err, rcon.ErrLockedOut), errors.Is(err, rcon.ErrBadPassword), errors.Is(err, rcon.ErrRconBanned):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 func sendCommand(api *Api) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		client, serverID, ok := api.serverClient(c)
+		if !ok {
+			return
+		}
+
 		var req RconCommandRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.Set("error", "Invalid request")
@@ -131,14 +156,14 @@ func sendCommand(api *Api) gin.HandlerFunc {
 		}
 		user := userVal.(*models.User)
 
-		response, err := api.rcon.SendCommand(sanitizedCommand)
+		response, err := client.SendCommand(sanitizedCommand)
 		success := err == nil
 
 		// Save command history
 		if success {
-			models.CreateCommandHistory(user.ID, sanitizedCommand, response, true, nil)
+			models.CreateCommandHistory(user.ID, sanitizedCommand, response, true, serverID)
 		} else {
-			models.CreateCommandHistory(user.ID, sanitizedCommand, err.Error(), false, nil)
+			models.CreateCommandHistory(user.ID, sanitizedCommand, err.Error(), false, serverID)
 		}
 
 		// Log to audit trail
@@ -161,6 +186,11 @@ func sendCommand(api *Api) gin.HandlerFunc {
 
 func kickPlayer(api *Api) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		client, serverID, ok := api.serverClient(c)
+		if !ok {
+			return
+		}
+
 		var req KickRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.Set("error", "Invalid request")
@@ -177,19 +207,15 @@ func kickPlayer(api *Api) gin.HandlerFunc {
 		}
 		user := userVal.(*models.User)
 
-		command := "clientkick " + req.PlayerID
-		if req.Reason != "" {
-			command += " " + req.Reason
-		}
-
-		response, err := api.rcon.SendCommand(command)
+		result, err := client.Kick(req.PlayerID, req.Reason)
+		command, response := result.Command, result.Response
 		success := err == nil
 
 		// Save command history
 		if success {
-			models.CreateCommandHistory(user.ID, command, response, true, nil)
+			models.CreateCommandHistory(user.ID, command, response, true, serverID)
 		} else {
-			models.CreateCommandHistory(user.ID, command, err.Error(), false, nil)
+			models.CreateCommandHistory(user.ID, command, err.Error(), false, serverID)
 		}
 
 		// Log to audit trail
@@ -201,10 +227,18 @@ func kickPlayer(api *Api) gin.HandlerFunc {
 
 		if err != nil {
 			c.Set("error", err.Error())
-			c.Status(http.StatusInternalServerError)
+			c.Status(rconErrorStatus(err))
 			return
 		}
 
+		events.Publish(events.PlayerKicked, serverID, events.KickPayload{
+			PlayerID:   req.PlayerID,
+			KickedBy:   user.Username,
+			KickedByID: &user.ID,
+			Reason:     req.Reason,
+			Source:     "web",
+		})
+
 		c.Set("data", gin.H{"response": response})
 		c.Status(http.StatusOK)
 	}
@@ -212,6 +246,11 @@ func kickPlayer(api *Api) gin.HandlerFunc {
 
 func banPlayer(api *Api) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		client, serverID, ok := api.serverClient(c)
+		if !ok {
+			return
+		}
+
 		var req BanRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.Set("error", "Invalid request")
@@ -228,41 +267,87 @@ func banPlayer(api *Api) gin.HandlerFunc {
 		}
 		user := userVal.(*models.User)
 
-		command := "banclient " + req.PlayerID
-		if req.Reason != "" {
-			command += " " + req.Reason
+		// Resolve the slot to the player so the ban follows their GUID and
+		// is enforced on every server, not just this one's ban file. A slot
+		// number is never stored as a GUID.
+		status, err := client.Status()
+		if err != nil {
+			c.Set("error", "Failed to get server status: "+err.Error())
+			c.Status(http.StatusBadGateway)
+			return
+		}
+		var target *rcon.StatusPlayer
+		for i := range status.Players {
+			player := &status.Players[i]
+			if strconv.Itoa(player.ID) == req.PlayerID || strings.EqualFold(player.Uuid, req.PlayerID) {
+				target = player
+				break
+			}
+		}
+		if target == nil || target.Uuid == "" {
+			c.Set("error", "Player not found")
+			c.Status(http.StatusNotFound)
+			return
 		}
 
-		response, err := api.rcon.SendCommand(command)
-		success := err == nil
-
-		// Save command history
-		if success {
-			models.CreateCommandHistory(user.ID, command, response, true, nil)
-		} else {
-			models.CreateCommandHistory(user.ID, command, err.Error(), false, nil)
+		ban := &models.Ban{
+			Type:           models.BanTypeGUID,
+			Value:          target.Uuid,
+			PlayerName:     target.StrippedName,
+			PlayerGUID:     target.Uuid,
+			Reason:         req.Reason,
+			BannedByUserID: &user.ID,
+			BannedByName:   user.Username,
+			ServerID:       serverID,
+			Source:         "web",
+		}
+		if ban.Reason == "" {
+			ban.Reason = "Banned by an admin"
 		}
 
-		// Log to audit trail
+		err = models.CreateBan(ban)
 		var errorMsg string
 		if err != nil {
 			errorMsg = err.Error()
 		}
-		Audit.LogBan(c, "", req.PlayerID, req.Reason, success, errorMsg)
+		Audit.LogBan(c, ban.PlayerName, ban.Value, ban.Reason, err == nil, errorMsg)
 
 		if err != nil {
-			c.Set("error", err.Error())
+			c.Set("error", "Failed to create ban")
 			c.Status(http.StatusInternalServerError)
 			return
 		}
 
-		c.Set("data", gin.H{"response": response})
+		result, err := client.Kick(strconv.Itoa(target.ID), ban.KickMessage())
+		if err == nil {
+			models.CreateCommandHistory(user.ID, result.Command, result.Response, true, serverID)
+		} else {
+			models.CreateCommandHistory(user.ID, result.Command, err.Error(), false, serverID)
+		}
+
+		events.Publish(events.PlayerBanned, serverID, events.BanPayload{
+			PlayerName: ban.PlayerName,
+			PlayerGUID: ban.Value,
+			BannedBy:   user.Username,
+			BannedByID: &user.ID,
+			Reason:     ban.Reason,
+			BanType:    "permanent",
+			Source:     "web",
+			BanID:      &ban.ID,
+		})
+
+		c.Set("data", gin.H{"response": result.Response, "ban": ban})
 		c.Status(http.StatusOK)
 	}
 }
 
 func sayMessage(api *Api) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		client, serverID, ok := api.serverClient(c)
+		if !ok {
+			return
+		}
+
 		var req SayRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.Set("error", "Invalid request")
@@ -279,20 +364,20 @@ func sayMessage(api *Api) gin.HandlerFunc {
 		}
 		user := userVal.(*models.User)
 
-		command := "say " + req.Message
-		response, err := api.rcon.SendCommand(command)
+		result, err := client.Say(req.Message)
+		command, response := result.Command, result.Response
 		success := err == nil
 
 		// Save command history
 		if success {
-			models.CreateCommandHistory(user.ID, command, response, true, nil)
+			models.CreateCommandHistory(user.ID, command, response, true, serverID)
 		} else {
-			models.CreateCommandHistory(user.ID, command, err.Error(), false, nil)
+			models.CreateCommandHistory(user.ID, command, err.Error(), false, serverID)
 		}
 
 		if err != nil {
 			c.Set("error", err.Error())
-			c.Status(http.StatusInternalServerError)
+			c.Status(rconErrorStatus(err))
 			return
 		}
 
@@ -342,6 +427,11 @@ func getCommandHistory(api *Api) gin.HandlerFunc {
 
 func unbanPlayer(api *Api) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		client, serverID, ok := api.serverClient(c)
+		if !ok {
+			return
+		}
+
 		var req UnbanRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.Set("error", "Invalid request")
@@ -357,29 +447,69 @@ func unbanPlayer(api *Api) gin.HandlerFunc {
 		}
 		user := userVal.(*models.User)
 
-		command := "unbanUser " + req.PlayerName
-		response, err := api.rcon.SendCommand(command)
+		// Lift GoAdmin bans for the player as well as the server's own
+		var bans []models.Ban
+		if req.BanID != 0 {
+			ban, err := models.GetBanByID(req.BanID)
+			if err != nil || !ban.IsInEffect() {
+				c.Set("error", "Ban not found")
+				c.Status(http.StatusNotFound)
+				return
+			}
+			bans = []models.Ban{*ban}
+		} else {
+			found, err := models.FindBansForPlayer(req.PlayerName)
+			if err == models.ErrAmbiguousBan {
+				c.Set("error", "More than one ban matches this player, specify the ban ID")
+				c.Status(http.StatusConflict)
+				return
+			}
+			bans = found
+		}
+
+		revoked := 0
+		for _, ban := range bans {
+			if models.RevokeBan(ban.ID, user.Username, "Unbanned from player management") == nil {
+				revoked++
+			}
+		}
+
+		result, err := client.UnbanUser(req.PlayerName)
+		command, response := result.Command, result.Response
 		success := err == nil
 
 		if success {
-			models.CreateCommandHistory(user.ID, command, response, true, nil)
+			models.CreateCommandHistory(user.ID, command, response, true, serverID)
 		} else {
-			models.CreateCommandHistory(user.ID, command, err.Error(), false, nil)
+			models.CreateCommandHistory(user.ID, command, err.Error(), false, serverID)
 		}
 
-		if err != nil {
+		// The server not knowing the player is fine if GoAdmin held the ban
+		if err != nil && revoked == 0 {
 			c.Set("error", err.Error())
-			c.Status(http.StatusInternalServerError)
+			c.Status(rconErrorStatus(err))
 			return
 		}
 
-		c.Set("data", gin.H{"response": response})
+		events.Publish(events.PlayerUnbanned, serverID, events.BanPayload{
+			PlayerName: req.PlayerName,
+			BannedBy:   user.Username,
+			BannedByID: &user.ID,
+			Source:     "web",
+		})
+
+		c.Set("data", gin.H{"response": response, "revokedBans": revoked})
 		c.Status(http.StatusOK)
 	}
 }
 
 func dumpUser(api *Api) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		client, serverID, ok := api.serverClient(c)
+		if !ok {
+			return
+		}
+
 		var req DumpUserRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.Set("error", "Invalid request")
@@ -395,29 +525,34 @@ func dumpUser(api *Api) gin.HandlerFunc {
 		}
 		user := userVal.(*models.User)
 
-		command := "dumpuser " + req.PlayerName
-		response, err := api.rcon.SendCommand(command)
+		command := "dumpuser " + commands.Quote(req.PlayerName)
+		info, err := client.DumpUser(req.PlayerName)
 		success := err == nil
 
 		if success {
-			models.CreateCommandHistory(user.ID, command, response, true, nil)
+			models.CreateCommandHistory(user.ID, command, info.Name, true, serverID)
 		} else {
-			models.CreateCommandHistory(user.ID, command, err.Error(), false, nil)
+			models.CreateCommandHistory(user.ID, command, err.Error(), false, serverID)
 		}
 
 		if err != nil {
 			c.Set("error", err.Error())
-			c.Status(http.StatusInternalServerError)
+			c.Status(rconErrorStatus(err))
 			return
 		}
 
-		c.Set("data", gin.H{"response": response})
+		c.Set("data", gin.H{"info": info})
 		c.Status(http.StatusOK)
 	}
 }
 
 func tellPlayer(api *Api) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		client, serverID, ok := api.serverClient(c)
+		if !ok {
+			return
+		}
+
 		var req TellRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.Set("error", "Invalid request")
@@ -433,19 +568,19 @@ func tellPlayer(api *Api) gin.HandlerFunc {
 		}
 		user := userVal.(*models.User)
 
-		command := "tell " + req.PlayerID + " " + req.Message
-		response, err := api.rcon.SendCommand(command)
+		result, err := client.Tell(req.PlayerID, req.Message)
+		command, response := result.Command, result.Response
 		success := err == nil
 
 		if success {
-			models.CreateCommandHistory(user.ID, command, response, true, nil)
+			models.CreateCommandHistory(user.ID, command, response, true, serverID)
 		} else {
-			models.CreateCommandHistory(user.ID, command, err.Error(), false, nil)
+			models.CreateCommandHistory(user.ID, command, err.Error(), false, serverID)
 		}
 
 		if err != nil {
 			c.Set("error", err.Error())
-			c.Status(http.StatusInternalServerError)
+			c.Status(rconErrorStatus(err))
 			return
 		}
 
@@ -458,6 +593,11 @@ func tellPlayer(api *Api) gin.HandlerFunc {
 
 func changeMap(api *Api) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		client, serverID, ok := api.serverClient(c)
+		if !ok {
+			return
+		}
+
 		var req MapChangeRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.Set("error", "Invalid request")
@@ -473,19 +613,25 @@ func changeMap(api *Api) gin.HandlerFunc {
 		}
 		user := userVal.(*models.User)
 
-		command := "map " + req.MapName
-		response, err := api.rcon.SendCommand(command)
+		if !commands.IsIdentifier(req.MapName) {
+			c.Set("error", "Invalid map name")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		result, err := client.Map(req.MapName)
+		command, response := result.Command, result.Response
 		success := err == nil
 
 		if success {
-			models.CreateCommandHistory(user.ID, command, response, true, nil)
+			models.CreateCommandHistory(user.ID, command, response, true, serverID)
 		} else {
-			models.CreateCommandHistory(user.ID, command, err.Error(), false, nil)
+			models.CreateCommandHistory(user.ID, command, err.Error(), false, serverID)
 		}
 
 		if err != nil {
 			c.Set("error", err.Error())
-			c.Status(http.StatusInternalServerError)
+			c.Status(rconErrorStatus(err))
 			return
 		}
 
@@ -496,6 +642,11 @@ func changeMap(api *Api) gin.HandlerFunc {
 
 func mapRotate(api *Api) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		client, serverID, ok := api.serverClient(c)
+		if !ok {
+			return
+		}
+
 		userVal, exists := c.Get("user")
 		if !exists {
 			c.Set("error", "User not found")
@@ -504,19 +655,19 @@ func mapRotate(api *Api) gin.HandlerFunc {
 		}
 		user := userVal.(*models.User)
 
-		command := "map_rotate"
-		response, err := api.rcon.SendCommand(command)
+		result, err := client.MapRotate()
+		command, response := result.Command, result.Response
 		success := err == nil
 
 		if success {
-			models.CreateCommandHistory(user.ID, command, response, true, nil)
+			models.CreateCommandHistory(user.ID, command, response, true, serverID)
 		} else {
-			models.CreateCommandHistory(user.ID, command, err.Error(), false, nil)
+			models.CreateCommandHistory(user.ID, command, err.Error(), false, serverID)
 		}
 
 		if err != nil {
 			c.Set("error", err.Error())
-			c.Status(http.StatusInternalServerError)
+			c.Status(rconErrorStatus(err))
 			return
 		}
 
@@ -527,6 +678,11 @@ func mapRotate(api *Api) gin.HandlerFunc {
 
 func mapRestart(api *Api) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		client, serverID, ok := api.serverClient(c)
+		if !ok {
+			return
+		}
+
 		userVal, exists := c.Get("user")
 		if !exists {
 			c.Set("error", "User not found")
@@ -536,13 +692,13 @@ func mapRestart(api *Api) gin.HandlerFunc {
 		user := userVal.(*models.User)
 
 		command := "map_restart"
-		response, err := api.rcon.SendCommand(command)
+		response, err := client.SendCommand(command)
 		success := err == nil
 
 		if success {
-			models.CreateCommandHistory(user.ID, command, response, true, nil)
+			models.CreateCommandHistory(user.ID, command, response, true, serverID)
 		} else {
-			models.CreateCommandHistory(user.ID, command, err.Error(), false, nil)
+			models.CreateCommandHistory(user.ID, command, err.Error(), false, serverID)
 		}
 
 		if err != nil {
@@ -558,6 +714,11 @@ func mapRestart(api *Api) gin.HandlerFunc {
 
 func fastRestart(api *Api) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		client, serverID, ok := api.serverClient(c)
+		if !ok {
+			return
+		}
+
 		userVal, exists := c.Get("user")
 		if !exists {
 			c.Set("error", "User not found")
@@ -567,13 +728,13 @@ func fastRestart(api *Api) gin.HandlerFunc {
 		user := userVal.(*models.User)
 
 		command := "fast_restart"
-		response, err := api.rcon.SendCommand(command)
+		response, err := client.SendCommand(command)
 		success := err == nil
 
 		if success {
-			models.CreateCommandHistory(user.ID, command, response, true, nil)
+			models.CreateCommandHistory(user.ID, command, response, true, serverID)
 		} else {
-			models.CreateCommandHistory(user.ID, command, err.Error(), false, nil)
+			models.CreateCommandHistory(user.ID, command, err.Error(), false, serverID)
 		}
 
 		if err != nil {
@@ -589,6 +750,11 @@ func fastRestart(api *Api) gin.HandlerFunc {
 
 func changeGametype(api *Api) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		client, serverID, ok := api.serverClient(c)
+		if !ok {
+			return
+		}
+
 		var req GametypeChangeRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.Set("error", "Invalid request")
@@ -604,19 +770,25 @@ func changeGametype(api *Api) gin.HandlerFunc {
 		}
 		user := userVal.(*models.User)
 
-		command := "g_gametype " + req.Gametype
-		response, err := api.rcon.SendCommand(command)
+		if !commands.IsIdentifier(req.Gametype) {
+			c.Set("error", "Invalid gametype")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		result, err := client.SetCvar("g_gametype", req.Gametype)
+		command, response := result.Command, result.Response
 		success := err == nil
 
 		if success {
-			models.CreateCommandHistory(user.ID, command, response, true, nil)
+			models.CreateCommandHistory(user.ID, command, response, true, serverID)
 		} else {
-			models.CreateCommandHistory(user.ID, command, err.Error(), false, nil)
+			models.CreateCommandHistory(user.ID, command, err.Error(), false, serverID)
 		}
 
 		if err != nil {
 			c.Set("error", err.Error())
-			c.Status(http.StatusInternalServerError)
+			c.Status(rconErrorStatus(err))
 			return
 		}
 
@@ -629,6 +801,11 @@ func changeGametype(api *Api) gin.HandlerFunc {
 
 func execConfig(api *Api) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		client, serverID, ok := api.serverClient(c)
+		if !ok {
+			return
+		}
+
 		var req ExecRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.Set("error", "Invalid request")
@@ -644,19 +821,25 @@ func execConfig(api *Api) gin.HandlerFunc {
 		}
 		user := userVal.(*models.User)
 
-		command := "exec " + req.Filename
-		response, err := api.rcon.SendCommand(command)
+		if !commands.IsIdentifier(req.Filename) {
+			c.Set("error", "Invalid config file name")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		result, err := client.ExecConfig(req.Filename)
+		command, response := result.Command, result.Response
 		success := err == nil
 
 		if success {
-			models.CreateCommandHistory(user.ID, command, response, true, nil)
+			models.CreateCommandHistory(user.ID, command, response, true, serverID)
 		} else {
-			models.CreateCommandHistory(user.ID, command, err.Error(), false, nil)
+			models.CreateCommandHistory(user.ID, command, err.Error(), false, serverID)
 		}
 
 		if err != nil {
 			c.Set("error", err.Error())
-			c.Status(http.StatusInternalServerError)
+			c.Status(rconErrorStatus(err))
 			return
 		}
 
@@ -667,6 +850,11 @@ func execConfig(api *Api) gin.HandlerFunc {
 
 func writeConfig(api *Api) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		client, serverID, ok := api.serverClient(c)
+		if !ok {
+			return
+		}
+
 		var req ExecRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.Set("error", "Invalid request")
@@ -682,19 +870,25 @@ func writeConfig(api *Api) gin.HandlerFunc {
 		}
 		user := userVal.(*models.User)
 
-		command := "writeconfig " + req.Filename
-		response, err := api.rcon.SendCommand(command)
+		if !commands.IsIdentifier(req.Filename) {
+			c.Set("error", "Invalid config file name")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		result, err := client.WriteConfig(req.Filename)
+		command, response := result.Command, result.Response
 		success := err == nil
 
 		if success {
-			models.CreateCommandHistory(user.ID, command, response, true, nil)
+			models.CreateCommandHistory(user.ID, command, response, true, serverID)
 		} else {
-			models.CreateCommandHistory(user.ID, command, err.Error(), false, nil)
+			models.CreateCommandHistory(user.ID, command, err.Error(), false, serverID)
 		}
 
 		if err != nil {
 			c.Set("error", err.Error())
-			c.Status(http.StatusInternalServerError)
+			c.Status(rconErrorStatus(err))
 			return
 		}
 
@@ -705,6 +899,11 @@ func writeConfig(api *Api) gin.HandlerFunc {
 
 func setCvar(api *Api) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		client, serverID, ok := api.serverClient(c)
+		if !ok {
+			return
+		}
+
 		var req SetCvarRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.Set("error", "Invalid request")
@@ -720,19 +919,25 @@ func setCvar(api *Api) gin.HandlerFunc {
 		}
 		user := userVal.(*models.User)
 
-		command := "set " + req.Cvar + " " + req.Value
-		response, err := api.rcon.SendCommand(command)
+		if !commands.IsIdentifier(req.Cvar) {
+			c.Set("error", "Invalid cvar name")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		result, err := client.SetCvar(req.Cvar, req.Value)
+		command, response := result.Command, result.Response
 		success := err == nil
 
 		if success {
-			models.CreateCommandHistory(user.ID, command, response, true, nil)
+			models.CreateCommandHistory(user.ID, command, response, true, serverID)
 		} else {
-			models.CreateCommandHistory(user.ID, command, err.Error(), false, nil)
+			models.CreateCommandHistory(user.ID, command, err.Error(), false, serverID)
 		}
 
 		if err != nil {
 			c.Set("error", err.Error())
-			c.Status(http.StatusInternalServerError)
+			c.Status(rconErrorStatus(err))
 			return
 		}
 
@@ -743,21 +948,31 @@ func setCvar(api *Api) gin.HandlerFunc {
 
 func getServerInfo(api *Api) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		response, err := api.rcon.SendCommand("serverinfo")
+		client, _, ok := api.serverClient(c)
+		if !ok {
+			return
+		}
+
+		info, err := client.ServerInfo()
 		if err != nil {
 			c.Set("error", err.Error())
-			c.Status(http.StatusInternalServerError)
+			c.Status(rconErrorStatus(err))
 			return
 		}
 
-		c.Set("data", gin.H{"response": response})
+		c.Set("data", gin.H{"response": info.Raw, "info": info})
 		c.Status(http.StatusOK)
 	}
 }
 
 func getSystemInfo(api *Api) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		response, err := api.rcon.SendCommand("systeminfo")
+		client, _, ok := api.serverClient(c)
+		if !ok {
+			return
+		}
+
+		response, err := client.SendCommand("systeminfo")
 		if err != nil {
 			c.Set("error", err.Error())
 			c.Status(http.StatusInternalServerError)
@@ -830,7 +1045,20 @@ func getSystemStats(api *Api) gin.HandlerFunc {
 			}
 		}
 
-		stats, err := models.GetSystemStatsRange(startTime, endTime)
+		// Optional server ID filter
+		var serverID *uint
+		if serverIDStr := c.Query("server_id"); serverIDStr != "" {
+			id, err := strconv.ParseUint(serverIDStr, 10, 32)
+			if err != nil {
+				c.Set("error", "Invalid server ID")
+				c.Status(http.StatusBadRequest)
+				return
+			}
+			sid := uint(id)
+			serverID = &sid
+		}
+
+		stats, err := models.GetSystemStatsRange(startTime, endTime, serverID)
 		if err != nil {
 			c.Set("error", "Failed to retrieve system stats")
 			c.Status(http.StatusInternalServerError)
@@ -858,7 +1086,20 @@ func getPlayerStats(api *Api) gin.HandlerFunc {
 			}
 		}
 
-		stats, err := models.GetPlayerStatsRange(startTime, endTime)
+		// Optional server ID filter
+		var serverID *uint
+		if serverIDStr := c.Query("server_id"); serverIDStr != "" {
+			id, err := strconv.ParseUint(serverIDStr, 10, 32)
+			if err != nil {
+				c.Set("error", "Invalid server ID")
+				c.Status(http.StatusBadRequest)
+				return
+			}
+			sid := uint(id)
+			serverID = &sid
+		}
+
+		stats, err := models.GetPlayerStatsRange(startTime, endTime, serverID)
 		if err != nil {
 			c.Set("error", "Failed to retrieve player stats")
 			c.Status(http.StatusInternalServerError)
@@ -3,10 +3,12 @@ package rest
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/ethanburkett/goadmin/app/events"
 	"github.com/ethanburkett/goadmin/app/models"
-	"github.com/ethanburkett/goadmin/app/webhook"
+	"github.com/ethanburkett/goadmin/app/rcon"
 	"github.com/gin-gonic/gin"
 )
 
@@ -147,24 +149,60 @@ func actionReport(api *Api) gin.HandlerFunc {
 		updates := make(map[string]interface{})
 		updates["reviewed_by_user_id"] = uid
 
+		// Set when the reported player was online but couldn't be kicked
+		var kickErr error
+
 		switch req.Action {
 		case "dismiss":
 			updates["status"] = "dismissed"
 			updates["action_taken"] = req.Reason
 
 		case "ban":
+			inst, err := api.servers.Resolve(report.ServerID)
+			if err != nil {
+				c.Set("error", err.Error())
+				c.Status(http.StatusServiceUnavailable)
+				return
+			}
+
 			updates["status"] = "actioned"
 			updates["action_taken"] = "Permanently banned: " + req.Reason
-			// Execute permanent ban via RCON
-			_, err := api.rcon.SendCommand("banClient " + report.ReportedGUID + " " + req.Reason)
-			if err != nil {
+			// Add to the ban list, which is enforced on every server
+			ban := &models.Ban{
+				Type:           models.BanTypeGUID,
+				Value:          report.ReportedGUID,
+				PlayerName:     report.ReportedName,
+				PlayerGUID:     report.ReportedGUID,
+				Reason:         req.Reason,
+				BannedByUserID: &uid,
+				BannedByName:   user.Username,
+				ServerID:       report.ServerID,
+				Source:         "report",
+			}
+			if err := models.CreateBan(ban); err != nil {
 				Audit.LogBan(c, report.ReportedName, report.ReportedGUID, req.Reason, false, err.Error())
-				c.Set("error", "Failed to execute ban")
+				c.Set("error", "Failed to create ban")
 				c.Status(http.StatusInternalServerError)
 				return
 			}
 			Audit.LogBan(c, report.ReportedName, report.ReportedGUID, req.Reason, true, "")
 
+			// Kick the player - they might already be offline
+			kickErr = kickByGUID(inst.RCON, report.ReportedGUID, ban.KickMessage())
+
+			reportID := uint(id)
+			events.Publish(events.PlayerBanned, report.ServerID, events.BanPayload{
+				PlayerName: report.ReportedName,
+				PlayerGUID: report.ReportedGUID,
+				BannedBy:   user.Username,
+				BannedByID: &uid,
+				Reason:     req.Reason,
+				BanType:    "permanent",
+				Source:     "web",
+				ReportID:   &reportID,
+				BanID:      &ban.ID,
+			})
+
 		case "tempban":
 			if req.Duration == nil || *req.Duration <= 0 {
 				c.Set("error", "Duration required for temporary ban")
@@ -172,6 +210,13 @@ func actionReport(api *Api) gin.HandlerFunc {
 				return
 			}
 
+			inst, err := api.servers.Resolve(report.ServerID)
+			if err != nil {
+				c.Set("error", err.Error())
+				c.Status(http.StatusServiceUnavailable)
+				return
+			}
+
 			// Check for ban loop abuse (5 bans in 15 minutes)
 			banLoopResult, err := models.BanLoopDetectorInstance.CheckCircularBan(
 				report.ReportedGUID, &uid, 15*time.Minute, 5)
@@ -195,35 +240,26 @@ func actionReport(api *Api) gin.HandlerFunc {
 				return
 			}
 
-			// Dispatch webhook event
-			adminName := "unknown"
-			if userVal, exists := c.Get("user"); exists {
-				if user, ok := userVal.(*models.User); ok {
-					adminName = user.Username
-				}
-			}
-			go webhook.GlobalDispatcher.Dispatch(models.WebhookEventPlayerBanned, map[string]interface{}{
-				"player_name":    report.ReportedName,
-				"player_guid":    report.ReportedGUID,
-				"banned_by":      adminName,
-				"banned_by_id":   uid,
-				"reason":         req.Reason,
-				"duration":       strconv.Itoa(*req.Duration) + " hours",
-				"expires_at":     tempBan.ExpiresAt.Format(time.RFC3339),
-				"ban_type":       "temporary",
-				"source":         "web",
-				"report_id":      id,
-				"abuse_detected": banLoopResult.IsAbuse,
+			reportID := uint(id)
+			events.Publish(events.PlayerBanned, report.ServerID, events.BanPayload{
+				PlayerName:    report.ReportedName,
+				PlayerGUID:    report.ReportedGUID,
+				BannedBy:      user.Username,
+				BannedByID:    &uid,
+				Reason:        req.Reason,
+				Duration:      strconv.Itoa(*req.Duration) + " hours",
+				ExpiresAt:     tempBan.ExpiresAt.Format(time.RFC3339),
+				BanType:       "temporary",
+				Source:        "web",
+				ReportID:      &reportID,
+				AbuseDetected: banLoopResult.IsAbuse,
 			})
 
 			updates["status"] = "actioned"
 			updates["action_taken"] = "Temporarily banned for " + strconv.Itoa(*req.Duration) + " hours: " + req.Reason
 
-			// Kick the player
-			_, err = api.rcon.SendCommand("clientkick " + report.ReportedGUID + " Temporarily banned: " + req.Reason)
-			if err != nil {
-				// Log error but continue - they might already be offline
-			}
+			// Kick the player - they might already be offline
+			kickErr = kickByGUID(inst.RCON, report.ReportedGUID, "Temporarily banned: "+req.Reason)
 
 			Audit.LogTempBan(c, report.ReportedName, report.ReportedGUID, req.Reason, *req.Duration, true, "")
 
@@ -240,32 +276,46 @@ func actionReport(api *Api) gin.HandlerFunc {
 			return
 		}
 
-		// Dispatch webhook event for report action
-		adminName := "unknown"
-		if userVal, exists := c.Get("user"); exists {
-			if user, ok := userVal.(*models.User); ok {
-				adminName = user.Username
-			}
-		}
-		go webhook.GlobalDispatcher.Dispatch(models.WebhookEventReportActioned, map[string]interface{}{
-			"report_id":      id,
-			"action":         req.Action,
-			"action_taken":   updates["action_taken"],
-			"actioned_by":    adminName,
-			"actioned_by_id": uid,
-			"reason":         req.Reason,
-			"reported_name":  report.ReportedName,
-			"reported_guid":  report.ReportedGUID,
-			"reporter_name":  report.ReporterName,
-			"reporter_guid":  report.ReporterGUID,
-			"source":         "web",
+		actionTaken, _ := updates["action_taken"].(string)
+		events.Publish(events.ReportActioned, report.ServerID, events.ReportPayload{
+			ReportID:     uint(id),
+			Action:       req.Action,
+			ActionTaken:  actionTaken,
+			ActionedBy:   user.Username,
+			ActionedByID: &uid,
+			Reason:       req.Reason,
+			ReportedName: report.ReportedName,
+			ReportedGUID: report.ReportedGUID,
+			ReporterName: report.ReporterName,
+			ReporterGUID: report.ReporterGUID,
+			Source:       "web",
 		})
 
-		c.Set("data", gin.H{"message": "Report actioned successfully"})
+		data := gin.H{"message": "Report actioned successfully"}
+		if kickErr != nil {
+			data["kickError"] = kickErr.Error()
+		}
+		c.Set("data", data)
 		c.Status(http.StatusOK)
 	}
 }
 
+// kickByGUID kicks a player from the slot they hold in status. clientkick
+// only takes a slot; a player who isn't on the server is not an error.
+func kickByGUID(client *rcon.Client, guid, reason string) error {
+	status, err := client.Status()
+	if err != nil {
+		return err
+	}
+	for _, player := range status.Players {
+		if strings.EqualFold(player.Uuid, guid) {
+			_, err := client.Kick(strconv.Itoa(player.ID), reason)
+			return err
+		}
+	}
+	return nil
+}
+
 func deleteReport(api *Api) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -4,8 +4,12 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/ethanburkett/goadmin/app/games"
+	"github.com/ethanburkett/goadmin/app/logger"
 	"github.com/ethanburkett/goadmin/app/models"
+	"github.com/ethanburkett/goadmin/app/watcher"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 type CreateServerRequest struct {
@@ -15,6 +19,9 @@ type CreateServerRequest struct {
 	RconPort     int    `json:"rconPort" binding:"required"`
 	RconPassword string `json:"rconPassword" binding:"required"`
 	GamesMpPath  string `json:"gamesMpPath"`
+	Game         string `json:"game"`
+	LogSource    string `json:"logSource"`
+	LogAddress   string `json:"logAddress"`
 	Description  string `json:"description"`
 	Region       string `json:"region"`
 	MaxPlayers   int    `json:"maxPlayers"`
@@ -28,6 +35,9 @@ type UpdateServerRequest struct {
 	RconPort     *int    `json:"rconPort"`
 	RconPassword *string `json:"rconPassword"`
 	GamesMpPath  *string `json:"gamesMpPath"`
+	Game         *string `json:"game"`
+	LogSource    *string `json:"logSource"`
+	LogAddress   *string `json:"logAddress"`
 	Description  *string `json:"description"`
 	Region       *string `json:"region"`
 	MaxPlayers   *int    `json:"maxPlayers"`
@@ -42,6 +52,7 @@ func RegisterServerRoutes(r *gin.Engine, api *Api) {
 		servers.GET("", getAllServers(api))
 		servers.GET("/active", getActiveServers(api))
 		servers.GET("/default", getDefaultServer(api))
+		servers.GET("/games", getSupportedGames(api))
 		servers.POST("", RequirePermission("servers.manage"), createServer(api))
 		servers.GET("/:id", getServer(api))
 		servers.PUT("/:id", RequirePermission("servers.manage"), updateServer(api))
@@ -49,6 +60,7 @@ func RegisterServerRoutes(r *gin.Engine, api *Api) {
 		servers.POST("/:id/default", RequirePermission("servers.manage"), setDefaultServer(api))
 		servers.POST("/:id/activate", RequirePermission("servers.manage"), activateServer(api))
 		servers.POST("/:id/deactivate", RequirePermission("servers.manage"), deactivateServer(api))
+		servers.POST("/:id/log-token", RequirePermission("servers.manage"), rotateServerLogToken(api))
 	}
 }
 
@@ -94,6 +106,13 @@ func getDefaultServer(api *Api) gin.HandlerFunc {
 	}
 }
 
+func getSupportedGames(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("data", games.List())
+		c.Status(http.StatusOK)
+	}
+}
+
 func getServer(api *Api) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -124,11 +143,28 @@ func createServer(api *Api) gin.HandlerFunc {
 			return
 		}
 
+		if req.Game == "" {
+			req.Game = games.DefaultGame
+		}
+		if _, err := games.Get(req.Game); err != nil {
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		if !watcher.ValidSourceType(req.LogSource) {
+			c.Set("error", "Invalid log source: "+req.LogSource)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
 		server, err := models.CreateServer(
 			req.Name,
 			req.Host,
 			req.RconPassword,
 			req.GamesMpPath,
+			req.Game,
+			req.LogSource,
+			req.LogAddress,
 			req.Description,
 			req.Region,
 			req.Port,
@@ -149,9 +185,12 @@ func createServer(api *Api) gin.HandlerFunc {
 				"host":   server.Host,
 				"port":   server.Port,
 				"region": server.Region,
+				"game":   server.Game,
 			},
 			"Server created successfully")
 
+		api.reloadServer(server.ID)
+
 		c.Set("data", server)
 		c.Status(http.StatusCreated)
 	}
@@ -200,6 +239,25 @@ func updateServer(api *Api) gin.HandlerFunc {
 		if req.GamesMpPath != nil {
 			updates["games_mp_path"] = *req.GamesMpPath
 		}
+		if req.Game != nil {
+			if _, err := games.Get(*req.Game); err != nil {
+				c.Set("error", err.Error())
+				c.Status(http.StatusBadRequest)
+				return
+			}
+			updates["game"] = *req.Game
+		}
+		if req.LogSource != nil {
+			if !watcher.ValidSourceType(*req.LogSource) {
+				c.Set("error", "Invalid log source: "+*req.LogSource)
+				c.Status(http.StatusBadRequest)
+				return
+			}
+			updates["log_source"] = *req.LogSource
+		}
+		if req.LogAddress != nil {
+			updates["log_address"] = *req.LogAddress
+		}
 		if req.Description != nil {
 			updates["description"] = *req.Description
 		}
@@ -230,6 +288,8 @@ func updateServer(api *Api) gin.HandlerFunc {
 			},
 			"Server updated successfully")
 
+		api.reloadServer(uint(id))
+
 		c.Set("data", gin.H{"message": "Server updated successfully"})
 		c.Status(http.StatusOK)
 	}
@@ -271,6 +331,8 @@ func deleteServer(api *Api) gin.HandlerFunc {
 			nil,
 			"Server deleted successfully")
 
+		api.servers.Stop(uint(id))
+
 		c.Set("data", gin.H{"message": "Server deleted successfully"})
 		c.Status(http.StatusOK)
 	}
@@ -341,6 +403,8 @@ func activateServer(api *Api) gin.HandlerFunc {
 			},
 			"Server activated")
 
+		api.reloadServer(uint(id))
+
 		c.Set("data", gin.H{"message": "Server activated"})
 		c.Status(http.StatusOK)
 	}
@@ -383,7 +447,55 @@ func deactivateServer(api *Api) gin.HandlerFunc {
 			},
 			"Server deactivated")
 
+		api.servers.Stop(uint(id))
+
 		c.Set("data", gin.H{"message": "Server deactivated"})
 		c.Status(http.StatusOK)
 	}
 }
+
+func rotateServerLogToken(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid server ID")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		server, err := models.GetServerByID(uint(id))
+		if err != nil {
+			c.Set("error", "Server not found")
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		token, err := server.RotateLogToken()
+		if err != nil {
+			c.Set("error", "Failed to generate log token")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		Audit.LogAction(c, models.ActionCommandUpdate, models.SourceWebUI,
+			true, "", "server", "", server.Name,
+			map[string]interface{}{
+				"action": "rotate_log_token",
+			},
+			"Log ingest token rotated")
+
+		// The token is only ever shown here
+		c.Set("data", gin.H{"token": token})
+		c.Status(http.StatusOK)
+	}
+}
+
+// reloadServer restarts a server's runtime in the background so that an
+// unreachable server doesn't hold up the request
+func (api *Api) reloadServer(serverID uint) {
+	go func() {
+		if err := api.servers.Reload(serverID); err != nil {
+			logger.Error("Failed to reload server", zap.Uint("server_id", serverID), zap.Error(err))
+		}
+	}()
+}
@@ -0,0 +1,198 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/models"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterStatsRoutes(r *gin.Engine, api *Api) {
+	stats := r.Group("/stats")
+	stats.Use(AuthMiddleware())
+	stats.Use(RequirePermission("players.view"))
+	{
+		stats.GET("/leaderboard", getLeaderboard(api))
+		stats.GET("/players/:guid", getPlayerProfile(api))
+		stats.GET("/maps", getMapStats(api))
+		stats.GET("/weapons", getWeaponStats(api))
+	}
+}
+
+// parseStatsFilter reads the optional server_id, start and end query
+// parameters. start and end take RFC3339 timestamps or YYYY-MM-DD dates.
+// On failure the error response is already set and ok is false.
+func parseStatsFilter(c *gin.Context) (filter models.StatsFilter, ok bool) {
+	if serverIDStr := c.Query("server_id"); serverIDStr != "" {
+		id, err := strconv.ParseUint(serverIDStr, 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid server ID")
+			c.Status(http.StatusBadRequest)
+			return filter, false
+		}
+		sid := uint(id)
+		filter.ServerID = &sid
+	}
+
+	for _, param := range []struct {
+		name   string
+		target **time.Time
+		endOf  bool
+	}{
+		{"start", &filter.Start, false},
+		{"end", &filter.End, true},
+	} {
+		value := c.Query(param.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			t, err = time.ParseInLocation("2006-01-02", value, time.Local)
+			if err != nil {
+				c.Set("error", "Invalid "+param.name+" time, expected RFC3339 or YYYY-MM-DD")
+				c.Status(http.StatusBadRequest)
+				return filter, false
+			}
+			if param.endOf {
+				// A bare end date includes the whole day
+				t = t.Add(24*time.Hour - time.Nanosecond)
+			}
+		}
+		t = models.ColumnTime(t)
+		*param.target = &t
+	}
+
+	return filter, true
+}
+
+func getLeaderboard(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, ok := parseStatsFilter(c)
+		if !ok {
+			return
+		}
+
+		sort := c.DefaultQuery("sort", models.LeaderboardKills)
+		if !models.ValidLeaderboardSort(sort) {
+			c.Set("error", "Invalid sort, expected kills, kd, headshots, streak, playtime or deaths")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "25"))
+		if err != nil || limit < 1 || limit > 100 {
+			c.Set("error", "Limit must be between 1 and 100")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		// K/D is meaningless for players with a handful of kills
+		defaultMinKills := "0"
+		if sort == models.LeaderboardKD {
+			defaultMinKills = "10"
+		}
+		minKills, err := strconv.Atoi(c.DefaultQuery("min_kills", defaultMinKills))
+		if err != nil || minKills < 0 {
+			c.Set("error", "Invalid min_kills")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		leaderboard, err := models.GetLeaderboard(filter, sort, minKills, limit)
+		if err != nil {
+			c.Set("error", "Failed to retrieve leaderboard")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", leaderboard)
+		c.Status(http.StatusOK)
+	}
+}
+
+func getPlayerProfile(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, ok := parseStatsFilter(c)
+		if !ok {
+			return
+		}
+		guid := c.Param("guid")
+
+		summary, err := models.GetPlayerStatSummary(guid, filter)
+		if err != nil {
+			c.Set("error", "Failed to retrieve player stats")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		rank, err := models.GetPlayerRank(guid, filter)
+		if err != nil {
+			c.Set("error", "Failed to retrieve player rank")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		maps, err := models.GetMapBreakdown(guid, filter)
+		if err != nil {
+			c.Set("error", "Failed to retrieve map stats")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		weapons, err := models.GetWeaponBreakdown(guid, filter)
+		if err != nil {
+			c.Set("error", "Failed to retrieve weapon stats")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{
+			"summary": summary,
+			"rank":    rank,
+			"maps":    maps,
+			"weapons": weapons,
+		})
+		c.Status(http.StatusOK)
+	}
+}
+
+func getMapStats(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, ok := parseStatsFilter(c)
+		if !ok {
+			return
+		}
+
+		maps, err := models.GetMapBreakdown("", filter)
+		if err != nil {
+			c.Set("error", "Failed to retrieve map stats")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", maps)
+		c.Status(http.StatusOK)
+	}
+}
+
+func getWeaponStats(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, ok := parseStatsFilter(c)
+		if !ok {
+			return
+		}
+
+		weapons, err := models.GetWeaponBreakdown("", filter)
+		if err != nil {
+			c.Set("error", "Failed to retrieve weapon stats")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", weapons)
+		c.Status(http.StatusOK)
+	}
+}
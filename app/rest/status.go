@@ -12,7 +12,12 @@ func RegisterStatusRoute(r *gin.Engine, api *Api) {
 		status.GET("", func(c *gin.Context) {
 			// Fetch from RCON directly without caching
 			// The RCON status call is lightweight and provides real-time data
-			status, err := api.rcon.Status()
+			client, _, ok := api.serverClient(c)
+			if !ok {
+				return
+			}
+
+			status, err := client.Status()
 			if err != nil {
 				c.Set("error", err.Error())
 				c.Status(500)
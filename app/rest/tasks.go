@@ -0,0 +1,341 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/jobs"
+	"github.com/ethanburkett/goadmin/app/models"
+	"github.com/gin-gonic/gin"
+)
+
+type ScheduledTaskRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Cron     string `json:"cron" binding:"required"`
+	ServerID *uint  `json:"serverId"`                  // Omit to run on every server
+	Action   string `json:"action" binding:"required"` // rcon, say, command or map
+	Payload  string `json:"payload" binding:"required"`
+	Enabled  *bool  `json:"enabled"`
+}
+
+type CronPreviewRequest struct {
+	Cron string `json:"cron" binding:"required"`
+}
+
+func RegisterTaskRoutes(r *gin.Engine, api *Api) {
+	tasks := r.Group("/tasks")
+	tasks.Use(AuthMiddleware())
+	{
+		tasks.GET("", RequirePermission("tasks.view"), getScheduledTasks(api))
+		tasks.GET("/:id", RequirePermission("tasks.view"), getScheduledTask(api))
+		tasks.GET("/:id/runs", RequirePermission("tasks.view"), getTaskRuns(api))
+		tasks.POST("/preview", RequirePermission("tasks.view"), previewCron(api))
+
+		tasks.POST("", RequirePermission("tasks.manage"), saveScheduledTask(api))
+		tasks.PUT("/:id", RequirePermission("tasks.manage"), saveScheduledTask(api))
+		tasks.DELETE("/:id", RequirePermission("tasks.manage"), deleteScheduledTask(api))
+		tasks.POST("/:id/run", RequirePermission("tasks.manage"), runScheduledTask(api))
+	}
+}
+
+func getScheduledTasks(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tasks, err := models.GetScheduledTasks()
+		if err != nil {
+			c.Set("error", "Failed to retrieve scheduled tasks")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{"tasks": tasks})
+		c.Status(http.StatusOK)
+	}
+}
+
+func getScheduledTask(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid task ID")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		task, err := models.GetScheduledTask(uint(id))
+		if err != nil {
+			c.Set("error", "Task not found")
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		runs, err := models.GetTaskRuns(task.ID, false, 10)
+		if err != nil {
+			c.Set("error", "Failed to retrieve task runs")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{
+			"task": task,
+			"runs": runs,
+		})
+		c.Status(http.StatusOK)
+	}
+}
+
+// getTaskRuns lists a task's run history, ?failed=true for failures only
+func getTaskRuns(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid task ID")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit <= 0 || limit > 100 {
+			limit = 50
+		}
+
+		runs, err := models.GetTaskRuns(uint(id), c.Query("failed") == "true", limit)
+		if err != nil {
+			c.Set("error", "Failed to retrieve task runs")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{"runs": runs})
+		c.Status(http.StatusOK)
+	}
+}
+
+// previewCron checks a cron expression and returns its next five runs
+func previewCron(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req CronPreviewRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		schedule, err := jobs.ParseCron(req.Cron)
+		if err != nil {
+			c.Set("error", fmt.Sprintf("Invalid cron expression: %v", err))
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		runs := []time.Time{}
+		next := time.Now()
+		for i := 0; i < 5; i++ {
+			if next = schedule.Next(next); next.IsZero() {
+				break
+			}
+			runs = append(runs, next)
+		}
+
+		c.Set("data", gin.H{"runs": runs})
+		c.Status(http.StatusOK)
+	}
+}
+
+// saveScheduledTask creates a task, or replaces one when called with an ID
+func saveScheduledTask(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ScheduledTaskRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		userVal, exists := c.Get("user")
+		if !exists {
+			c.Set("error", "User not found")
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+		user := userVal.(*models.User)
+
+		task := &models.ScheduledTask{Enabled: true, CreatedByName: user.Username}
+		status := http.StatusCreated
+		if c.Param("id") != "" {
+			id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+			if err != nil {
+				c.Set("error", "Invalid task ID")
+				c.Status(http.StatusBadRequest)
+				return
+			}
+			task, err = models.GetScheduledTask(uint(id))
+			if err != nil {
+				c.Set("error", "Task not found")
+				c.Status(http.StatusNotFound)
+				return
+			}
+			status = http.StatusOK
+		}
+
+		task.Name = req.Name
+		task.Cron = req.Cron
+		task.ServerID = req.ServerID
+		task.Action = req.Action
+		task.Payload = req.Payload
+		if req.Enabled != nil {
+			task.Enabled = *req.Enabled
+		}
+
+		if task.ServerID != nil {
+			if _, err := models.GetServerByID(*task.ServerID); err != nil {
+				c.Set("error", "Server not found")
+				c.Status(http.StatusBadRequest)
+				return
+			}
+		}
+
+		// Raw commands get the same checks as the RCON console
+		if task.Action == models.TaskActionRcon {
+			sanitized, err := ValidateRconCommand(task.Payload)
+			if err != nil {
+				if CommandValidatorInstance.IsRestrictedCommand(task.Payload) {
+					Audit.LogSecurityViolation(c, "restricted_command_attempt", task.Payload, err.Error())
+				}
+				c.Set("error", fmt.Sprintf("Invalid command: %v", err))
+				c.Status(http.StatusBadRequest)
+				return
+			}
+			task.Payload = sanitized
+		}
+
+		if err := task.Validate(); err != nil {
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		next, err := jobs.NextRun(task.Cron, time.Now())
+		if err != nil {
+			c.Set("error", fmt.Sprintf("Invalid cron expression: %v", err))
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		if next == nil {
+			c.Set("error", "Cron expression never matches")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		task.NextRunAt = nil
+		if task.Enabled {
+			task.NextRunAt = next
+		}
+
+		err = models.SaveScheduledTask(task)
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		Audit.LogAction(c, models.ActionTaskConfig, models.SourceWebUI, err == nil, errMsg,
+			"scheduled_task", fmt.Sprintf("%d", task.ID), task.Name,
+			map[string]interface{}{
+				"cron":      task.Cron,
+				"server_id": task.ServerID,
+				"action":    task.Action,
+				"payload":   task.Payload,
+				"enabled":   task.Enabled,
+			}, fmt.Sprintf("Saved scheduled task '%s' (%s: %s)", task.Name, task.Cron, task.Action))
+
+		if err != nil {
+			c.Set("error", "Failed to save task")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{"task": task})
+		c.Status(status)
+	}
+}
+
+func deleteScheduledTask(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid task ID")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		task, err := models.GetScheduledTask(uint(id))
+		if err != nil {
+			c.Set("error", "Task not found")
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		err = models.DeleteScheduledTask(task.ID)
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		Audit.LogAction(c, models.ActionTaskConfig, models.SourceWebUI, err == nil, errMsg,
+			"scheduled_task", fmt.Sprintf("%d", task.ID), task.Name, nil,
+			fmt.Sprintf("Deleted scheduled task '%s'", task.Name))
+
+		if err != nil {
+			c.Set("error", "Failed to delete task")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{"message": "Task deleted"})
+		c.Status(http.StatusOK)
+	}
+}
+
+// runScheduledTask runs a task immediately, whether or not it's enabled.
+// Its schedule is unchanged.
+func runScheduledTask(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid task ID")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		task, err := models.GetScheduledTask(uint(id))
+		if err != nil {
+			c.Set("error", "Task not found")
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		runs, err := jobs.RunTask(api.servers, task, true)
+		if err != nil {
+			c.Set("error", "Failed to record task run")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		success := task.LastStatus == models.TaskStatusSuccess
+		var errMsg string
+		if !success {
+			errMsg = task.LastResult
+		}
+		Audit.LogAction(c, models.ActionTaskRun, models.SourceWebUI, success, errMsg,
+			"scheduled_task", fmt.Sprintf("%d", task.ID), task.Name,
+			map[string]interface{}{
+				"action":  task.Action,
+				"payload": task.Payload,
+				"servers": len(runs),
+			}, fmt.Sprintf("Ran scheduled task '%s' manually: %s", task.Name, task.LastResult))
+
+		c.Set("data", gin.H{
+			"task": task,
+			"runs": runs,
+		})
+		c.Status(http.StatusOK)
+	}
+}
@@ -0,0 +1,382 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/ethanburkett/goadmin/app/models"
+	"github.com/gin-gonic/gin"
+)
+
+type WarningRuleRequest struct {
+	Threshold       int    `json:"threshold" binding:"required"`
+	WindowHours     int    `json:"windowHours" binding:"required"`
+	Action          string `json:"action" binding:"required"` // kick, tempban or ban
+	DurationMinutes int    `json:"durationMinutes"`
+	Message         string `json:"message"`
+	Enabled         *bool  `json:"enabled"`
+}
+
+type WarningPresetRequest struct {
+	Key         string `json:"key" binding:"required"`
+	Reason      string `json:"reason" binding:"required"`
+	Message     string `json:"message"`
+	ExpireHours int    `json:"expireHours"`
+}
+
+func RegisterWarningRoutes(r *gin.Engine, api *Api) {
+	warnings := r.Group("/warnings")
+	warnings.Use(AuthMiddleware())
+	{
+		warnings.GET("/players/:guid", RequirePermission("players.view"), getPlayerWarnings(api))
+		warnings.DELETE("/players/:guid", RequirePermission("warnings.manage"), clearPlayerWarnings(api))
+		warnings.DELETE("/:id", RequirePermission("warnings.manage"), clearWarning(api))
+
+		warnings.GET("/rules", RequirePermission("players.view"), getWarningRules(api))
+		warnings.POST("/rules", RequirePermission("warnings.manage"), saveWarningRule(api))
+		warnings.PUT("/rules/:id", RequirePermission("warnings.manage"), saveWarningRule(api))
+		warnings.DELETE("/rules/:id", RequirePermission("warnings.manage"), deleteWarningRule(api))
+
+		warnings.GET("/presets", RequirePermission("players.view"), getWarningPresets(api))
+		warnings.POST("/presets", RequirePermission("warnings.manage"), saveWarningPreset(api))
+		warnings.PUT("/presets/:id", RequirePermission("warnings.manage"), saveWarningPreset(api))
+		warnings.DELETE("/presets/:id", RequirePermission("warnings.manage"), deleteWarningPreset(api))
+	}
+}
+
+func getPlayerWarnings(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		guid := c.Param("guid")
+		activeOnly := c.Query("active") == "true"
+
+		warnings, err := models.GetPlayerWarnings(guid, activeOnly)
+		if err != nil {
+			c.Set("error", "Failed to retrieve warnings")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		active, err := models.CountActiveWarnings(guid, 0)
+		if err != nil {
+			c.Set("error", "Failed to count warnings")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{
+			"warnings": warnings,
+			"active":   active,
+		})
+		c.Status(http.StatusOK)
+	}
+}
+
+func clearWarning(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid warning ID")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		userVal, exists := c.Get("user")
+		if !exists {
+			c.Set("error", "User not found")
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+		user := userVal.(*models.User)
+
+		warning, err := models.GetWarningByID(uint(id))
+		if err != nil {
+			c.Set("error", "Warning not found")
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		err = models.ClearWarning(warning.ID, user.Username)
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		Audit.LogAction(c, models.ActionWarningClear, models.SourceWebUI, err == nil, errMsg,
+			"player", warning.PlayerGUID, warning.PlayerName,
+			map[string]interface{}{
+				"warning_id": warning.ID,
+				"reason":     warning.Reason,
+			}, fmt.Sprintf("Cleared warning #%d", warning.ID))
+
+		if errors.Is(err, models.ErrWarningNotFound) {
+			c.Set("error", "Warning is already cleared")
+			c.Status(http.StatusConflict)
+			return
+		}
+		if err != nil {
+			c.Set("error", "Failed to clear warning")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{"message": "Warning cleared"})
+		c.Status(http.StatusOK)
+	}
+}
+
+func clearPlayerWarnings(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		guid := c.Param("guid")
+
+		userVal, exists := c.Get("user")
+		if !exists {
+			c.Set("error", "User not found")
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+		user := userVal.(*models.User)
+
+		cleared, err := models.ClearPlayerWarnings(guid, user.Username)
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		Audit.LogAction(c, models.ActionWarningClear, models.SourceWebUI, err == nil, errMsg,
+			"player", guid, "",
+			map[string]interface{}{"cleared": cleared},
+			fmt.Sprintf("Cleared %d active warning(s)", cleared))
+
+		if err != nil {
+			c.Set("error", "Failed to clear warnings")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{"cleared": cleared})
+		c.Status(http.StatusOK)
+	}
+}
+
+func getWarningRules(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rules, err := models.GetWarningRules()
+		if err != nil {
+			c.Set("error", "Failed to retrieve escalation rules")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{"rules": rules})
+		c.Status(http.StatusOK)
+	}
+}
+
+// saveWarningRule creates a rule, or replaces one when called with an ID
+func saveWarningRule(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req WarningRuleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		rule := &models.WarningRule{Enabled: true}
+		status := http.StatusCreated
+		if c.Param("id") != "" {
+			id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+			if err != nil {
+				c.Set("error", "Invalid rule ID")
+				c.Status(http.StatusBadRequest)
+				return
+			}
+			rule, err = models.GetWarningRule(uint(id))
+			if err != nil {
+				c.Set("error", "Rule not found")
+				c.Status(http.StatusNotFound)
+				return
+			}
+			status = http.StatusOK
+		}
+
+		rule.Threshold = req.Threshold
+		rule.WindowHours = req.WindowHours
+		rule.Action = req.Action
+		rule.DurationMinutes = req.DurationMinutes
+		rule.Message = req.Message
+		if req.Enabled != nil {
+			rule.Enabled = *req.Enabled
+		}
+
+		if err := rule.Validate(); err != nil {
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		err := models.SaveWarningRule(rule)
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		Audit.LogAction(c, models.ActionWarningConfig, models.SourceWebUI, err == nil, errMsg,
+			"warning_rule", fmt.Sprintf("%d", rule.ID), "",
+			map[string]interface{}{
+				"threshold":        rule.Threshold,
+				"window_hours":     rule.WindowHours,
+				"action":           rule.Action,
+				"duration_minutes": rule.DurationMinutes,
+				"enabled":          rule.Enabled,
+			}, fmt.Sprintf("Saved escalation rule: %d warnings in %dh -> %s", rule.Threshold, rule.WindowHours, rule.Describe()))
+
+		if err != nil {
+			c.Set("error", "Failed to save rule")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{"rule": rule})
+		c.Status(status)
+	}
+}
+
+func deleteWarningRule(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid rule ID")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		err = models.DeleteWarningRule(uint(id))
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		Audit.LogAction(c, models.ActionWarningConfig, models.SourceWebUI, err == nil, errMsg,
+			"warning_rule", fmt.Sprintf("%d", id), "", nil,
+			fmt.Sprintf("Deleted escalation rule #%d", id))
+
+		if err != nil {
+			c.Set("error", "Failed to delete rule")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{"message": "Rule deleted"})
+		c.Status(http.StatusOK)
+	}
+}
+
+func getWarningPresets(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		presets, err := models.GetWarningPresets()
+		if err != nil {
+			c.Set("error", "Failed to retrieve warning presets")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{"presets": presets})
+		c.Status(http.StatusOK)
+	}
+}
+
+// saveWarningPreset creates a preset, or replaces one when called with an ID
+func saveWarningPreset(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req WarningPresetRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		if req.ExpireHours < 0 {
+			c.Set("error", "Expiry cannot be negative")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		preset := &models.WarningPreset{}
+		status := http.StatusCreated
+		if c.Param("id") != "" {
+			id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+			if err != nil {
+				c.Set("error", "Invalid preset ID")
+				c.Status(http.StatusBadRequest)
+				return
+			}
+			preset, err = models.GetWarningPreset(uint(id))
+			if err != nil {
+				c.Set("error", "Preset not found")
+				c.Status(http.StatusNotFound)
+				return
+			}
+			status = http.StatusOK
+		}
+
+		preset.Key = req.Key
+		preset.Reason = req.Reason
+		preset.Message = req.Message
+		preset.ExpireHours = req.ExpireHours
+
+		err := models.SaveWarningPreset(preset)
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		Audit.LogAction(c, models.ActionWarningConfig, models.SourceWebUI, err == nil, errMsg,
+			"warning_preset", fmt.Sprintf("%d", preset.ID), preset.Key,
+			map[string]interface{}{
+				"reason":       preset.Reason,
+				"message":      preset.Message,
+				"expire_hours": preset.ExpireHours,
+			}, fmt.Sprintf("Saved warning preset '%s'", preset.Key))
+
+		if err != nil {
+			c.Set("error", err.Error())
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		c.Set("data", gin.H{"preset": preset})
+		c.Status(status)
+	}
+}
+
+func deleteWarningPreset(api *Api) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Set("error", "Invalid preset ID")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		preset, err := models.GetWarningPreset(uint(id))
+		if err != nil {
+			c.Set("error", "Preset not found")
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		err = models.DeleteWarningPreset(preset.ID)
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		Audit.LogAction(c, models.ActionWarningConfig, models.SourceWebUI, err == nil, errMsg,
+			"warning_preset", fmt.Sprintf("%d", preset.ID), preset.Key, nil,
+			fmt.Sprintf("Deleted warning preset '%s'", preset.Key))
+
+		if err != nil {
+			c.Set("error", "Failed to delete preset")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set("data", gin.H{"message": "Preset deleted"})
+		c.Status(http.StatusOK)
+	}
+}
@@ -0,0 +1,109 @@
+package stats
+
+import (
+	"sync"
+
+	"github.com/ethanburkett/goadmin/app/events"
+	"github.com/ethanburkett/goadmin/app/logger"
+	"github.com/ethanburkett/goadmin/app/models"
+	"go.uber.org/zap"
+)
+
+// Tracker turns kill events into persistent player statistics. It keeps the
+// current map and kill streaks per server, which the log doesn't carry.
+type Tracker struct {
+	mu      sync.Mutex
+	servers map[uint]*serverState
+}
+
+type serverState struct {
+	mapName  string
+	gameType string
+	streaks  map[string]int // GUID -> kills since last death
+}
+
+// NewTracker creates a tracker with no state
+func NewTracker() *Tracker {
+	return &Tracker{servers: make(map[uint]*serverState)}
+}
+
+// HandleEvent is an events.Handler
+func (t *Tracker) HandleEvent(event events.Event) {
+	if event.ServerID == nil {
+		return
+	}
+
+	switch event.Type {
+	case events.MapStart:
+		if payload, ok := event.Data.(events.MapPayload); ok {
+			t.startMap(*event.ServerID, payload)
+		}
+
+	case events.MapEnd:
+		t.startMap(*event.ServerID, events.MapPayload{})
+
+	case events.PlayerKill:
+		if payload, ok := event.Data.(events.HitPayload); ok {
+			t.recordKill(event, payload)
+		}
+	}
+}
+
+// startMap resets streaks; they don't carry over between maps
+func (t *Tracker) startMap(serverID uint, payload events.MapPayload) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := t.state(serverID)
+	state.mapName = payload.Map
+	state.gameType = payload.GameType
+	state.streaks = make(map[string]int)
+}
+
+func (t *Tracker) recordKill(event events.Event, hit events.HitPayload) {
+	t.mu.Lock()
+	state := t.state(*event.ServerID)
+	mapName, gameType := state.mapName, state.gameType
+
+	delete(state.streaks, hit.Victim.GUID)
+	streak := 0
+	if !hit.Suicide && !hit.TeamKill && !hit.Attacker.IsWorld() {
+		state.streaks[hit.Attacker.GUID]++
+		streak = state.streaks[hit.Attacker.GUID]
+	}
+	t.mu.Unlock()
+
+	kill := &models.Kill{
+		ServerID:     event.ServerID,
+		MapName:      mapName,
+		GameType:     gameType,
+		Weapon:       hit.Weapon,
+		MeansOfDeath: hit.MeansOfDeath,
+		HitLocation:  hit.HitLocation,
+		VictimGUID:   hit.Victim.GUID,
+		VictimName:   hit.Victim.Name,
+		VictimTeam:   hit.Victim.Team,
+		Headshot:     hit.Headshot,
+		Suicide:      hit.Suicide,
+		TeamKill:     hit.TeamKill,
+		Timestamp:    event.Timestamp,
+	}
+	if !hit.Attacker.IsWorld() {
+		kill.AttackerGUID = hit.Attacker.GUID
+		kill.AttackerName = hit.Attacker.Name
+		kill.AttackerTeam = hit.Attacker.Team
+	}
+
+	if err := models.RecordKill(kill, streak); err != nil {
+		logger.Error("Failed to record kill", zap.Uint("server", *event.ServerID), zap.Error(err))
+	}
+}
+
+func (t *Tracker) state(serverID uint) *serverState {
+	state, ok := t.servers[serverID]
+	if !ok {
+		state = &serverState{streaks: make(map[string]int)}
+		t.servers[serverID] = state
+	}
+	return state
+}
@@ -0,0 +1,131 @@
+package supervisor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/events"
+	"github.com/ethanburkett/goadmin/app/logger"
+	"github.com/ethanburkett/goadmin/app/models"
+	"github.com/ethanburkett/goadmin/app/parser"
+	"github.com/ethanburkett/goadmin/app/rcon"
+	"go.uber.org/zap"
+)
+
+// enforceBanOnJoin kicks a joining player covered by the ban list, or warns
+// about one a ban feed peer flagged. Join lines carry no IP, so status is
+// only fetched when address bans exist and nothing else matched.
+func (inst *Instance) enforceBanOnJoin(entry *parser.LogEntry) {
+	matcher, err := models.CachedBanMatcher()
+	if err != nil {
+		logger.Error("Failed to load ban list", zap.Error(err))
+		return
+	}
+
+	if !matcher.Empty() {
+		ban := matcher.Match(entry.PlayerGUID, "", entry.PlayerName)
+		if ban == nil && matcher.HasAddressBans() {
+			if status, err := inst.RCON.Status(); err == nil {
+				for _, player := range status.Players {
+					if strconv.Itoa(player.ID) == entry.PlayerID {
+						ban = matcher.Match(entry.PlayerGUID, player.Address, entry.PlayerName)
+						break
+					}
+				}
+			}
+		}
+
+		if ban != nil {
+			inst.kickBanned(entry.PlayerID, entry.PlayerName, entry.PlayerGUID, ban)
+			return
+		}
+	}
+
+	inst.warnFlagged(entry)
+}
+
+// warnFlagged announces a joining player that trusted peers have banned
+// but whose bans we only watch
+func (inst *Instance) warnFlagged(entry *parser.LogEntry) {
+	if entry.PlayerGUID == "" {
+		return
+	}
+	flags, err := models.GetBanFlags(strings.ToLower(entry.PlayerGUID), true)
+	if err != nil {
+		logger.Error("Failed to load ban flags", zap.Error(err))
+		return
+	}
+	if len(flags) == 0 {
+		return
+	}
+
+	// Newest flag first
+	flag := flags[0]
+	logger.Warn(fmt.Sprintf("Flagged player %s (%s) joined %s, banned on %s for %s",
+		entry.PlayerName, entry.PlayerGUID, inst.Server.Name, flag.IssuingInstance, flag.Category),
+		zap.Int("flags", len(flags)))
+
+	events.Publish(events.PlayerFlagged, inst.ServerID(), events.FlagPayload{
+		PlayerName:      entry.PlayerName,
+		PlayerGUID:      entry.PlayerGUID,
+		PlayerID:        entry.PlayerID,
+		Category:        flag.Category,
+		IssuingInstance: flag.IssuingInstance,
+		BannedAt:        flag.BannedAt.Format(time.RFC3339),
+		Flags:           len(flags),
+	})
+}
+
+// SweepBans kicks every player on the server covered by the ban list
+func (inst *Instance) SweepBans(matcher *models.BanMatcher) error {
+	status, err := inst.RCON.StatusWithPriority(rcon.PriorityStats)
+	if err != nil {
+		return err
+	}
+
+	for _, player := range status.Players {
+		if player.IsBot {
+			continue
+		}
+		if ban := matcher.Match(player.Uuid, player.Address, player.StrippedName); ban != nil {
+			inst.kickBanned(strconv.Itoa(player.ID), player.StrippedName, player.Uuid, ban)
+		}
+	}
+	return nil
+}
+
+// SweepBans checks the players on every running server against the ban list
+func (s *Supervisor) SweepBans() {
+	matcher, err := models.CachedBanMatcher()
+	if err != nil {
+		logger.Error("Failed to load ban list", zap.Error(err))
+		return
+	}
+	if matcher.Empty() {
+		return
+	}
+
+	for _, inst := range s.Instances() {
+		if err := inst.SweepBans(matcher); err != nil {
+			logger.Debug("Ban sweep skipped server", zap.String("server", inst.Server.Name), zap.Error(err))
+		}
+	}
+}
+
+func (inst *Instance) kickBanned(slot, name, guid string, ban *models.Ban) {
+	if _, err := inst.RCON.Kick(slot, ban.KickMessage()); err != nil {
+		logger.Error("Failed to kick banned player", zap.String("guid", guid), zap.Uint("ban_id", ban.ID), zap.Error(err))
+		return
+	}
+	logger.Info(fmt.Sprintf("Kicked banned player %s (%s) from %s, ban #%d", name, guid, inst.Server.Name, ban.ID))
+
+	events.Publish(events.PlayerKicked, inst.ServerID(), events.KickPayload{
+		PlayerName: name,
+		PlayerID:   slot,
+		KickedBy:   "GoAdmin",
+		Reason:     ban.KickMessage(),
+		Source:     "ban_list",
+	})
+}
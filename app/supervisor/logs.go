@@ -0,0 +1,216 @@
+package supervisor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/chatfilter"
+	"github.com/ethanburkett/goadmin/app/events"
+	"github.com/ethanburkett/goadmin/app/logger"
+	"github.com/ethanburkett/goadmin/app/models"
+	"github.com/ethanburkett/goadmin/app/parser"
+	"github.com/ethanburkett/goadmin/app/watcher"
+	"go.uber.org/zap"
+)
+
+// matchState is what the log processor remembers between lines. It is only
+// touched from the processLogs goroutine.
+type matchState struct {
+	mapName  string
+	gameType string
+	names    map[int]parser.Combatant // last seen identity per slot
+}
+
+// processLogs handles log lines for one server until the watcher stops
+func (inst *Instance) processLogs(changesChan <-chan watcher.FileChangeEvent) {
+	defer close(inst.done)
+
+	state := &matchState{names: make(map[int]parser.Combatant)}
+
+	for event := range changesChan {
+		entry, ok := inst.Adapter.ParseLogLine(event.NewLine)
+		if !ok {
+			continue
+		}
+
+		inst.publishLogEvent(entry, state)
+		inst.handleLogEntry(entry)
+	}
+}
+
+// publishLogEvent converts a parsed line into a typed event on the event bus
+func (inst *Instance) publishLogEvent(entry *parser.LogEntry, state *matchState) {
+	serverID := inst.ServerID()
+
+	switch e := entry.Event.(type) {
+	case *parser.ChatEvent:
+		inst.trackName(e.Player, state)
+		eventType := events.PlayerChat
+		if e.Team {
+			eventType = events.PlayerTeamChat
+		}
+		events.Publish(eventType, serverID, events.ChatPayload{
+			PlayerPayload: events.NewPlayerPayload(e.Player),
+			Message:       e.Message,
+		})
+
+	case *parser.JoinEvent:
+		state.names[e.Player.Slot] = e.Player
+		events.Publish(events.PlayerConnect, serverID, events.NewPlayerPayload(e.Player))
+
+	case *parser.QuitEvent:
+		delete(state.names, e.Player.Slot)
+		events.Publish(events.PlayerDisconnect, serverID, events.NewPlayerPayload(e.Player))
+
+	case *parser.KillEvent:
+		inst.trackName(e.Victim, state)
+		inst.trackName(e.Attacker, state)
+		events.Publish(events.PlayerKill, serverID, events.NewKillPayload(e))
+
+	case *parser.DamageEvent:
+		inst.trackName(e.Victim, state)
+		inst.trackName(e.Attacker, state)
+		events.Publish(events.PlayerDamage, serverID, events.NewHitPayload(e))
+
+	case *parser.WeaponEvent:
+		inst.trackName(e.Player, state)
+		events.Publish(events.PlayerWeapon, serverID, events.WeaponPayload{
+			PlayerPayload: events.NewPlayerPayload(e.Player),
+			Weapon:        e.Weapon,
+		})
+
+	case *parser.ActionEvent:
+		inst.trackName(e.Player, state)
+		events.Publish(events.PlayerAction, serverID, events.ActionPayload{
+			PlayerPayload: events.NewPlayerPayload(e.Player),
+			Team:          e.Player.Team,
+			Action:        e.Action,
+		})
+
+	case *parser.TeamResultEvent:
+		events.Publish(events.RoundEnd, serverID, events.RoundPayload{
+			Team:    e.Team,
+			Win:     e.Win,
+			Players: e.Players,
+		})
+
+	case *parser.InitGameEvent:
+		state.mapName = e.Map()
+		state.gameType = e.GameType()
+		inst.Handler.ResetMapVoting()
+		events.Publish(events.MapStart, serverID, events.MapPayload{
+			Map:      state.mapName,
+			GameType: state.gameType,
+			Settings: e.Settings,
+		})
+
+	case *parser.ExitLevelEvent, *parser.ShutdownGameEvent:
+		// ExitLevel is followed by ShutdownGame, so only report the first
+		if state.mapName == "" {
+			return
+		}
+		reason := "exit_level"
+		if entry.CommandType == parser.SHUTDOWNGAME {
+			reason = "shutdown"
+		}
+		events.Publish(events.MapEnd, serverID, events.MapPayload{
+			Map:      state.mapName,
+			GameType: state.gameType,
+			Reason:   reason,
+		})
+		state.mapName = ""
+		state.gameType = ""
+		// Slots are reassigned on the next map
+		state.names = make(map[int]parser.Combatant)
+	}
+}
+
+// trackName publishes player.name_change when a known player in a slot shows
+// up under a different name
+func (inst *Instance) trackName(player parser.Combatant, state *matchState) {
+	if player.IsWorld() || player.GUID == "" {
+		return
+	}
+
+	known, ok := state.names[player.Slot]
+	state.names[player.Slot] = player
+
+	if !ok || known.GUID != player.GUID || known.Name == player.Name {
+		return
+	}
+
+	events.Publish(events.PlayerNameChange, inst.ServerID(), events.NameChangePayload{
+		PlayerPayload: events.NewPlayerPayload(player),
+		OldName:       known.Name,
+	})
+	models.CreateOrUpdateInGamePlayerOnServer(player.GUID, player.Name, inst.ServerID())
+}
+
+func (inst *Instance) handleLogEntry(entry *parser.LogEntry) {
+	serverID := inst.ServerID()
+
+	switch entry.CommandType {
+	case parser.SAY, parser.SAYTEAM:
+		cleanMsg := strings.Map(func(r rune) rune {
+			if r < 32 || r == 127 {
+				return -1
+			}
+			return r
+		}, entry.Message)
+		cleanMsg = strings.TrimSpace(cleanMsg)
+
+		if cleanMsg != "" {
+			if err := models.CreateChatMessage(serverID, entry.PlayerGUID, entry.PlayerName, cleanMsg, entry.CommandType == parser.SAYTEAM); err != nil {
+				logger.Error("Failed to record chat message", zap.Error(err))
+			}
+
+			chatfilter.Default.Handle(inst.RCON, chatfilter.Line{
+				ServerID: serverID,
+				GUID:     entry.PlayerGUID,
+				Name:     entry.PlayerName,
+				Slot:     entry.PlayerID,
+				Message:  cleanMsg,
+			})
+		}
+
+		if len(cleanMsg) > 0 && cleanMsg[0] == '!' {
+			models.CreateOrUpdateInGamePlayerOnServer(entry.PlayerGUID, entry.PlayerName, serverID)
+
+			if err := inst.Handler.ProcessChatCommand(entry.PlayerName, entry.PlayerGUID, cleanMsg); err != nil {
+				logger.Error("Failed to process command", zap.Error(err))
+			}
+		}
+
+	case parser.JOIN:
+		fmt.Printf("[JOIN] %s (GUID: %s, ID: %s) joined %s\n", entry.PlayerName, entry.PlayerGUID, entry.PlayerID, inst.Server.Name)
+		models.CreateOrUpdateInGamePlayerOnServer(entry.PlayerGUID, entry.PlayerName, serverID)
+
+		slot, _ := strconv.Atoi(entry.PlayerID)
+		if _, err := models.OpenPlayerSession(entry.PlayerGUID, entry.PlayerName, slot, serverID, watcher.SessionStaleAfter); err != nil {
+			logger.Error("Failed to open player session", zap.String("guid", entry.PlayerGUID), zap.Error(err))
+		}
+
+		if models.IsPlayerTempBanned(entry.PlayerGUID) {
+			ban, _ := models.GetTempBanByGUID(entry.PlayerGUID)
+			if ban != nil {
+				timeRemaining := time.Until(ban.ExpiresAt)
+				hours := int(timeRemaining.Hours())
+				minutes := int(timeRemaining.Minutes()) % 60
+				kickMsg := fmt.Sprintf("You are temporarily banned. %dh %dm remaining. Reason: %s", hours, minutes, ban.Reason)
+				inst.RCON.Kick(entry.PlayerID, kickMsg)
+				logger.Info(fmt.Sprintf("Kicked temp-banned player %s (%s) from %s", entry.PlayerName, entry.PlayerGUID, inst.Server.Name))
+			}
+		}
+
+		inst.enforceBanOnJoin(entry)
+
+	case parser.LEAVE:
+		fmt.Printf("[LEAVE] %s (GUID: %s, ID: %s) left %s\n", entry.PlayerName, entry.PlayerGUID, entry.PlayerID, inst.Server.Name)
+
+		if err := models.ClosePlayerSession(entry.PlayerGUID, serverID, models.SessionEndQuit); err != nil {
+			logger.Error("Failed to close player session", zap.String("guid", entry.PlayerGUID), zap.Error(err))
+		}
+	}
+}
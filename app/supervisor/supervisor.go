@@ -0,0 +1,281 @@
+package supervisor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ethanburkett/goadmin/app/commands"
+	"github.com/ethanburkett/goadmin/app/config"
+	"github.com/ethanburkett/goadmin/app/database"
+	"github.com/ethanburkett/goadmin/app/games"
+	"github.com/ethanburkett/goadmin/app/logger"
+	"github.com/ethanburkett/goadmin/app/models"
+	"github.com/ethanburkett/goadmin/app/plugins"
+	"github.com/ethanburkett/goadmin/app/rcon"
+	"github.com/ethanburkett/goadmin/app/watcher"
+	"go.uber.org/zap"
+)
+
+// ErrServerNotRunning is returned when a server has no running instance
+var ErrServerNotRunning = errors.New("server is not running")
+
+// Global is the supervisor used by the REST API and plugins
+var Global *Supervisor
+
+// Instance is everything running for one server
+type Instance struct {
+	Server  models.Server
+	Adapter games.Adapter
+	RCON    *rcon.Client
+	Handler *commands.CommandHandler
+	Stats   *watcher.StatsCollector
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// ServerID returns a pointer to the instance's server ID for model calls
+func (inst *Instance) ServerID() *uint {
+	id := inst.Server.ID
+	return &id
+}
+
+// Supervisor starts and stops the per-server runtime for every active server
+type Supervisor struct {
+	cfg       *config.Config
+	mu        sync.RWMutex
+	instances map[uint]*Instance
+	locks     map[uint]*sync.Mutex // Serializes Start and Stop per server
+}
+
+// New creates a supervisor. RCON tuning options are taken from cfg.
+func New(cfg *config.Config) *Supervisor {
+	return &Supervisor{
+		cfg:       cfg,
+		instances: make(map[uint]*Instance),
+		locks:     make(map[uint]*sync.Mutex),
+	}
+}
+
+// lock takes the per-server lock so overlapping reloads of one server can't
+// leave a second instance running. The returned func releases it.
+func (s *Supervisor) lock(serverID uint) func() {
+	s.mu.Lock()
+	l, ok := s.locks[serverID]
+	if !ok {
+		l = &sync.Mutex{}
+		s.locks[serverID] = l
+	}
+	s.mu.Unlock()
+
+	l.Lock()
+	return l.Unlock
+}
+
+// StartAll starts an instance for every active server and announces the
+// panel in game. Servers that fail to start are logged and skipped so one bad
+// server doesn't block the rest.
+func (s *Supervisor) StartAll() error {
+	servers, err := models.GetActiveServers()
+	if err != nil {
+		return fmt.Errorf("failed to load servers: %w", err)
+	}
+
+	for _, server := range servers {
+		unlock := s.lock(server.ID)
+		err := s.start(server, true)
+		unlock()
+		if err != nil {
+			logger.Error("Failed to start server", zap.String("server", server.Name), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// Start starts the runtime for a server, replacing any running instance
+func (s *Supervisor) Start(server models.Server) error {
+	defer s.lock(server.ID)()
+	return s.start(server, false)
+}
+
+// start replaces a server's instance. The caller holds the server's lock.
+func (s *Supervisor) start(server models.Server, announce bool) error {
+	s.stop(server.ID)
+
+	adapter := games.ForServer(&server)
+
+	serverCfg := s.cfg.Server
+	serverCfg.Host = server.Host
+	serverCfg.Port = server.RconPort
+	if serverCfg.Port == 0 {
+		serverCfg.Port = server.Port
+	}
+	serverCfg.RconPassword = server.RconPassword
+	serverCfg.Game = adapter.Name()
+
+	client := rcon.NewClientFromServerConfig(serverCfg)
+	client.Dialect = adapter
+	if err := client.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to %s:%d: %w", serverCfg.Host, serverCfg.Port, err)
+	}
+
+	inst := &Instance{
+		Server:  server,
+		Adapter: adapter,
+		RCON:    client,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	inst.Handler = commands.NewCommandHandler(client, database.DB, inst.ServerID())
+	inst.Handler.SetPluginCommandAPI(plugins.GlobalPluginManager.GetCommandAPI())
+
+	inst.Stats = watcher.NewStatsCollector(client, inst.ServerID())
+	inst.Stats.Start()
+
+	if source, err := s.logSource(server); err != nil {
+		close(inst.done)
+		logger.Info("No log source, in-game commands disabled", zap.String("server", server.Name), zap.Error(err))
+	} else {
+		logger.Info(fmt.Sprintf("Reading logs for %s from %s", server.Name, source))
+		go inst.processLogs(source.Lines(inst.stop))
+	}
+
+	s.mu.Lock()
+	s.instances[server.ID] = inst
+	s.mu.Unlock()
+
+	logger.Info(fmt.Sprintf("Started server %s (%s) using %s adapter", server.Name, serverCfg.Host, adapter.DisplayName()))
+
+	if announce {
+		go client.Say("^5GoAdmin ^7now serving.")
+	}
+	return nil
+}
+
+// logSource builds the log source configured for a server
+func (s *Supervisor) logSource(server models.Server) (watcher.LogSource, error) {
+	cfg := watcher.SourceConfig{
+		Type:     server.LogSource,
+		Path:     server.GamesMpPath,
+		Address:  server.LogAddress,
+		FromHost: server.Host,
+		ServerID: server.ID,
+		Tail:     watcher.TailOptionsFromConfig(s.cfg),
+	}
+	if server.LogSource == watcher.SourcePoll && server.LogAddress != "" {
+		cfg.Path = server.LogAddress
+	}
+	return watcher.NewLogSource(cfg)
+}
+
+// Stop shuts down a server's runtime if it is running
+func (s *Supervisor) Stop(serverID uint) {
+	defer s.lock(serverID)()
+	s.stop(serverID)
+}
+
+// stop shuts down a server's instance. The caller holds the server's lock.
+func (s *Supervisor) stop(serverID uint) {
+	s.mu.Lock()
+	inst, ok := s.instances[serverID]
+	delete(s.instances, serverID)
+	s.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	close(inst.stop)
+	inst.Stats.Stop()
+	inst.Handler.Stop()
+	<-inst.done
+	inst.RCON.Close()
+
+	logger.Info(fmt.Sprintf("Stopped server %s", inst.Server.Name))
+}
+
+// Reload re-reads a server from the database and restarts or stops it
+// depending on whether it is active
+func (s *Supervisor) Reload(serverID uint) error {
+	defer s.lock(serverID)()
+
+	server, err := models.GetServerByID(serverID)
+	if err != nil {
+		s.stop(serverID)
+		return err
+	}
+
+	if !server.IsActive {
+		s.stop(serverID)
+		return nil
+	}
+	return s.start(*server, false)
+}
+
+// StopAll shuts down every running server
+func (s *Supervisor) StopAll() {
+	s.mu.RLock()
+	ids := make([]uint, 0, len(s.instances))
+	for id := range s.instances {
+		ids = append(ids, id)
+	}
+	s.mu.RUnlock()
+
+	for _, id := range ids {
+		s.Stop(id)
+	}
+}
+
+// Get returns the running instance for a server
+func (s *Supervisor) Get(serverID uint) (*Instance, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	inst, ok := s.instances[serverID]
+	return inst, ok
+}
+
+// Resolve returns the instance for serverID, or the default server's
+// instance when serverID is nil
+func (s *Supervisor) Resolve(serverID *uint) (*Instance, error) {
+	if serverID == nil {
+		server, err := models.GetDefaultServer()
+		if err != nil {
+			return nil, fmt.Errorf("no default server configured")
+		}
+		serverID = &server.ID
+	}
+
+	inst, ok := s.Get(*serverID)
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrServerNotRunning, *serverID)
+	}
+	return inst, nil
+}
+
+// Client returns the RCON client for serverID, or the default server's
+// client when serverID is nil
+func (s *Supervisor) Client(serverID *uint) (*rcon.Client, error) {
+	inst, err := s.Resolve(serverID)
+	if err != nil {
+		return nil, err
+	}
+	return inst.RCON, nil
+}
+
+// Instances returns all running instances ordered by server ID
+func (s *Supervisor) Instances() []*Instance {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	instances := make([]*Instance, 0, len(s.instances))
+	for _, inst := range s.instances {
+		instances = append(instances, inst)
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].Server.ID < instances[j].Server.ID
+	})
+	return instances
+}
@@ -0,0 +1,103 @@
+package watcher
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrNoIngestListener is returned when a chunk is pushed for a server that
+// isn't running an HTTP log source
+var ErrNoIngestListener = errors.New("server is not accepting pushed logs")
+
+// Ingest routes log chunks received over HTTP to the running HTTP sources
+var Ingest = NewIngestHub()
+
+// IngestHub hands pushed log chunks to the HTTP source of each server
+type IngestHub struct {
+	mu        sync.RWMutex
+	listeners map[uint]chan []byte
+}
+
+// NewIngestHub creates an empty hub
+func NewIngestHub() *IngestHub {
+	return &IngestHub{listeners: make(map[uint]chan []byte)}
+}
+
+// Push delivers a chunk of log data for a server. Chunks may end mid-line;
+// the remainder is joined with the next chunk.
+func (h *IngestHub) Push(serverID uint, data []byte) error {
+	h.mu.RLock()
+	ch, ok := h.listeners[serverID]
+	h.mu.RUnlock()
+
+	if !ok {
+		return ErrNoIngestListener
+	}
+
+	// Copy so the caller can reuse its buffer
+	chunk := make([]byte, len(data))
+	copy(chunk, data)
+
+	select {
+	case ch <- chunk:
+		return nil
+	default:
+		return fmt.Errorf("log ingest queue for server %d is full", serverID)
+	}
+}
+
+func (h *IngestHub) register(serverID uint) chan []byte {
+	ch := make(chan []byte, 64)
+	h.mu.Lock()
+	h.listeners[serverID] = ch
+	h.mu.Unlock()
+	return ch
+}
+
+func (h *IngestHub) unregister(serverID uint, ch chan []byte) {
+	h.mu.Lock()
+	if h.listeners[serverID] == ch {
+		delete(h.listeners, serverID)
+	}
+	h.mu.Unlock()
+}
+
+// HTTPSource emits lines pushed to the ingest endpoint for one server
+type HTTPSource struct {
+	serverID uint
+	hub      *IngestHub
+}
+
+// NewHTTPSource creates a source fed by the global Ingest hub
+func NewHTTPSource(serverID uint) *HTTPSource {
+	return &HTTPSource{serverID: serverID, hub: Ingest}
+}
+
+func (s *HTTPSource) String() string {
+	return fmt.Sprintf("http ingest (server %d)", s.serverID)
+}
+
+func (s *HTTPSource) Lines(stop <-chan struct{}) <-chan FileChangeEvent {
+	lines := make(chan FileChangeEvent)
+	chunks := s.hub.register(s.serverID)
+
+	go func() {
+		defer close(lines)
+		defer s.hub.unregister(s.serverID, chunks)
+
+		var lb lineBuffer
+		for {
+			select {
+			case chunk := <-chunks:
+				if !send(lines, s.String(), lb.split(chunk), stop) {
+					return
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return lines
+}
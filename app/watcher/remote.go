@@ -0,0 +1,165 @@
+package watcher
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/logger"
+	"go.uber.org/zap"
+)
+
+// DefaultRemotePollInterval is how often a remote log file is checked
+const DefaultRemotePollInterval = 5 * time.Second
+
+// RemoteFile is a log file on another machine that can only be read by
+// offset, e.g. over FTP or SFTP
+type RemoteFile interface {
+	// Size returns the current size of the file
+	Size() (int64, error)
+
+	// ReadFrom returns the contents from offset to the end of the file
+	ReadFrom(offset int64) (io.ReadCloser, error)
+
+	// String describes the file for logging
+	String() string
+}
+
+// LocalRemoteFile implements RemoteFile on a local path. It stands in for
+// remote transports and suits directories synced from a game host.
+type LocalRemoteFile struct {
+	path string
+}
+
+// NewLocalRemoteFile creates a RemoteFile reading a local path
+func NewLocalRemoteFile(path string) *LocalRemoteFile {
+	return &LocalRemoteFile{path: path}
+}
+
+func (f *LocalRemoteFile) Size() (int64, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func (f *LocalRemoteFile) ReadFrom(offset int64) (io.ReadCloser, error) {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+func (f *LocalRemoteFile) String() string {
+	return f.path
+}
+
+// PollSource fetches new data from a RemoteFile on an interval. It starts
+// at the current end of the file and rereads from the top when the file
+// shrinks, which is how rotation looks from the outside.
+type PollSource struct {
+	file     RemoteFile
+	interval time.Duration
+}
+
+// NewPollSource creates a source polling file every interval
+func NewPollSource(file RemoteFile, interval time.Duration) *PollSource {
+	if interval <= 0 {
+		interval = DefaultRemotePollInterval
+	}
+	return &PollSource{file: file, interval: interval}
+}
+
+func (s *PollSource) String() string {
+	return "poll " + s.file.String()
+}
+
+func (s *PollSource) Lines(stop <-chan struct{}) <-chan FileChangeEvent {
+	lines := make(chan FileChangeEvent)
+
+	go func() {
+		defer close(lines)
+
+		offset := int64(-1)
+		failing := false
+		var lb lineBuffer
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			size, err := s.file.Size()
+			switch {
+			case err != nil:
+				if !failing {
+					logger.Error("Failed to check remote log", zap.String("source", s.String()), zap.Error(err))
+					failing = true
+				}
+
+			case offset < 0:
+				// First successful check; only new lines are of interest
+				offset = size
+				failing = false
+
+			case size < offset:
+				logger.Info("Remote log shrank, reading from start", zap.String("source", s.String()))
+				offset = 0
+				lb.reset()
+				fallthrough
+
+			default:
+				failing = false
+				if size > offset {
+					n, ok := s.fetch(offset, &lb, lines, stop)
+					offset += n
+					if !ok {
+						return
+					}
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return lines
+}
+
+// fetch reads from offset to the end of the file, returning how many bytes
+// were consumed and false if stop was closed
+func (s *PollSource) fetch(offset int64, lb *lineBuffer, lines chan<- FileChangeEvent, stop <-chan struct{}) (int64, bool) {
+	r, err := s.file.ReadFrom(offset)
+	if err != nil {
+		logger.Error("Failed to read remote log", zap.String("source", s.String()), zap.Error(err))
+		return 0, true
+	}
+	defer r.Close()
+
+	var read int64
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			read += int64(n)
+			if !send(lines, s.String(), lb.split(buf[:n]), stop) {
+				return read, false
+			}
+		}
+		if err != nil {
+			if err != io.EOF {
+				logger.Error("Failed to read remote log", zap.String("source", s.String()), zap.Error(err))
+			}
+			return read, true
+		}
+	}
+}
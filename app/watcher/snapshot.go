@@ -0,0 +1,40 @@
+package watcher
+
+import (
+	"sync"
+	"time"
+)
+
+// Snapshot is a server's state as of the latest stats collection
+type Snapshot struct {
+	ServerID   *uint     `json:"serverId,omitempty"`
+	Players    int       `json:"players"`
+	MaxPlayers int       `json:"maxPlayers"`
+	Map        string    `json:"map"`
+	GameType   string    `json:"gameType"`
+	At         time.Time `json:"at"`
+}
+
+// SnapshotHandler receives snapshots. Handlers are called on the collector's
+// goroutine and should hand off anything slow.
+type SnapshotHandler func(Snapshot)
+
+var (
+	snapshotMu       sync.RWMutex
+	snapshotHandlers []SnapshotHandler
+)
+
+// OnSnapshot registers a handler for every server's snapshots
+func OnSnapshot(handler SnapshotHandler) {
+	snapshotMu.Lock()
+	snapshotHandlers = append(snapshotHandlers, handler)
+	snapshotMu.Unlock()
+}
+
+func publishSnapshot(snapshot Snapshot) {
+	snapshotMu.RLock()
+	defer snapshotMu.RUnlock()
+	for _, handler := range snapshotHandlers {
+		handler(snapshot)
+	}
+}
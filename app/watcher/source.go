@@ -0,0 +1,139 @@
+package watcher
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// Log source types
+const (
+	SourceFile = "file" // Local games_mp.log, tailed
+	SourceUDP  = "udp"  // Lines streamed to a UDP listener
+	SourceHTTP = "http" // Chunks pushed by a log shipper to the REST API
+	SourcePoll = "poll" // Remote file fetched periodically by offset
+)
+
+// LogSource produces the log lines of one server
+type LogSource interface {
+	// Lines emits lines until stop is closed, then closes the channel
+	Lines(stop <-chan struct{}) <-chan FileChangeEvent
+
+	// String describes the source for logging
+	String() string
+}
+
+// SourceConfig selects and configures a log source
+type SourceConfig struct {
+	Type     string
+	Path     string // file and poll sources
+	Address  string // udp listen address, e.g. ":27500"
+	FromHost string // udp: only accept datagrams from this host, empty accepts any
+	ServerID uint   // http: server the pushed chunks belong to
+
+	PollInterval time.Duration // poll source, DefaultRemotePollInterval if zero
+	Tail         TailOptions   // file source
+}
+
+// NewLogSource creates the source described by cfg. An empty type is a local file.
+func NewLogSource(cfg SourceConfig) (LogSource, error) {
+	switch cfg.Type {
+	case "", SourceFile:
+		if cfg.Path == "" {
+			return nil, fmt.Errorf("file log source requires a path")
+		}
+		return &fileSource{path: cfg.Path, opts: cfg.Tail}, nil
+
+	case SourceUDP:
+		if cfg.Address == "" {
+			return nil, fmt.Errorf("udp log source requires a listen address")
+		}
+		return NewUDPSource(cfg.Address, cfg.FromHost), nil
+
+	case SourceHTTP:
+		if cfg.ServerID == 0 {
+			return nil, fmt.Errorf("http log source requires a server ID")
+		}
+		return NewHTTPSource(cfg.ServerID), nil
+
+	case SourcePoll:
+		if cfg.Path == "" {
+			return nil, fmt.Errorf("poll log source requires a path")
+		}
+		return NewPollSource(NewLocalRemoteFile(cfg.Path), cfg.PollInterval), nil
+
+	default:
+		return nil, fmt.Errorf("unknown log source type: %s", cfg.Type)
+	}
+}
+
+// ValidSourceType reports whether t names a known log source
+func ValidSourceType(t string) bool {
+	switch t {
+	case "", SourceFile, SourceUDP, SourceHTTP, SourcePoll:
+		return true
+	}
+	return false
+}
+
+// fileSource tails a local file
+type fileSource struct {
+	path string
+	opts TailOptions
+}
+
+func (s *fileSource) Lines(stop <-chan struct{}) <-chan FileChangeEvent {
+	return NewTailer(s.path, s.opts).Run(stop)
+}
+
+func (s *fileSource) String() string {
+	return "file " + s.path
+}
+
+// lineBuffer splits a byte stream into lines, holding back an unterminated
+// tail until the rest of it arrives
+type lineBuffer struct {
+	partial []byte
+}
+
+// split returns the complete, non-empty lines in data
+func (b *lineBuffer) split(data []byte) []string {
+	var lines []string
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			b.partial = append(b.partial, data...)
+			if len(b.partial) > maxPartialLine {
+				// Something is writing without newlines; don't grow forever
+				b.partial = b.partial[:0]
+			}
+			break
+		}
+
+		line := append(b.partial, data[:i]...)
+		b.partial = b.partial[:0]
+		data = data[i+1:]
+
+		if text := string(bytes.TrimRight(line, "\r")); text != "" {
+			lines = append(lines, text)
+		}
+	}
+	return lines
+}
+
+// reset drops any buffered partial line
+func (b *lineBuffer) reset() {
+	b.partial = b.partial[:0]
+}
+
+// send emits lines in order, returning false if stop was closed first
+func send(out chan<- FileChangeEvent, source string, lines []string, stop <-chan struct{}) bool {
+	for _, line := range lines {
+		select {
+		case out <- FileChangeEvent{FilePath: source, NewLine: line, ModTime: time.Now()}:
+		case <-stop:
+			return false
+		}
+	}
+	return true
+}
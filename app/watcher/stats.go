@@ -12,17 +12,27 @@ import (
 	"github.com/ethanburkett/goadmin/app/webhook"
 )
 
+// SessionStaleAfter is how long an open session can go unseen before it is
+// treated as left open by a crash or a panel restart. Polls run every minute.
+const SessionStaleAfter = 3 * time.Minute
+
 type StatsCollector struct {
 	rcon       *rcon.Client
+	serverID   *uint
 	ticker     *time.Ticker
 	done       chan bool
 	lastOnline bool
 	dispatcher *webhook.Dispatcher
+
+	// lastPlayerStats is when player stats were last collected; kills and
+	// playtime are counted from there
+	lastPlayerStats time.Time
 }
 
-func NewStatsCollector(rconClient *rcon.Client) *StatsCollector {
+func NewStatsCollector(rconClient *rcon.Client, serverID *uint) *StatsCollector {
 	return &StatsCollector{
 		rcon:       rconClient,
+		serverID:   serverID,
 		done:       make(chan bool),
 		lastOnline: false,
 		dispatcher: webhook.NewDispatcher(),
@@ -58,31 +68,41 @@ func (sc *StatsCollector) collectStats() {
 
 	// Track server online status
 	serverOnline := true
+	offlineReason := ""
 
 	// Collect server stats
 	if err := sc.collectServerStats(); err != nil {
 		logger.Error(fmt.Sprintf("Failed to collect server stats: %v", err))
 		serverOnline = false
+		offlineReason = rcon.ErrorReason(err)
 	}
 
 	// Check for status change and dispatch webhook
 	if serverOnline != sc.lastOnline {
 		if serverOnline {
 			logger.Info("Server came online")
-			sc.dispatcher.Dispatch(models.WebhookEventServerOnline, map[string]interface{}{
+			sc.dispatcher.Dispatch(models.WebhookEventServerOnline, sc.webhookPayload(map[string]interface{}{
 				"timestamp": time.Now().Format(time.RFC3339),
 				"message":   "Server is now online",
-			})
+			}))
 		} else {
 			logger.Info("Server went offline")
-			sc.dispatcher.Dispatch(models.WebhookEventServerOffline, map[string]interface{}{
+			sc.dispatcher.Dispatch(models.WebhookEventServerOffline, sc.webhookPayload(map[string]interface{}{
 				"timestamp": time.Now().Format(time.RFC3339),
 				"message":   "Server is now offline",
-			})
+				"reason":    offlineReason,
+			}))
 		}
 		sc.lastOnline = serverOnline
 	}
 
+	// Nobody can still be playing on a server that doesn't answer
+	if !serverOnline {
+		if err := models.CloseOpenPlayerSessions(sc.serverID, models.SessionEndServerOffline); err != nil {
+			logger.Error(fmt.Sprintf("Failed to close player sessions: %v", err))
+		}
+	}
+
 	// Only collect system and player stats if server is online
 	if serverOnline {
 		// Collect system stats
@@ -97,21 +117,28 @@ func (sc *StatsCollector) collectStats() {
 	}
 }
 
+// webhookPayload adds the server ID to a webhook payload when known
+func (sc *StatsCollector) webhookPayload(data map[string]interface{}) map[string]interface{} {
+	if sc.serverID != nil {
+		data["server_id"] = *sc.serverID
+	}
+	return data
+}
+
 func (sc *StatsCollector) collectServerStats() error {
 	// Get status
-	statusResp, err := sc.rcon.SendCommand("status")
+	status, err := sc.rcon.StatusWithPriority(rcon.PriorityStats)
 	if err != nil {
 		return fmt.Errorf("failed to get status: %w", err)
 	}
 
 	// Get serverinfo
-	serverinfoResp, err := sc.rcon.SendCommand("serverinfo")
+	serverinfoResp, err := sc.rcon.SendCommandWithPriority("serverinfo", rcon.PriorityStats)
 	if err != nil {
 		return fmt.Errorf("failed to get serverinfo: %w", err)
 	}
 
-	// Parse status for player count
-	playerCount := parsePlayerCount(statusResp)
+	playerCount := len(status.Players)
 
 	// Parse serverinfo for settings (format: "key value" on each line)
 	maxPlayers := parseCvarInt(serverinfoResp, "sv_maxclients", 32)
@@ -123,12 +150,24 @@ func (sc *StatsCollector) collectServerStats() error {
 	// Parse uptime from serverinfo (format: "uptime               5 hours")
 	uptime := parseUptime(serverinfoResp)
 
-	return models.CreateServerStats(playerCount, maxPlayers, mapName, gametype, hostname, fps, uptime)
+	if err := models.CreateServerStats(playerCount, maxPlayers, mapName, gametype, hostname, fps, uptime, sc.serverID); err != nil {
+		return err
+	}
+
+	publishSnapshot(Snapshot{
+		ServerID:   sc.serverID,
+		Players:    playerCount,
+		MaxPlayers: maxPlayers,
+		Map:        mapName,
+		GameType:   gametype,
+		At:         time.Now(),
+	})
+	return nil
 }
 
 func (sc *StatsCollector) collectSystemStats() error {
 	// Get meminfo
-	meminfoResp, err := sc.rcon.SendCommand("meminfo")
+	meminfoResp, err := sc.rcon.SendCommandWithPriority("meminfo", rcon.PriorityStats)
 	if err != nil {
 		return fmt.Errorf("failed to get meminfo: %w", err)
 	}
@@ -172,43 +211,79 @@ func (sc *StatsCollector) collectSystemStats() error {
 
 	cpuUsage := 0.0 // CPU usage not available in these commands
 
-	return models.CreateSystemStats(cpuUsage, memoryUsed, memoryTotal)
+	return models.CreateSystemStats(cpuUsage, memoryUsed, memoryTotal, sc.serverID)
 }
 
 func (sc *StatsCollector) collectPlayerStats() error {
 	// Get status
-	resp, err := sc.rcon.SendCommand("status")
+	status, err := sc.rcon.StatusWithPriority(rcon.PriorityStats)
 	if err != nil {
 		return fmt.Errorf("failed to get status: %w", err)
 	}
 
+	now := time.Now()
+	since := sc.lastPlayerStats
+	if since.IsZero() {
+		since = now.Add(-1 * time.Minute)
+	}
+	sc.lastPlayerStats = now
+
+	// Cap playtime so a long outage isn't credited to whoever is online after it
+	elapsed := now.Sub(since)
+	if elapsed > 2*time.Minute {
+		elapsed = 2 * time.Minute
+	}
+
+	totalKills, totalDeaths, err := models.CountKillsSince(sc.serverID, since)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to count kills: %v", err))
+	}
+
 	// Parse player stats
-	totalKills := 0
-	totalDeaths := 0
 	totalPing := 0
 	totalScore := 0
 	playerCount := 0
 
-	lines := strings.Split(resp, "\n")
-	for _, line := range lines {
-		// Skip header lines
-		if strings.Contains(line, "num") || strings.Contains(line, "---") || len(strings.TrimSpace(line)) == 0 {
+	var sessions []models.SessionSample
+
+	// Connecting and zombie slots have no meaningful ping or score
+	for _, player := range status.Players {
+		if player.State != rcon.PlayerStateActive {
 			continue
 		}
+		totalScore += player.Score
+		totalPing += player.Ping
+		playerCount++
 
-		// Parse player line (format: num score ping guid name lastmsg address)
-		fields := strings.Fields(line)
-		if len(fields) >= 3 {
-			if score, err := strconv.Atoi(fields[1]); err == nil {
-				totalScore += score
-				playerCount++
-			}
-			if ping, err := strconv.Atoi(fields[2]); err == nil {
-				totalPing += ping
+		if player.IsBot || player.Uuid == "" {
+			continue
+		}
+
+		sessions = append(sessions, models.SessionSample{
+			GUID: player.Uuid,
+			Name: player.StrippedName,
+			IP:   player.Address,
+			Slot: player.ID,
+			Ping: player.Ping,
+		})
+		models.CreateOrUpdateInGamePlayerOnServer(player.Uuid, player.StrippedName, sc.serverID)
+		if err := models.RecordPlayerIP(player.Uuid, player.Address, sc.serverID); err != nil {
+			logger.Error(fmt.Sprintf("Failed to record IP for %s: %v", player.StrippedName, err))
+		}
+
+		if sc.serverID != nil {
+			if err := models.AddPlaytime(player.Uuid, player.StrippedName, *sc.serverID, int(elapsed.Seconds())); err != nil {
+				logger.Error(fmt.Sprintf("Failed to record playtime for %s: %v", player.StrippedName, err))
 			}
 		}
 	}
 
+	// Sessions not refreshed for a few polls were left open by a crash or a
+	// panel restart and are closed rather than extended
+	if err := models.ReconcilePlayerSessions(sc.serverID, sessions, SessionStaleAfter); err != nil {
+		logger.Error(fmt.Sprintf("Failed to reconcile player sessions: %v", err))
+	}
+
 	avgPing := 0.0
 	avgScore := 0.0
 	if playerCount > 0 {
@@ -216,31 +291,10 @@ func (sc *StatsCollector) collectPlayerStats() error {
 		avgScore = float64(totalScore) / float64(playerCount)
 	}
 
-	return models.CreatePlayerStats(totalKills, totalDeaths, avgPing, avgScore)
+	return models.CreatePlayerStats(totalKills, totalDeaths, avgPing, avgScore, sc.serverID)
 }
 
 // Helper functions
-func parsePlayerCount(status string) int {
-	count := 0
-	lines := strings.Split(status, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		// Skip empty lines, headers, and separators
-		if len(line) == 0 || strings.Contains(line, "num") || strings.Contains(line, "---") || strings.Contains(line, "map:") || strings.Contains(line, "players") {
-			continue
-		}
-		// Player lines start with a number (slot ID)
-		fields := strings.Fields(line)
-		if len(fields) >= 7 {
-			// Validate first field is a number (slot ID)
-			if _, err := strconv.Atoi(fields[0]); err == nil {
-				count++
-			}
-		}
-	}
-	return count
-}
-
 func parseCvarInt(response, cvar string, defaultValue int) int {
 	lines := strings.Split(response, "\n")
 	for _, line := range lines {
@@ -0,0 +1,250 @@
+package watcher
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/logger"
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultPollInterval is how often the tailer checks the file when polling
+	DefaultPollInterval = 500 * time.Millisecond
+
+	// notifyFallbackInterval is how often the file is still checked while
+	// using fsnotify, in case an event is missed (network shares, editors
+	// that replace the file, etc.)
+	notifyFallbackInterval = 5 * time.Second
+
+	// maxPartialLine caps how much of an unterminated line is buffered
+	maxPartialLine = 64 * 1024
+)
+
+// TailOptions configures a Tailer
+type TailOptions struct {
+	// PollInterval is the polling period, DefaultPollInterval if zero
+	PollInterval time.Duration
+
+	// UseFsnotify waits for filesystem events instead of polling. Falls back
+	// to polling if the watcher can't be created.
+	UseFsnotify bool
+
+	// FromStart reads the existing file contents instead of starting at the end
+	FromStart bool
+}
+
+// Tailer follows a log file like `tail -F`: it emits every new line in order,
+// reopens the file when it is rotated or truncated and waits for it to come
+// back if it disappears.
+type Tailer struct {
+	path string
+	opts TailOptions
+
+	file    *os.File
+	info    os.FileInfo
+	offset  int64
+	buf     lineBuffer
+	missing bool
+}
+
+// NewTailer creates a tailer for path
+func NewTailer(path string, opts TailOptions) *Tailer {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	return &Tailer{path: path, opts: opts}
+}
+
+// Run emits lines on the returned channel until stop is closed. A nil stop
+// channel runs forever.
+func (t *Tailer) Run(stop <-chan struct{}) <-chan FileChangeEvent {
+	lines := make(chan FileChangeEvent)
+
+	go func() {
+		defer close(lines)
+		defer t.close()
+
+		if err := t.open(!t.opts.FromStart); err != nil {
+			t.logMissing(err)
+		}
+
+		var events <-chan fsnotify.Event
+		var errs <-chan error
+		interval := t.opts.PollInterval
+
+		if t.opts.UseFsnotify {
+			watcher, err := fsnotify.NewWatcher()
+			if err == nil {
+				err = watcher.Add(filepath.Dir(t.path))
+			}
+			if err != nil {
+				logger.Error("fsnotify unavailable, falling back to polling", zap.String("path", t.path), zap.Error(err))
+				if watcher != nil {
+					watcher.Close()
+				}
+			} else {
+				defer watcher.Close()
+				events = watcher.Events
+				errs = watcher.Errors
+				interval = notifyFallbackInterval
+			}
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if !t.poll(lines, stop) {
+				return
+			}
+
+			select {
+			case <-ticker.C:
+			case _, ok := <-events:
+				// Any change in the directory triggers a check, which also
+				// covers rotations that rename or recreate the file
+				if !ok {
+					events = nil
+				}
+			case err, ok := <-errs:
+				if !ok {
+					errs = nil
+					continue
+				}
+				logger.Error("fsnotify error", zap.String("path", t.path), zap.Error(err))
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return lines
+}
+
+// poll reads any new lines and handles rotation. Returns false if stop was closed.
+func (t *Tailer) poll(lines chan<- FileChangeEvent, stop <-chan struct{}) bool {
+	if t.file == nil {
+		// The file appeared after being missing, so everything in it is new
+		if err := t.open(false); err != nil {
+			t.logMissing(err)
+			return true
+		}
+	}
+
+	info, statErr := os.Stat(t.path)
+
+	switch {
+	case statErr != nil:
+		// Deleted or moved away; drain what is left of the old file and wait
+		// for a new one
+		if !t.drain(lines, stop) {
+			return false
+		}
+		t.logMissing(statErr)
+		t.close()
+		return true
+
+	case !os.SameFile(t.info, info):
+		// Rotated: finish the old file, then start the new one from the top
+		if !t.drain(lines, stop) {
+			return false
+		}
+		logger.Info("Log file rotated, reopening", zap.String("path", t.path))
+		t.close()
+		if err := t.open(false); err != nil {
+			t.logMissing(err)
+			return true
+		}
+
+	case info.Size() < t.offset:
+		// Truncated in place
+		logger.Info("Log file truncated, reading from start", zap.String("path", t.path))
+		if _, err := t.file.Seek(0, io.SeekStart); err != nil {
+			logger.Error("Failed to rewind log file", zap.String("path", t.path), zap.Error(err))
+			t.close()
+			return true
+		}
+		t.offset = 0
+		t.buf.reset()
+	}
+
+	t.info = info
+	return t.drain(lines, stop)
+}
+
+// drain reads from the current offset to EOF and emits complete lines
+func (t *Tailer) drain(lines chan<- FileChangeEvent, stop <-chan struct{}) bool {
+	if t.file == nil {
+		return true
+	}
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := t.file.Read(buf)
+		if n > 0 {
+			t.offset += int64(n)
+			if !send(lines, t.path, t.buf.split(buf[:n]), stop) {
+				return false
+			}
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				logger.Error("Failed to read log file", zap.String("path", t.path), zap.Error(err))
+			}
+			return true
+		}
+	}
+}
+
+func (t *Tailer) open(seekEnd bool) error {
+	file, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	var offset int64
+	if seekEnd {
+		offset, err = file.Seek(0, io.SeekEnd)
+		if err != nil {
+			file.Close()
+			return err
+		}
+	}
+
+	if t.missing {
+		logger.Info("Log file available again", zap.String("path", t.path))
+	}
+
+	t.file = file
+	t.info = info
+	t.offset = offset
+	t.buf.reset()
+	t.missing = false
+	return nil
+}
+
+func (t *Tailer) close() {
+	if t.file != nil {
+		t.file.Close()
+		t.file = nil
+	}
+}
+
+// logMissing logs once per outage rather than on every poll
+func (t *Tailer) logMissing(err error) {
+	if !t.missing {
+		logger.Error("Log file unavailable, waiting for it to appear", zap.String("path", t.path), zap.Error(err))
+		t.missing = true
+	}
+}
@@ -0,0 +1,142 @@
+package watcher
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/ethanburkett/goadmin/app/logger"
+	"go.uber.org/zap"
+)
+
+// oobHeader prefixes connectionless packets from Quake 3 based servers
+var oobHeader = []byte{0xff, 0xff, 0xff, 0xff}
+
+// resolveRetry is how long to wait before resolving the sender again after
+// a failed lookup. Datagrams are dropped until it resolves.
+const resolveRetry = 30 * time.Second
+
+// UDPSource listens for log lines forwarded by a game server or a log
+// forwarding script. Each datagram holds one or more whole lines.
+type UDPSource struct {
+	address  string
+	fromHost string
+}
+
+// NewUDPSource creates a source listening on address. If fromHost is set,
+// datagrams from any other host are dropped.
+func NewUDPSource(address, fromHost string) *UDPSource {
+	return &UDPSource{address: address, fromHost: fromHost}
+}
+
+func (s *UDPSource) String() string {
+	return "udp " + s.address
+}
+
+func (s *UDPSource) Lines(stop <-chan struct{}) <-chan FileChangeEvent {
+	lines := make(chan FileChangeEvent)
+
+	go func() {
+		defer close(lines)
+
+		conn, err := s.listen(stop)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		allowed, resolved := s.allowedIPs()
+		resolvedAt := time.Now()
+		buf := make([]byte, 64*1024)
+
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			if !resolved && time.Since(resolvedAt) >= resolveRetry {
+				allowed, resolved = s.allowedIPs()
+				resolvedAt = time.Now()
+			}
+
+			// Wake up regularly so stop is noticed without traffic
+			conn.SetReadDeadline(time.Now().Add(time.Second))
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() {
+					continue
+				}
+				logger.Error("UDP log source read failed", zap.String("address", s.address), zap.Error(err))
+				return
+			}
+
+			if s.fromHost != "" && !ipAllowed(addr, allowed) {
+				continue
+			}
+
+			data := bytes.TrimPrefix(buf[:n], oobHeader)
+			data = bytes.TrimPrefix(data, []byte("print\n"))
+
+			// Datagrams are never split, so an unterminated tail is a whole line
+			var lb lineBuffer
+			chunk := lb.split(append(data, '\n'))
+			if !send(lines, s.String(), chunk, stop) {
+				return
+			}
+		}
+	}()
+
+	return lines
+}
+
+// listen binds the socket, retrying while the address is in use (e.g. the
+// previous instance is still shutting down during a reload)
+func (s *UDPSource) listen(stop <-chan struct{}) (net.PacketConn, error) {
+	for {
+		conn, err := net.ListenPacket("udp", s.address)
+		if err == nil {
+			logger.Info("Listening for UDP log lines", zap.String("address", s.address))
+			return conn, nil
+		}
+		logger.Error("Failed to listen for UDP log lines", zap.String("address", s.address), zap.Error(err))
+
+		select {
+		case <-time.After(5 * time.Second):
+		case <-stop:
+			return nil, err
+		}
+	}
+}
+
+// allowedIPs resolves fromHost. resolved is false when the lookup failed,
+// in which case no sender is allowed until it is retried.
+func (s *UDPSource) allowedIPs() (ips []net.IP, resolved bool) {
+	if s.fromHost == "" {
+		return nil, true
+	}
+
+	ips, err := net.LookupIP(s.fromHost)
+	if err != nil || len(ips) == 0 {
+		logger.Error("Failed to resolve UDP log sender, dropping log lines until it resolves",
+			zap.String("host", s.fromHost), zap.Error(err))
+		return nil, false
+	}
+	return ips, true
+}
+
+func ipAllowed(addr net.Addr, allowed []net.IP) bool {
+	udpAddr, ok := addr.(*net.UDPAddr)
+	if !ok {
+		return false
+	}
+	for _, ip := range allowed {
+		if ip.Equal(udpAddr.IP) {
+			return true
+		}
+	}
+	return false
+}
@@ -118,7 +118,7 @@ func (d *Dispatcher) deliverWebhook(webhook *models.Webhook, delivery *models.We
 
 	// Sign payload if secret is configured
 	if webhook.Secret != "" {
-		signature := d.signPayload(payloadBytes, webhook.Secret)
+		signature := SignPayload(payloadBytes, webhook.Secret)
 		req.Header.Set("X-Webhook-Signature", signature)
 	}
 
@@ -230,13 +230,18 @@ func (d *Dispatcher) scheduleRetry(webhook *models.Webhook, delivery *models.Web
 		delivery.AttemptCount+1, webhook.URL, nextRetry.Format(time.RFC3339)))
 }
 
-// signPayload creates HMAC SHA256 signature
-func (d *Dispatcher) signPayload(payload []byte, secret string) string {
+// SignPayload creates an HMAC SHA256 signature in the form "sha256=<hex>"
+func SignPayload(payload []byte, secret string) string {
 	mac := hmac.New(sha256.New, []byte(secret))
 	mac.Write(payload)
 	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
 }
 
+// VerifyPayload checks a signature made by SignPayload in constant time
+func VerifyPayload(payload []byte, secret, signature string) bool {
+	return hmac.Equal([]byte(SignPayload(payload, secret)), []byte(signature))
+}
+
 // ProcessRetries processes pending webhook deliveries
 func (d *Dispatcher) ProcessRetries() error {
 	deliveries, err := models.GetPendingDeliveries()
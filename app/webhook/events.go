@@ -0,0 +1,27 @@
+package webhook
+
+import (
+	"github.com/ethanburkett/goadmin/app/events"
+	"github.com/ethanburkett/goadmin/app/logger"
+	"github.com/ethanburkett/goadmin/app/models"
+	"go.uber.org/zap"
+)
+
+// HandleEvent delivers an event from the event stream to the webhooks
+// subscribed to its type. Damage and weapon events are too frequent to send.
+func (d *Dispatcher) HandleEvent(event events.Event) {
+	if event.Type.IsHighVolume() {
+		return
+	}
+
+	data := event.DataMap()
+	data["event_id"] = event.ID
+	data["version"] = event.Version
+	if event.ServerID != nil {
+		data["server_id"] = *event.ServerID
+	}
+
+	if err := d.Dispatch(models.WebhookEvent(event.Type), data); err != nil {
+		logger.Error("Failed to dispatch event webhook", zap.String("event", string(event.Type)), zap.Error(err))
+	}
+}
@@ -18,6 +18,8 @@ export interface InGamePlayer {
   groupId: number | null;
   group?: Group;
   enabled: boolean;
+  firstSeen: string;
+  lastSeen: string;
   createdAt: string;
   updatedAt: string;
 }
@@ -141,6 +141,7 @@ export const WEBHOOK_EVENTS = [
   { value: "player.banned", label: "Player Banned" },
   { value: "player.unbanned", label: "Player Unbanned" },
   { value: "player.kicked", label: "Player Kicked" },
+  { value: "player.flagged", label: "Flagged Player Joined" },
   { value: "report.created", label: "Report Created" },
   { value: "report.actioned", label: "Report Actioned" },
   { value: "user.approved", label: "User Approved" },
@@ -148,4 +149,15 @@ export const WEBHOOK_EVENTS = [
   { value: "server.online", label: "Server Online" },
   { value: "server.offline", label: "Server Offline" },
   { value: "security.alert", label: "Security Alert" },
+  { value: "automation.triggered", label: "Automation Rule Fired" },
+  { value: "player.connect", label: "Player Connected" },
+  { value: "player.disconnect", label: "Player Disconnected" },
+  { value: "player.chat", label: "Chat Message" },
+  { value: "player.team_chat", label: "Team Chat Message" },
+  { value: "player.kill", label: "Player Killed" },
+  { value: "player.action", label: "Gametype Action" },
+  { value: "player.name_change", label: "Name Changed" },
+  { value: "map.start", label: "Map Started" },
+  { value: "map.end", label: "Map Ended" },
+  { value: "round.end", label: "Round Ended" },
 ] as const;
@@ -99,13 +99,12 @@ func (p *AdvancedExamplePlugin) Start() error {
 			Handler: func(playerName, playerGUID string, args []string) error {
 				if p.ctx.RCONAPI != nil {
 					// This demonstrates resource-aware operation
-					statusData, err := p.ctx.RCONAPI.GetStatus()
+					status, err := p.ctx.RCONAPI.GetStatus()
 					if err == nil {
-						msg := fmt.Sprintf(`tell %s "^2Server Info: ^7%v"`, playerName, statusData)
-						p.ctx.RCONAPI.SendCommand(msg)
+						p.ctx.RCONAPI.Tell(playerName, fmt.Sprintf("^2Server Info: ^7%s on %s (%d players)",
+							status.Hostname, status.Map, len(status.Players)))
 					} else {
-						msg := fmt.Sprintf(`tell %s "^1Failed to get server info"`, playerName)
-						p.ctx.RCONAPI.SendCommand(msg)
+						p.ctx.RCONAPI.Tell(playerName, "^1Failed to get server info")
 					}
 				}
 				return nil
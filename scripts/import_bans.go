@@ -0,0 +1,107 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ethanburkett/goadmin/app/banio"
+	"github.com/ethanburkett/goadmin/app/config"
+	"github.com/ethanburkett/goadmin/app/database"
+	"github.com/ethanburkett/goadmin/app/logger"
+	"github.com/ethanburkett/goadmin/app/models"
+)
+
+// Imports a ban list from B3, CoD4x or a plain GUID list into GoAdmin's ban
+// list, or exports the bans in effect with -export. Run from the directory
+// holding data.sqlite.
+func main() {
+	format := flag.String("format", "", "File format: "+strings.Join(banio.Formats, ", "))
+	file := flag.String("file", "", "Ban file to import")
+	dryRun := flag.Bool("dry-run", false, "Show what would be imported without saving")
+	reason := flag.String("reason", "", "Reason for entries that have none")
+	export := flag.String("export", "", "Write the bans in effect to this file instead of importing")
+	verbose := flag.Bool("v", false, "List every imported and skipped entry")
+	flag.Parse()
+
+	if !banio.ValidFormat(*format) {
+		log.Fatalf("Unknown -format %q, expected one of: %s", *format, strings.Join(banio.Formats, ", "))
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+	logger.Init("import_bans", cfg.Environment == "development")
+	database.Init()
+
+	if !database.DB.Migrator().HasTable(&models.Ban{}) {
+		log.Fatal("Ban tables not found - start GoAdmin once to apply migrations")
+	}
+
+	if *export != "" {
+		runExport(*format, *export)
+		return
+	}
+
+	if *file == "" {
+		log.Fatal("-file is required when importing")
+	}
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("Failed to open %s: %v", *file, err)
+	}
+	defer f.Close()
+
+	report, err := banio.Import(*format, f, banio.Options{
+		DryRun:        *dryRun,
+		DefaultReason: *reason,
+		ImportedBy:    "import_bans",
+	})
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+
+	if *verbose {
+		for _, ban := range report.Bans {
+			fmt.Printf("  + %-8s %-40s %s\n", ban.Type, ban.Value, ban.Reason)
+		}
+	}
+	if len(report.Skipped) > 0 {
+		fmt.Printf("\nSkipped %d entries:\n", len(report.Skipped))
+		for _, s := range report.Skipped {
+			fmt.Printf("  line %-6d %-40s %s\n", s.Line, s.Value, s.Reason)
+		}
+	}
+
+	verb := "Imported"
+	if report.DryRun {
+		verb = "Would import"
+	}
+	fmt.Printf("\n%s %d of %d entries (%d duplicates, %d skipped)\n",
+		verb, report.Imported, report.Total, report.Duplicates, len(report.Skipped))
+}
+
+func runExport(format, path string) {
+	bans, err := models.GetBansInEffect()
+	if err != nil {
+		log.Fatalf("Failed to load bans: %v", err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		log.Fatalf("Failed to create %s: %v", path, err)
+	}
+	defer f.Close()
+
+	written, err := banio.Export(format, f, bans)
+	if err != nil {
+		log.Fatalf("Export failed: %v", err)
+	}
+	fmt.Printf("Exported %d of %d bans to %s\n", written, len(bans), path)
+}
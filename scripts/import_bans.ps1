@@ -0,0 +1,32 @@
+# Ban List Import Script
+# Usage: .\import_bans.ps1 -Format <b3|cod4x|guids|json> -File <path> [-DryRun] [-Reason <text>]
+#        .\import_bans.ps1 -Format <b3|cod4x|guids|json> -Export <path>
+
+param(
+    [Parameter(Mandatory=$true)]
+    [string]$Format,
+    [string]$File = "",
+    [string]$Reason = "",
+    [string]$Export = "",
+    [switch]$DryRun
+)
+
+$goArgs = @("-format", $Format)
+if ($Export) {
+    Write-Host "Exporting bans..." -ForegroundColor Cyan
+    $goArgs += @("-export", $Export)
+} else {
+    Write-Host "Importing bans from $File..." -ForegroundColor Cyan
+    $goArgs += @("-file", $File)
+    if ($Reason) { $goArgs += @("-reason", $Reason) }
+    if ($DryRun) { $goArgs += "-dry-run" }
+}
+
+go run .\scripts\import_bans.go @goArgs
+
+if ($LASTEXITCODE -eq 0) {
+    Write-Host "`n✅ Done!" -ForegroundColor Green
+} else {
+    Write-Host "`n❌ Ban import/export failed!" -ForegroundColor Red
+    exit 1
+}
@@ -0,0 +1,104 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Ships games_mp.log from a game host to a GoAdmin server configured with the
+// "http" log source. Appended data is posted in chunks to /ingest/logs/<id>
+// using the token from POST /servers/<id>/log-token. The file is reread from
+// the start if it shrinks (rotation). Failed chunks are retried.
+func main() {
+	file := flag.String("file", "games_mp.log", "Log file to ship")
+	url := flag.String("url", "http://localhost:8080", "GoAdmin API base URL")
+	serverID := flag.Uint("server", 1, "GoAdmin server ID")
+	token := flag.String("token", os.Getenv("GOADMIN_LOG_TOKEN"), "Log token (or GOADMIN_LOG_TOKEN)")
+	interval := flag.Duration("interval", time.Second, "How often to check for new data")
+	flag.Parse()
+
+	if *token == "" {
+		log.Fatal("A log token is required")
+	}
+
+	endpoint := fmt.Sprintf("%s/ingest/logs/%d", *url, *serverID)
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	// Start at the end; older lines were written before the shipper ran
+	var offset int64
+	if info, err := os.Stat(*file); err == nil {
+		offset = info.Size()
+	}
+
+	log.Printf("Shipping %s to %s", *file, endpoint)
+
+	for range time.Tick(*interval) {
+		info, err := os.Stat(*file)
+		if err != nil {
+			continue
+		}
+		if info.Size() < offset {
+			log.Printf("Log shrank, shipping from start")
+			offset = 0
+		}
+		if info.Size() == offset {
+			continue
+		}
+
+		chunk, err := readChunk(*file, offset, 512*1024)
+		if err != nil {
+			log.Printf("Read failed: %v", err)
+			continue
+		}
+
+		if err := post(client, endpoint, *token, chunk); err != nil {
+			log.Printf("Upload failed, will retry: %v", err)
+			continue
+		}
+		offset += int64(len(chunk))
+	}
+}
+
+// readChunk reads up to max bytes from offset
+func readChunk(path string, offset, max int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(io.LimitReader(f, max))
+}
+
+func post(client *http.Client, endpoint, token string, chunk []byte) error {
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(chunk))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("X-Log-Token", token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
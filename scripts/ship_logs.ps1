@@ -0,0 +1,16 @@
+# Log Shipper Script
+# Usage: .\ship_logs.ps1 -File <path\to\games_mp.log> -Url <http://goadmin:8080> -Server <id> -Token <token>
+
+param(
+    [Parameter(Mandatory=$true)]
+    [string]$File,
+    [string]$Url = "http://localhost:8080",
+    [int]$Server = 1,
+    [Parameter(Mandatory=$true)]
+    [string]$Token
+)
+
+Write-Host "Shipping $File to $Url (server $Server)..." -ForegroundColor Cyan
+
+# Run the shipper from the project root
+go run .\scripts\ship_logs.go --file "$File" --url "$Url" --server $Server --token "$Token"